@@ -0,0 +1,255 @@
+package domain
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Interpolation はキーフレーム間の補間方法を表します
+type Interpolation int
+
+const (
+	// InterpolationLinear 線形補間（四元数の場合は球面線形補間）
+	InterpolationLinear Interpolation = iota
+	// InterpolationStep 次のキーフレームまで値を変えない
+	InterpolationStep
+	// InterpolationBezier 3次ベジェ曲線で補間の進み具合を調整する
+	InterpolationBezier
+)
+
+// CubicBezier は補間の進み具合を表す3次ベジェ曲線です（CSSのcubic-bezierと同じ）
+// 始点(0,0)と終点(1,1)は固定で、2つの制御点(X1,Y1), (X2,Y2)を指定します
+// Xは時間の進み具合、Yは値の進み具合を表します
+type CubicBezier struct {
+	X1, Y1, X2, Y2 float64
+}
+
+// Evaluate は時間の進み具合s（0〜1）に対する値の進み具合を返します
+func (b CubicBezier) Evaluate(s float64) float64 {
+	curve := func(p1, p2, u float64) float64 {
+		v := 1 - u
+		return 3*v*v*u*p1 + 3*v*u*u*p2 + u*u*u
+	}
+
+	// X(u) = s となる u を二分法で求める（X1, X2が0〜1ならX(u)は単調増加）
+	low, high := 0.0, 1.0
+	u := s
+	for i := 0; i < 50; i++ {
+		x := curve(b.X1, b.X2, u)
+		if math.Abs(x-s) < 1e-9 {
+			break
+		}
+		if x < s {
+			low = u
+		} else {
+			high = u
+		}
+		u = (low + high) / 2
+	}
+
+	return curve(b.Y1, b.Y2, u)
+}
+
+// Keyframe は時刻と値の組です
+type Keyframe[T any] struct {
+	Time  time.Duration
+	Value T
+	// Interpolation このキーフレームから次のキーフレームまでの補間方法
+	Interpolation Interpolation
+	// Easing InterpolationBezierの場合に使用する補間の進み具合
+	Easing CubicBezier
+}
+
+// Track は時刻の順に並んだキーフレームの列です
+// 順番が揃っていないキーフレームからはNewTrackで作ります
+type Track[T any] []Keyframe[T]
+
+// NewTrack はキーフレームを時刻の順に並べ替えたトラックを返します
+// 時刻が同じキーフレームは指定した順番のまま残します。元のキーフレームは変更しません
+func NewTrack[T any](keyframes ...Keyframe[T]) Track[T] {
+	track := make(Track[T], len(keyframes))
+	copy(track, keyframes)
+	sort.SliceStable(track, func(i, j int) bool {
+		return track[i].Time < track[j].Time
+	})
+	return track
+}
+
+// Evaluate は時刻tの値を返します
+// 最初のキーフレームより前は最初の値、最後のキーフレームより後は最後の値になります
+// キーフレームが1つもない場合はfalseを返します
+func (tr Track[T]) Evaluate(t time.Duration, lerp func(from, to T, s float64) T) (bool, T) {
+	if len(tr) == 0 {
+		var zero T
+		return false, zero
+	}
+
+	if t <= tr[0].Time {
+		return true, tr[0].Value
+	}
+	last := tr[len(tr)-1]
+	if t >= last.Time {
+		return true, last.Value
+	}
+
+	// tを挟む2つのキーフレームを探す
+	nextIndex := sort.Search(len(tr), func(i int) bool {
+		return tr[i].Time > t
+	})
+	from := tr[nextIndex-1]
+	to := tr[nextIndex]
+
+	s := float64(t-from.Time) / float64(to.Time-from.Time)
+	switch from.Interpolation {
+	case InterpolationStep:
+		return true, from.Value
+	case InterpolationBezier:
+		s = from.Easing.Evaluate(s)
+	}
+	return true, lerp(from.Value, to.Value, s)
+}
+
+// LerpFloat は2つの値を線形補間します
+func LerpFloat(from, to float64, s float64) float64 {
+	return from + (to-from)*s
+}
+
+// LerpVector3D は2つのベクトルを線形補間します
+func LerpVector3D(from, to Vector3D, s float64) Vector3D {
+	return from.Add(to.Sub(from).MulScalar(s))
+}
+
+// Slerp は2つの四元数を球面線形補間します
+// 常に短い方の弧に沿って補間します
+func Slerp(from, to Quaternion, s float64) Quaternion {
+	from = from.Normalize()
+	to = to.Normalize()
+
+	dot := from.Dot(to)
+	if dot < 0 {
+		// qと-qは同じ回転を表すため、近い方を使う
+		to = Quaternion{W: -to.W, X: -to.X, Y: -to.Y, Z: -to.Z}
+		dot = -dot
+	}
+
+	if dot > 1-1e-9 {
+		// ほぼ同じ向きの場合は線形補間で近似する
+		return Quaternion{
+			W: LerpFloat(from.W, to.W, s),
+			X: LerpFloat(from.X, to.X, s),
+			Y: LerpFloat(from.Y, to.Y, s),
+			Z: LerpFloat(from.Z, to.Z, s),
+		}.Normalize()
+	}
+
+	theta := math.Acos(dot)
+	sinTheta := math.Sin(theta)
+	a := math.Sin((1-s)*theta) / sinTheta
+	b := math.Sin(s*theta) / sinTheta
+	return Quaternion{
+		W: a*from.W + b*to.W,
+		X: a*from.X + b*to.X,
+		Y: a*from.Y + b*to.Y,
+		Z: a*from.Z + b*to.Z,
+	}
+}
+
+// ObjectAnimation はオブジェクト（LocatedObject・SceneNode）のアニメーションを表します
+// キーフレームが1つもないトラックは元の値のままになります
+type ObjectAnimation struct {
+	Location Track[Vector3D]
+	Scale    Track[Vector3D]
+	// Rotation オイラー角を線形補間する
+	Rotation Track[Vector3D]
+	// Quaternion 四元数を球面線形補間する。Rotationより優先される
+	Quaternion Track[Quaternion]
+}
+
+// CameraAnimation はカメラのアニメーションを表します
+// キーフレームが1つもないトラックは元の値のままになります
+type CameraAnimation struct {
+	Location       Track[Vector3D]
+	Direction      Track[Vector3D]
+	ApertureRadius Track[float64]
+	FocalDistance  Track[float64]
+	// FieldOfView World.Clipping.FieldOfViewを変更する
+	FieldOfView Track[float64]
+}
+
+// apply はアニメーションの時刻tの値を設定します
+func (a ObjectAnimation) apply(t time.Duration, location, scale, rotation *Vector3D, quaternion **Quaternion) {
+	if ok, v := a.Location.Evaluate(t, LerpVector3D); ok {
+		*location = v
+	}
+	if ok, v := a.Scale.Evaluate(t, LerpVector3D); ok {
+		*scale = v
+	}
+	if ok, v := a.Rotation.Evaluate(t, LerpVector3D); ok {
+		*rotation = v
+	}
+	if ok, v := a.Quaternion.Evaluate(t, Slerp); ok {
+		*quaternion = &v
+	}
+}
+
+// At は時刻tにおけるオブジェクトを返します
+func (l LocatedObject) At(t time.Duration) LocatedObject {
+	if l.Animation != nil {
+		l.Animation.apply(t, &l.Location, &l.Scale, &l.Rotation, &l.Quaternion)
+	}
+	return l
+}
+
+// At は時刻tにおけるノードとその子孫を返します
+func (n SceneNode) At(t time.Duration) SceneNode {
+	if n.Animation != nil {
+		n.Animation.apply(t, &n.Location, &n.Scale, &n.Rotation, &n.Quaternion)
+	}
+	if len(n.Children) > 0 {
+		children := make([]SceneNode, 0, len(n.Children))
+		for _, child := range n.Children {
+			children = append(children, child.At(t))
+		}
+		n.Children = children
+	}
+	return n
+}
+
+// At は時刻tにおけるワールドを返します
+// 元のワールドは変更しないため、同じ時刻を指定すれば常に同じ結果になります
+func (w World) At(t time.Duration) World {
+	if a := w.Camera.Animation; a != nil {
+		if ok, v := a.Location.Evaluate(t, LerpVector3D); ok {
+			w.Camera.Location = v
+		}
+		if ok, v := a.Direction.Evaluate(t, LerpVector3D); ok {
+			w.Camera.Direction = v
+		}
+		if ok, v := a.ApertureRadius.Evaluate(t, LerpFloat); ok {
+			w.Camera.ApertureRadius = v
+		}
+		if ok, v := a.FocalDistance.Evaluate(t, LerpFloat); ok {
+			w.Camera.FocalDistance = v
+		}
+		if ok, v := a.FieldOfView.Evaluate(t, LerpFloat); ok {
+			w.Clipping.FieldOfView = v
+		}
+	}
+
+	locatedObjects := make([]LocatedObject, 0, len(w.LocatedObjects))
+	for _, locatedObj := range w.LocatedObjects {
+		locatedObjects = append(locatedObjects, locatedObj.At(t))
+	}
+	w.LocatedObjects = locatedObjects
+
+	if len(w.Scene) > 0 {
+		scene := make([]SceneNode, 0, len(w.Scene))
+		for _, node := range w.Scene {
+			scene = append(scene, node.At(t))
+		}
+		w.Scene = scene
+	}
+
+	return w
+}
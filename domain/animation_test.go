@@ -0,0 +1,181 @@
+package domain
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCubicBezier_Evaluate(t *testing.T) {
+	// 直線になる制御点では線形補間と同じ
+	linear := CubicBezier{X1: 1.0 / 3, Y1: 1.0 / 3, X2: 2.0 / 3, Y2: 2.0 / 3}
+	assert.InDelta(t, 0.25, linear.Evaluate(0.25), 1e-6)
+	assert.InDelta(t, 0.5, linear.Evaluate(0.5), 1e-6)
+
+	// ease-in-out は始点と終点がゆっくりで、中央では線形と同じ
+	easeInOut := CubicBezier{X1: 0.42, Y1: 0, X2: 0.58, Y2: 1}
+	assert.InDelta(t, 0.0, easeInOut.Evaluate(0), 1e-6)
+	assert.InDelta(t, 0.5, easeInOut.Evaluate(0.5), 1e-6)
+	assert.InDelta(t, 1.0, easeInOut.Evaluate(1), 1e-6)
+	assert.Less(t, easeInOut.Evaluate(0.2), 0.2)
+	assert.Greater(t, easeInOut.Evaluate(0.8), 0.8)
+}
+
+func TestTrack_Evaluate_線形補間(t *testing.T) {
+	track := Track[float64]{
+		{Time: 0, Value: 0},
+		{Time: 2 * time.Second, Value: 10},
+	}
+
+	ok, v := track.Evaluate(500*time.Millisecond, LerpFloat)
+	assert.True(t, ok)
+	assert.InDelta(t, 2.5, v, 1e-9)
+
+	// 範囲外は端の値
+	_, v = track.Evaluate(-time.Second, LerpFloat)
+	assert.Equal(t, 0.0, v)
+	_, v = track.Evaluate(3*time.Second, LerpFloat)
+	assert.Equal(t, 10.0, v)
+}
+
+func TestTrack_Evaluate_キーフレームがない場合(t *testing.T) {
+	ok, _ := Track[float64]{}.Evaluate(time.Second, LerpFloat)
+
+	assert.False(t, ok)
+}
+
+func TestTrack_Evaluate_ステップ補間(t *testing.T) {
+	track := Track[Vector3D]{
+		{Time: 0, Value: Vector3D{0, 0, 0}, Interpolation: InterpolationStep},
+		{Time: time.Second, Value: Vector3D{1, 1, 1}},
+	}
+
+	_, v := track.Evaluate(999*time.Millisecond, LerpVector3D)
+	assert.Equal(t, Vector3D{0, 0, 0}, v)
+
+	_, v = track.Evaluate(time.Second, LerpVector3D)
+	assert.Equal(t, Vector3D{1, 1, 1}, v)
+}
+
+func TestTrack_Evaluate_ベジェ補間(t *testing.T) {
+	easing := CubicBezier{X1: 0.42, Y1: 0, X2: 0.58, Y2: 1}
+	track := Track[float64]{
+		{Time: 0, Value: 0, Interpolation: InterpolationBezier, Easing: easing},
+		{Time: time.Second, Value: 10},
+	}
+
+	_, v := track.Evaluate(200*time.Millisecond, LerpFloat)
+
+	assert.InDelta(t, 10*easing.Evaluate(0.2), v, 1e-9)
+}
+
+func TestNewTrack(t *testing.T) {
+	keyframes := []Keyframe[float64]{
+		{Time: 2 * time.Second, Value: 20},
+		{Time: 0, Value: 0},
+		{Time: time.Second, Value: 10},
+		{Time: 0, Value: 1},
+	}
+
+	track := NewTrack(keyframes...)
+
+	// 時刻の順に並べ替え、時刻が同じキーフレームは元の順番のまま残す
+	assert.Equal(t, Track[float64]{keyframes[1], keyframes[3], keyframes[2], keyframes[0]}, track)
+	assert.Equal(t, 2*time.Second, keyframes[0].Time)
+	_, v := track.Evaluate(1500*time.Millisecond, LerpFloat)
+	assert.InDelta(t, 15.0, v, 1e-9)
+}
+
+func TestSlerp(t *testing.T) {
+	from := NewIdentityQuaternion()
+	to := AxisAngle{Axis: Vector3D{0, 1, 0}, Angle: math.Pi / 2}.Quaternion()
+
+	result := Slerp(from, to, 0.5)
+
+	// 中間は45度回転
+	assertSameQuaternion(t, AxisAngle{Axis: Vector3D{0, 1, 0}, Angle: math.Pi / 4}.Quaternion(), result)
+
+	assertSameQuaternion(t, from, Slerp(from, to, 0))
+	assertSameQuaternion(t, to, Slerp(from, to, 1))
+}
+
+func TestSlerp_短い方の弧で補間すること(t *testing.T) {
+	from := NewIdentityQuaternion()
+	// -qは同じ回転を表す
+	to := AxisAngle{Axis: Vector3D{0, 0, 1}, Angle: math.Pi / 2}.Quaternion()
+	to = Quaternion{W: -to.W, X: -to.X, Y: -to.Y, Z: -to.Z}
+
+	result := Slerp(from, to, 0.5)
+
+	assertSameQuaternion(t, AxisAngle{Axis: Vector3D{0, 0, 1}, Angle: math.Pi / 4}.Quaternion(), result)
+}
+
+func TestWorld_At(t *testing.T) {
+	q0 := NewIdentityQuaternion()
+	q1 := AxisAngle{Axis: Vector3D{0, 1, 0}, Angle: math.Pi / 2}.Quaternion()
+	world := World{
+		Camera: Camera{
+			Location: Vector3D{0, 0, -1},
+			Animation: &CameraAnimation{
+				Location: Track[Vector3D]{
+					{Time: 0, Value: Vector3D{0, 0, 0}},
+					{Time: time.Second, Value: Vector3D{2, 0, 0}},
+				},
+				FieldOfView: Track[float64]{
+					{Time: 0, Value: 1.0},
+					{Time: time.Second, Value: 2.0},
+				},
+			},
+		},
+		LocatedObjects: []LocatedObject{
+			{
+				Scale: Vector3D{1, 1, 1},
+				Animation: &ObjectAnimation{
+					Rotation: Track[Vector3D]{
+						{Time: 0, Value: Vector3D{0, 0, 0}},
+						{Time: time.Second, Value: Vector3D{0, 1, 0}},
+					},
+				},
+			},
+			{
+				Location: Vector3D{5, 0, 0},
+				Scale:    Vector3D{1, 1, 1},
+			},
+		},
+		Scene: []SceneNode{
+			{
+				Name: "parent",
+				Children: []SceneNode{
+					{
+						Name: "child",
+						Animation: &ObjectAnimation{
+							Quaternion: Track[Quaternion]{
+								{Time: 0, Value: q0},
+								{Time: time.Second, Value: q1},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	result := world.At(500 * time.Millisecond)
+
+	assert.Equal(t, Vector3D{1, 0, 0}, result.Camera.Location)
+	assert.InDelta(t, 1.5, result.Clipping.FieldOfView, 1e-9)
+	assert.Equal(t, Vector3D{0, 0.5, 0}, result.LocatedObjects[0].Rotation)
+	// アニメーションのないオブジェクトはそのまま
+	assert.Equal(t, Vector3D{5, 0, 0}, result.LocatedObjects[1].Location)
+	// 子ノードのアニメーションも評価される
+	child := result.FindNode("child")
+	assert.NotNil(t, child.Quaternion)
+	assertSameQuaternion(t, Slerp(q0, q1, 0.5), *child.Quaternion)
+
+	// 元のワールドは変更されない
+	assert.Equal(t, Vector3D{0, 0, -1}, world.Camera.Location)
+	assert.Equal(t, Vector3D{0, 0, 0}, world.LocatedObjects[0].Rotation)
+	assert.Nil(t, world.Scene[0].Children[0].Quaternion)
+}
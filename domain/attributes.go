@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"math"
+)
+
+// VertexAttribute は頂点ごとの属性（色・法線・UV・任意の値など）を表します
+// クリッピングで頂点が作られる場合は、位置と同じ比率で線形補間されます
+type VertexAttribute struct {
+	// Name 属性の名前（"color", "normal", "uv" など）
+	Name string
+	// Size 1頂点あたりの要素数（UVなら2、法線なら3）
+	Size int
+	// Values 頂点の添字番号の順にSize個ずつ並べた値
+	Values []float64
+}
+
+// attributeLayout は値を除いた属性の名前と要素数を返します
+func attributeLayout(attributes []VertexAttribute) []VertexAttribute {
+	if len(attributes) == 0 {
+		return nil
+	}
+	layout := make([]VertexAttribute, 0, len(attributes))
+	for _, attribute := range attributes {
+		layout = append(layout, VertexAttribute{Name: attribute.Name, Size: attribute.Size})
+	}
+	return layout
+}
+
+// newVertexAttributes は頂点ごとに全属性の値を連結した値（VertexAttributeValues）から属性を作ります
+// layoutは属性の名前と要素数です
+func newVertexAttributes(layout []VertexAttribute, values [][]float64) []VertexAttribute {
+	if len(layout) == 0 {
+		return nil
+	}
+	attributes := make([]VertexAttribute, 0, len(layout))
+	offset := 0
+	for _, l := range layout {
+		attribute := VertexAttribute{Name: l.Name, Size: l.Size, Values: make([]float64, 0, len(values)*l.Size)}
+		for _, v := range values {
+			attribute.Values = append(attribute.Values, v[offset:offset+l.Size]...)
+		}
+		attributes = append(attributes, attribute)
+		offset += l.Size
+	}
+	return attributes
+}
+
+// normalizeNormals は法線の属性（AttributeNormal）の値を正規化し直します
+// 補間した法線は長さが1にならないため、頂点を補間して作った後に使います
+func normalizeNormals(attributes []VertexAttribute) {
+	for _, attribute := range attributes {
+		if attribute.Name != AttributeNormal || attribute.Size != 3 {
+			continue
+		}
+		for i := 0; i+3 <= len(attribute.Values); i += 3 {
+			n := Vector3D{attribute.Values[i], attribute.Values[i+1], attribute.Values[i+2]}.Normalize()
+			copy(attribute.Values[i:i+3], n[:])
+		}
+	}
+}
+
+// VertexAttributeValues はi番目の頂点の全属性の値を連結して返します
+// 属性を持たない場合はnilを返します
+func (o Object) VertexAttributeValues(i int) []float64 {
+	if len(o.Attributes) == 0 {
+		return nil
+	}
+	values := make([]float64, 0, len(o.Attributes)*3)
+	for _, attribute := range o.Attributes {
+		values = append(values, attribute.Values[i*attribute.Size:(i+1)*attribute.Size]...)
+	}
+	return values
+}
+
+// Attribute は名前が一致する属性を返します
+// 見つからない場合はfalseを返します
+func (o Object) Attribute(name string) (bool, VertexAttribute) {
+	for _, attribute := range o.Attributes {
+		if attribute.Name == name {
+			return true, attribute
+		}
+	}
+	return false, VertexAttribute{}
+}
+
+// equalAttributeValues は2つの属性の値がepsilon未満の差で一致するかを判定します
+func equalAttributeValues(a, b []float64, epsilon float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(a[i]-b[i]) >= epsilon {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,152 @@
+package domain
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newAttributeTestViewVolume はクリッピングのテストで使うビューボリュームを返します
+func newAttributeTestViewVolume() ViewVolume {
+	world := World{
+		Viewport: Viewport{Width: 16, Height: 12},
+		Clipping: Clipping{NearDistance: 0.1, FarDistance: 10.0, FieldOfView: math.Pi / 4},
+	}
+	return world.ViewVolume()
+}
+
+// newAttributeTestPlane はz=2に置いた平面に、位置から計算できる頂点属性を持たせたオブジェクトを返します
+// uvは (x, y)、weightは x + 2y + 3 です
+func newAttributeTestPlane(width, height float64) Object {
+	obj := NewPlaneObject(width, height, color.RGBA{255, 0, 0, 255})
+	obj.VertexMatrix.TransformTranslate(0, 0, 2)
+	uv := VertexAttribute{Name: "uv", Size: 2}
+	weight := VertexAttribute{Name: "weight", Size: 1}
+	obj.VertexMatrix.EachVertex(func(_ int, v Vertex) bool {
+		uv.Values = append(uv.Values, v.X(), v.Y())
+		weight.Values = append(weight.Values, v.X()+2*v.Y()+3)
+		return true
+	})
+	obj.Attributes = []VertexAttribute{uv, weight}
+	return obj
+}
+
+func TestObject_VertexAttributeValues(t *testing.T) {
+	obj := newAttributeTestPlane(2, 2)
+
+	assert.Equal(t, []float64{-1, 1, 4}, obj.VertexAttributeValues(0))
+	assert.Nil(t, NewPlaneObject(2, 2, color.RGBA{}).VertexAttributeValues(0))
+}
+
+func TestObject_Attribute(t *testing.T) {
+	obj := newAttributeTestPlane(2, 2)
+
+	ok, weight := obj.Attribute("weight")
+	assert.True(t, ok)
+	assert.Equal(t, 1, weight.Size)
+
+	ok, _ = obj.Attribute("normal")
+	assert.False(t, ok)
+}
+
+func TestViewVolume_ClipPolygon_頂点属性を補間すること(t *testing.T) {
+	v := newAttributeTestViewVolume()
+	// 前方クリップ面（z = 0.1）をまたぐ三角形。属性はzに比例する
+	polygon := []ClipVertex{
+		{Position: Vector4D{0, 0, 1, 1}, Attributes: []float64{10}},
+		{Position: Vector4D{0.01, 0, -1, 1}, Attributes: []float64{-10}},
+		{Position: Vector4D{0, 0.01, 1, 1}, Attributes: []float64{10}},
+	}
+
+	result := v.ClipPolygon(polygon)
+
+	assert.Len(t, result, 4)
+	for _, vertex := range result {
+		assert.GreaterOrEqual(t, vertex.Position[2], 0.1-1e-9)
+		assert.InDelta(t, 10*vertex.Position[2], vertex.Attributes[0], 1e-9)
+	}
+}
+
+func TestViewVolume_ClipObject_頂点属性を補間すること(t *testing.T) {
+	v := newAttributeTestViewVolume()
+	obj := newAttributeTestPlane(10, 10)
+
+	result := v.ClipObject(obj)
+
+	assert.Equal(t, []VertexAttribute{{Name: "uv", Size: 2}, {Name: "weight", Size: 1}}, attributeLayout(result.Attributes))
+	assert.Greater(t, result.VertexMatrix.Len(), 4)
+	// 新しく作られた頂点の属性も、位置から計算した値と一致する
+	result.VertexMatrix.EachVertex(func(i int, vertex Vertex) bool {
+		values := result.VertexAttributeValues(i)
+		assert.InDelta(t, vertex.X(), values[0], 1e-9)
+		assert.InDelta(t, vertex.Y(), values[1], 1e-9)
+		assert.InDelta(t, vertex.X()+2*vertex.Y()+3, values[2], 1e-9)
+		return true
+	})
+}
+
+func TestViewVolume_ClipObject_頂点属性を持たない場合(t *testing.T) {
+	v := newAttributeTestViewVolume()
+	obj := NewPlaneObject(10, 10, color.RGBA{})
+	obj.VertexMatrix.TransformTranslate(0, 0, 2)
+
+	result := v.ClipObject(obj)
+
+	assert.Nil(t, result.Attributes)
+}
+
+func TestVertexGrid_AddVertexWithAttributes(t *testing.T) {
+	grid := NewVertexGrid(1e-2)
+
+	first := grid.AddVertexWithAttributes(Vector3D{1, 1, 1}, []float64{0, 0})
+	// 位置と属性が同じ頂点はマージされる
+	assert.Equal(t, first, grid.AddVertexWithAttributes(Vector3D{1, 1, 1.001}, []float64{0, 0.001}))
+	// 位置が同じでも属性が異なる頂点（UVの継ぎ目など）はマージされない
+	seam := grid.AddVertexWithAttributes(Vector3D{1, 1, 1}, []float64{1, 0})
+	assert.NotEqual(t, first, seam)
+
+	assert.Len(t, grid.Vertices(), 2)
+	assert.Equal(t, [][]float64{{0, 0}, {1, 0}}, grid.AttributeValues())
+}
+
+func TestViewVolume_MargeVertices_頂点属性を引き継ぐこと(t *testing.T) {
+	v := newAttributeTestViewVolume()
+	dObj := NewDynamicObject()
+	dObj.Attributes = []VertexAttribute{{Name: "weight", Size: 1}}
+	dObj.AddClipVerticesWithColor([3]ClipVertex{
+		{Position: Vector4D{0, 0, 1, 1}, Attributes: []float64{1}},
+		{Position: Vector4D{1, 0, 1, 1}, Attributes: []float64{2}},
+		{Position: Vector4D{0, 1, 1, 1}, Attributes: []float64{3}},
+	}, color.RGBA{})
+	dObj.AddClipVerticesWithColor([3]ClipVertex{
+		{Position: Vector4D{1, 0, 1, 1}, Attributes: []float64{2}},
+		{Position: Vector4D{1, 1, 1, 1}, Attributes: []float64{4}},
+		{Position: Vector4D{0, 1, 1, 1}, Attributes: []float64{3}},
+	}, color.RGBA{})
+
+	result := v.MargeVertices(dObj.ToObject())
+
+	assert.Equal(t, 4, result.VertexMatrix.Len())
+	assert.Equal(t, []VertexAttribute{{Name: "weight", Size: 1, Values: []float64{1, 2, 3, 4}}}, result.Attributes)
+}
+
+func TestClipObjectHomogeneous_頂点属性を補間すること(t *testing.T) {
+	// 右側のクリップ面（x = w）をまたぐ三角形。属性はxに比例する
+	obj := Object{
+		VertexMatrix: NewVertexMatrix4([]Vector4D{{0, 0, 0.5, 1}, {3, 0, 0.5, 1}, {0, 0.5, 0.5, 1}}),
+		Triangles:    [][3]int{{0, 1, 2}},
+		Attributes:   []VertexAttribute{{Name: "weight", Size: 1, Values: []float64{0, 6, 0}}},
+	}
+
+	result := ClipObjectHomogeneous(obj)
+
+	ok, weight := result.Attribute("weight")
+	assert.True(t, ok)
+	assert.Len(t, weight.Values, result.VertexMatrix.Len())
+	result.VertexMatrix.EachVertex(func(i int, vertex Vertex) bool {
+		assert.InDelta(t, 2*vertex.X(), weight.Values[i], 1e-9)
+		return true
+	})
+}
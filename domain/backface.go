@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"image/color"
+)
+
+// CullMode は面の向きによるカリングの方法を表します
+type CullMode int
+
+const (
+	// CullBack 裏面（カメラから見て頂点が時計回りの面）を描画しない
+	CullBack CullMode = iota
+	// CullFront 表面を描画しない
+	CullFront
+	// CullNone 両面を描画する
+	CullNone
+)
+
+// IsFrontFacing はカメラ座標系の三角形がカメラ（原点）の方を向いているかを判定します
+// 法線はCalcNormalFromPointsと同じ向きで、面積が0の三角形や真横から見た三角形は表面として扱いません
+func IsFrontFacing(triangle [3]Vector3D) bool {
+	normal := triangle[2].Sub(triangle[0]).Cross(triangle[1].Sub(triangle[0]))
+	// 三角形からカメラへ向かうベクトルと法線が同じ向きなら表面
+	return normal.Dot(triangle[0].MulScalar(-1)) > 0
+}
+
+// CullFaces はカメラ座標系のオブジェクトから、CullModeに従って描画しない面を除外します
+// 両面を描画する場合の裏面や、表面を除外する場合に残る裏面は、頂点の順番を入れ替えて法線を反転し、表面として扱います
+// （レイトレースは表面とだけ交差するため）
+// 除外した面の数も返します
+func (o Object) CullFaces() (Object, int) {
+	return o.cullFaces(func(triangle [3]int) bool {
+		return IsFrontFacing([3]Vector3D{
+			o.VertexMatrix.GetVertex(triangle[0]),
+			o.VertexMatrix.GetVertex(triangle[1]),
+			o.VertexMatrix.GetVertex(triangle[2]),
+		})
+	})
+}
+
+// cullFaces はfrontFacingで判定した面の向きとCullModeに従って面を除外します
+func (o Object) cullFaces(frontFacing func(triangle [3]int) bool) (Object, int) {
+	triangles := make([][3]int, 0, len(o.Triangles))
+	triangleColors := make([]color.RGBA, 0, len(o.Triangles))
+	culled := 0
+
+	for i, triangle := range o.Triangles {
+		isFront := frontFacing(triangle)
+
+		if (o.CullMode == CullBack && !isFront) || (o.CullMode == CullFront && isFront) {
+			culled++
+			continue
+		}
+		if !isFront {
+			triangle = [3]int{triangle[0], triangle[2], triangle[1]}
+		}
+
+		triangles = append(triangles, triangle)
+		if i < len(o.TriangleColors) {
+			triangleColors = append(triangleColors, o.TriangleColors[i])
+		} else {
+			triangleColors = append(triangleColors, color.RGBA{0, 0, 0, 255}) // デフォルト色
+		}
+	}
+
+	o.Triangles = triangles
+	o.TriangleColors = triangleColors
+	return o, culled
+}
@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsFrontFacing(t *testing.T) {
+	// NewPlaneObjectの三角形と同じ頂点の順番（カメラから見て反時計回り）
+	front := [3]Vector3D{{-1, 1, 2}, {-1, -1, 2}, {1, 1, 2}}
+	assert.True(t, IsFrontFacing(front))
+
+	back := [3]Vector3D{front[0], front[2], front[1]}
+	assert.False(t, IsFrontFacing(back))
+
+	// 真横から見た三角形
+	edgeOn := [3]Vector3D{{0, 1, 1}, {0, -1, 1}, {0, 1, 3}}
+	assert.False(t, IsFrontFacing(edgeOn))
+}
+
+func TestObject_CullFaces(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+	obj := Object{
+		VertexMatrix: NewVertexMatrix([]Vector3D{{-1, 1, 2}, {-1, -1, 2}, {1, 1, 2}}),
+		Triangles: [][3]int{
+			{0, 1, 2}, // 表面
+			{0, 2, 1}, // 裏面
+		},
+		TriangleColors: []color.RGBA{red, blue},
+	}
+
+	t.Run("裏面を除外する", func(t *testing.T) {
+		result, culled := obj.CullFaces()
+
+		assert.Equal(t, 1, culled)
+		assert.Equal(t, [][3]int{{0, 1, 2}}, result.Triangles)
+		assert.Equal(t, []color.RGBA{red}, result.TriangleColors)
+	})
+
+	t.Run("表面を除外する", func(t *testing.T) {
+		obj := obj
+		obj.CullMode = CullFront
+
+		result, culled := obj.CullFaces()
+
+		assert.Equal(t, 1, culled)
+		// 残った裏面は頂点の順番を入れ替えて表面として扱う
+		assert.Equal(t, [][3]int{{0, 1, 2}}, result.Triangles)
+		assert.Equal(t, []color.RGBA{blue}, result.TriangleColors)
+	})
+
+	t.Run("両面を描画する", func(t *testing.T) {
+		obj := obj
+		obj.CullMode = CullNone
+
+		result, culled := obj.CullFaces()
+
+		assert.Equal(t, 0, culled)
+		assert.Equal(t, [][3]int{{0, 1, 2}, {0, 1, 2}}, result.Triangles)
+		assert.Equal(t, []color.RGBA{red, blue}, result.TriangleColors)
+	})
+}
+
+func TestWorld_Transform_裏から見た平面(t *testing.T) {
+	gray := color.RGBA{50, 50, 50, 255}
+	world := World{
+		LocatedObjects: []LocatedObject{
+			{
+				Location: Vector3D{0, 0, 2},
+				Scale:    Vector3D{1, 1, 1},
+				// Y軸周りに180度回転して裏面をカメラに向ける
+				Rotation: Vector3D{0, math.Pi, 0},
+				Object:   NewPlaneObject(0.5, 0.5, gray),
+			},
+		},
+		Viewport: Viewport{
+			Width:  16,
+			Height: 12,
+		},
+		Clipping: Clipping{
+			NearDistance: 0.1,
+			FarDistance:  10.0,
+			FieldOfView:  math.Pi / 4,
+		},
+	}
+
+	// 既定では裏面は描画されない
+	frameBuffer, stats := world.TransformWithStats()
+	assert.Empty(t, frameBuffer)
+	assert.Equal(t, 2, stats.CulledFaces)
+
+	// 両面を描画する場合は裏からでも見える
+	world.LocatedObjects[0].Object.CullMode = CullNone
+	frameBuffer = world.Transform()
+	assert.Equal(t, gray, frameBuffer[FrameBufferKey{X: 8, Y: 6}].Color)
+
+	// 表面を除外する場合も裏面は見える
+	world.LocatedObjects[0].Object.CullMode = CullFront
+	frameBuffer = world.Transform()
+	assert.Equal(t, gray, frameBuffer[FrameBufferKey{X: 8, Y: 6}].Color)
+
+	// 表から見た場合は表面が除外される
+	world.LocatedObjects[0].Rotation = Vector3D{}
+	assert.Empty(t, world.Transform())
+}
+
+func TestViewVolume_ClipObject_CullModeを引き継ぐこと(t *testing.T) {
+	world := World{
+		Viewport: Viewport{Width: 16, Height: 12},
+		Clipping: Clipping{NearDistance: 0.1, FarDistance: 10.0, FieldOfView: math.Pi / 4},
+	}
+	obj := NewPlaneObject(10, 10, color.RGBA{})
+	obj.VertexMatrix.TransformTranslate(0, 0, 2)
+	obj.CullMode = CullNone
+
+	result := world.ViewVolume().ClipObject(obj)
+
+	assert.Equal(t, CullNone, result.CullMode)
+}
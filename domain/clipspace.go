@@ -0,0 +1,359 @@
+package domain
+
+import (
+	"image/color"
+	"math"
+)
+
+// Vector4D は同次座標のベクトル（x, y, z, w）を表します
+type Vector4D [4]float64
+
+// ClipVertex はクリップ空間の頂点と、クリッピング時に線形補間する頂点属性を表します
+type ClipVertex struct {
+	Position Vector4D
+	// Attributes 色・法線・UVなどの頂点属性。全ての頂点で同じ長さにする
+	Attributes []float64
+}
+
+// Lerp は2つの頂点の位置と頂点属性を線形補間します
+func (v ClipVertex) Lerp(to ClipVertex, t float64) ClipVertex {
+	result := ClipVertex{}
+	for i := 0; i < 4; i++ {
+		result.Position[i] = v.Position[i] + (to.Position[i]-v.Position[i])*t
+	}
+	if len(v.Attributes) > 0 {
+		result.Attributes = make([]float64, len(v.Attributes))
+		for i := range v.Attributes {
+			result.Attributes[i] = v.Attributes[i] + (to.Attributes[i]-v.Attributes[i])*t
+		}
+	}
+	return result
+}
+
+// Vector3D は位置のx, y, zを返します（wでは除算しません）
+func (v ClipVertex) Vector3D() Vector3D {
+	return Vector3D{v.Position[0], v.Position[1], v.Position[2]}
+}
+
+// ClipSpaceDistance はクリップ空間の点とクリップ面の（同次座標での）距離を返します
+// クリップ面は -w ≤ x,y ≤ w, 0 ≤ z ≤ w で、内側が正になります
+// 投影行列に依存しないため、透視投影・平行投影・任意の投影行列で同じように扱えます
+func ClipSpaceDistance(p Vector4D, clippingPlaneType ClippingPlaneType) float64 {
+	x, y, z, w := p[0], p[1], p[2], p[3]
+	switch clippingPlaneType {
+	case Near:
+		return z
+	case Far:
+		return w - z
+	case Left:
+		return w + x
+	case Right:
+		return w - x
+	case Bottom:
+		return w + y
+	case Top:
+		return w - y
+	}
+	return 0
+}
+
+// ClipPolygonHomogeneous はクリップ空間の多角形をSutherland-Hodgmanアルゴリズムでクリッピングします
+// 交点の位置と頂点属性は、クリップ面との距離の比で線形補間します
+func ClipPolygonHomogeneous(polygon []ClipVertex) []ClipVertex {
+	work1Vertices := polygon
+	for _, clippingPlaneType := range ClippingPlaneTypes() {
+		if len(work1Vertices) == 0 {
+			break
+		}
+		work2Vertices := make([]ClipVertex, 0, len(work1Vertices)+2)
+		for i := 0; i < len(work1Vertices); i++ {
+			fromVertex := work1Vertices[i]
+			toVertex := work1Vertices[(i+1)%len(work1Vertices)]
+
+			fromDistance := ClipSpaceDistance(fromVertex.Position, clippingPlaneType)
+			toDistance := ClipSpaceDistance(toVertex.Position, clippingPlaneType)
+			// クリップ面上の点は内側として扱う（ClippingEpsilon）
+			fromInside := fromDistance >= -ClippingEpsilon
+			toInside := toDistance >= -ClippingEpsilon
+
+			if fromInside && toInside {
+				// 内から内
+				work2Vertices = append(work2Vertices, toVertex)
+			} else if fromInside && !toInside {
+				// 内から外
+				t := ClipParameter(fromDistance, toDistance)
+				work2Vertices = append(work2Vertices, fromVertex.Lerp(toVertex, t))
+			} else if !fromInside && toInside {
+				// 外から内
+				// 先に交点を追加する。その後、内側の頂点を追加する。（頂点の順番を維持するため）
+				t := ClipParameter(fromDistance, toDistance)
+				work2Vertices = append(work2Vertices, fromVertex.Lerp(toVertex, t))
+				work2Vertices = append(work2Vertices, toVertex)
+			}
+		}
+		work1Vertices = work2Vertices
+	}
+	return work1Vertices
+}
+
+// ClassifyClipSpacePoints はクリップ空間の点群（境界ボックスの頂点など）を囲む凸包とクリップ空間の位置関係を判定します
+// 全ての点が同じクリップ面の外側にある場合は外側、全ての点が全てのクリップ面の内側にある場合は内側と判定します
+func ClassifyClipSpacePoints(points []Vector4D) CullResult {
+	result := CullInside
+	for _, clippingPlaneType := range ClippingPlaneTypes() {
+		outside := 0
+		for _, p := range points {
+			if ClipSpaceDistance(p, clippingPlaneType) < 0 {
+				outside++
+			}
+		}
+		if outside == len(points) {
+			return CullOutside
+		}
+		if outside > 0 {
+			result = CullIntersecting
+		}
+	}
+	return result
+}
+
+// IsFrontFacingHomogeneous はクリップ空間の三角形がカメラの方を向いているかを判定します
+// (x, y, w) を並べた行列式の符号で判定するため、透視除算の前（wが負の頂点を含む場合）でも使用できます
+// 透視投影ではカメラ座標系のIsFrontFacingと同じ結果になり、平行投影では画面上の頂点の回り方で判定します
+func IsFrontFacingHomogeneous(triangle [3]Vector4D) bool {
+	a, b, c := triangle[0], triangle[1], triangle[2]
+	det := a[0]*(b[1]*c[3]-b[3]*c[1]) -
+		a[1]*(b[0]*c[3]-b[3]*c[0]) +
+		a[3]*(b[0]*c[1]-b[1]*c[0])
+	return det > 0
+}
+
+// GetVector4D は同次座標を含むi番目の頂点を返します
+func (v VartexMatrix) GetVector4D(i int) Vector4D {
+	return Vector4D{v.At(0, i), v.At(1, i), v.At(2, i), v.At(3, i)}
+}
+
+// NewVertexMatrix4 は同次座標の頂点のスライスを行列に変換します
+func NewVertexMatrix4(vertices []Vector4D) VartexMatrix {
+	vm := NewVertexMatrix(make([]Vector3D, len(vertices)))
+	for i, v := range vertices {
+		for row := 0; row < 4; row++ {
+			vm.Set(row, i, v[row])
+		}
+	}
+	return vm
+}
+
+// ProjectionMatrix はカメラ座標系からクリップ空間への投影行列を返します
+// Clipping.Projectionを指定した場合はそれを使い、それ以外はFieldOfViewなどから透視投影行列を作ります
+func (w World) ProjectionMatrix() Matrix4 {
+	if w.Clipping.Projection != nil {
+		return *w.Clipping.Projection
+	}
+
+	aspect := float64(w.Viewport.Width) / float64(w.Viewport.Height)
+	projection := NewPerspectiveMatrix4(w.Clipping.FieldOfView, aspect, w.Clipping.NearDistance, w.Clipping.FarDistance)
+	// オフアクシス投影の場合は、ずれた視錐台の中心がx=0になるようにzに比例して平行移動する
+	projection[0][2] = -w.Clipping.HorizontalShift * projection[0][0] / w.Clipping.NearDistance
+	return projection
+}
+
+// CullObjectClipSpace はオブジェクトをクリップ空間で視錐台カリングし、描画する場合はクリップ空間に変換したオブジェクトを返します
+// mvpMatrixはオブジェクトのローカル座標系からクリップ空間への変換行列です
+// 境界ボックスの8頂点をクリップ空間に変換して判定するため、投影行列の種類に依存しません
+func CullObjectClipSpace(o Object, mvpMatrix Matrix4) (CullResult, Object) {
+	ok, box := o.BoundingBox()
+	if !ok {
+		return CullOutside, o
+	}
+
+	corners := make([]Vector4D, 0, 8)
+	for i := 0; i < 8; i++ {
+		corner := Vector4D{box.Min[0], box.Min[1], box.Min[2], 1}
+		for axis := 0; axis < 3; axis++ {
+			if i&(1<<axis) != 0 {
+				corner[axis] = box.Max[axis]
+			}
+		}
+		corners = append(corners, mvpMatrix.MulVector4(corner))
+	}
+
+	result := ClassifyClipSpacePoints(corners)
+	if result == CullOutside {
+		return CullOutside, o
+	}
+
+	o.VertexMatrix.TransformMatrix4(mvpMatrix)
+	return result, o
+}
+
+// CullFacesHomogeneous はクリップ空間のオブジェクトから、CullModeに従って描画しない面を除外します
+// 面の向きの判定にIsFrontFacingHomogeneousを使う以外はCullFacesと同じです
+func (o Object) CullFacesHomogeneous() (Object, int) {
+	return o.cullFaces(func(triangle [3]int) bool {
+		return IsFrontFacingHomogeneous([3]Vector4D{
+			o.VertexMatrix.GetVector4D(triangle[0]),
+			o.VertexMatrix.GetVector4D(triangle[1]),
+			o.VertexMatrix.GetVector4D(triangle[2]),
+		})
+	})
+}
+
+// ClipObjectHomogeneous はクリップ空間のオブジェクトをクリッピングします
+// 結果もクリップ空間の座標（透視除算の前）です。全ての三角形が外側にある場合は頂点を持たない空のオブジェクトを返します
+func ClipObjectHomogeneous(o Object) Object {
+	vertices := make([]Vector4D, 0, o.VertexMatrix.Len())
+	attributeValues := make([][]float64, 0, o.VertexMatrix.Len())
+	triangles := make([][3]int, 0, len(o.Triangles))
+	triangleColors := make([]color.RGBA, 0, len(o.Triangles))
+
+	for i, triangle := range o.Triangles {
+		polygon := make([]ClipVertex, 0, 3)
+		for _, index := range triangle {
+			polygon = append(polygon, ClipVertex{
+				Position:   o.VertexMatrix.GetVector4D(index),
+				Attributes: o.VertexAttributeValues(index),
+			})
+		}
+		polygon = ClipPolygonHomogeneous(polygon)
+		if len(polygon) < 3 {
+			continue
+		}
+
+		// 元の三角形の色を取得
+		var originalColor color.RGBA
+		if i < len(o.TriangleColors) {
+			originalColor = o.TriangleColors[i]
+		} else {
+			originalColor = color.RGBA{0, 0, 0, 255} // デフォルト色
+		}
+
+		// 凸多角形なので扇状に三角形分割する
+		first := len(vertices)
+		for _, v := range polygon {
+			vertices = append(vertices, v.Position)
+			attributeValues = append(attributeValues, v.Attributes)
+		}
+		for j := 1; j < len(polygon)-1; j++ {
+			triangles = append(triangles, [3]int{first, first + j, first + j + 1})
+			triangleColors = append(triangleColors, originalColor)
+		}
+	}
+
+	if len(vertices) == 0 {
+		return Object{CullMode: o.CullMode, Texture: o.Texture}
+	}
+	return Object{
+		VertexMatrix:   NewVertexMatrix4(vertices),
+		Edges:          [][2]int{},
+		Triangles:      triangles,
+		TriangleColors: triangleColors,
+		CullMode:       o.CullMode,
+		Texture:        o.Texture,
+		Attributes:     newVertexAttributes(attributeLayout(o.Attributes), attributeValues),
+	}
+}
+
+// PerspectiveDivide はクリップ空間のオブジェクトの各頂点をwで除算し、NDCに変換します
+func (o Object) PerspectiveDivide() Object {
+	vertices := make([]Vector4D, 0, o.VertexMatrix.Len())
+	ws := make([]float64, 0, o.VertexMatrix.Len())
+	o.VertexMatrix.EachVertex(func(i int, _ Vertex) bool {
+		p := o.VertexMatrix.GetVector4D(i)
+		vertices = append(vertices, Vector4D{p[0] / p[3], p[1] / p[3], p[2] / p[3], 1})
+		ws = append(ws, p[3])
+		return true
+	})
+	o.VertexMatrix = NewVertexMatrix4(vertices)
+	// テクスチャを透視補正して補間するため、wの逆数を残す
+	return o.withInverseW(ws)
+}
+
+// TransformClipSpace はクリップ空間でクリッピングするパイプラインでワールドをレンダリングし、カリングの統計と共に返します
+// 画素とレイの対応はTransformと同じくFieldOfViewから決めます
+// Clipping.Projectionを指定した場合は、NDCで平行なレイを飛ばします（RayTraceParallel）
+func (w World) TransformClipSpace() (FrameBuffer, CullingStats) {
+	objects, stats := w.NDCObjects()
+
+	calculatedWorld := NewCalculatedWorld(w)
+	for _, obj := range objects {
+		calculatedWorld.AddObject(obj)
+	}
+	return calculatedWorld.RayTrace(), stats
+}
+
+// NDCObjects は全オブジェクトをクリップ空間でカリング・クリッピングし、NDCに変換して返します
+// 投影行列（ProjectionMatrix）を適用してからカリング・クリッピングを行うため、平行投影や任意の投影行列でも使用できます
+func (w World) NDCObjects() ([]Object, CullingStats) {
+	projection := w.ProjectionMatrix()
+	viewMatrix := w.Camera.ViewMatrix()
+	stats := CullingStats{}
+
+	objects := make([]Object, 0, len(w.LocatedObjects))
+	w.EachObject(func(obj Object, modelMatrix Matrix4) {
+		stats.Objects++
+
+		result, obj := CullObjectClipSpace(obj, projection.Mul(viewMatrix.Mul(modelMatrix)))
+		switch result {
+		case CullOutside:
+			stats.Culled++
+			return
+		case CullInside:
+			stats.Inside++
+		case CullIntersecting:
+			stats.Clipped++
+		}
+
+		// 面の向きによるカリング（クリッピングの前に行い、除外した面はクリッピングしない）
+		obj, culledFaces := obj.CullFacesHomogeneous()
+		stats.CulledFaces += culledFaces
+		if len(obj.Triangles) == 0 {
+			return
+		}
+
+		if result == CullIntersecting {
+			obj = ClipObjectHomogeneous(obj)
+			if len(obj.Triangles) == 0 {
+				return
+			}
+		}
+
+		// 透視除算で潰れた三角形や、wが0の頂点を含む三角形は取り除く
+		obj, _ = obj.PerspectiveDivide().RemoveDegenerateTriangles()
+		if len(obj.Triangles) == 0 {
+			return
+		}
+
+		objects = append(objects, obj)
+	})
+
+	return objects, stats
+}
+
+// RayTraceParallel はNDCの各画素の位置から+Z方向に平行なレイを飛ばしてレイトレースします
+// 投影行列（Clipping.Projection）を指定した場合は、透視除算後のNDC（-1 ≤ x,y ≤ 1）がそのまま画面に映る範囲になるため、
+// FieldOfViewから決めた透視投影のレイではなくこのレイを使います（平行投影では距離によって大きさが変わりません）
+func (w CalculatedWorld) RayTraceParallel() FrameBuffer {
+	width := w.Origin.Viewport.Width
+	height := w.Origin.Viewport.Height
+	rayDirection := Vector3D{0, 0, 1}
+
+	frameBuffer := make(FrameBuffer, width*height)
+	for xPixel := int32(0); xPixel < width; xPixel++ {
+		for yPixel := int32(0); yPixel < height; yPixel++ {
+			rayOrigin := Vector3D{
+				(float64(xPixel)+0.5)/float64(width)*2 - 1,
+				1 - (float64(yPixel)+0.5)/float64(height)*2,
+				0,
+			}
+
+			hit, c, depth := w.traceRay(rayOrigin, rayDirection)
+			if !hit || math.IsNaN(depth) || math.IsInf(depth, 0) {
+				continue
+			}
+			frameBuffer[FrameBufferKey{X: xPixel, Y: yPixel}] = FrameBufferValue{Color: c, Depth: depth}
+		}
+	}
+
+	return frameBuffer
+}
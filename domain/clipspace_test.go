@@ -0,0 +1,232 @@
+package domain
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPerspectiveMatrix4(t *testing.T) {
+	m := NewPerspectiveMatrix4(math.Pi/2, 2, 1, 10)
+
+	// 前方クリップ面の右上の角は (w, w, 0, w)
+	near := m.MulVector4(Vector4D{2, 1, 1, 1})
+	assert.InDeltaSlice(t, []float64{1, 1, 0, 1}, near[:], 1e-9)
+
+	// 後方クリップ面の中心は (0, 0, w, w)
+	far := m.MulVector4(Vector4D{0, 0, 10, 1})
+	assert.InDeltaSlice(t, []float64{0, 0, 10, 10}, far[:], 1e-9)
+}
+
+func TestNewOrthographicMatrix4(t *testing.T) {
+	m := NewOrthographicMatrix4(4, 2, 1, 11)
+
+	// 距離に関係なく同じ大きさで映る
+	near := m.MulVector4(Vector4D{2, 1, 1, 1})
+	assert.InDeltaSlice(t, []float64{1, 1, 0, 1}, near[:], 1e-9)
+	far := m.MulVector4(Vector4D{2, 1, 11, 1})
+	assert.InDeltaSlice(t, []float64{1, 1, 1, 1}, far[:], 1e-9)
+}
+
+func TestWorld_ProjectionMatrix(t *testing.T) {
+	world := World{
+		Viewport: Viewport{Width: 16, Height: 12},
+		Clipping: Clipping{NearDistance: 0.1, FarDistance: 10.0, FieldOfView: math.Pi / 4, HorizontalShift: 0.01},
+	}
+
+	// オフアクシス投影では、ずれた視錐台の中心がx=0になる
+	center := world.ProjectionMatrix().MulVector4(Vector4D{0.01, 0, 0.1, 1})
+	assert.InDelta(t, 0.0, center[0], 1e-9)
+
+	// 投影行列を指定した場合はそれを使う
+	ortho := NewOrthographicMatrix4(4, 3, 0.1, 10)
+	world.Clipping.Projection = &ortho
+	assert.Equal(t, ortho, world.ProjectionMatrix())
+}
+
+func TestClipSpaceDistance(t *testing.T) {
+	p := Vector4D{0.5, -2, 3, 2}
+
+	assert.Equal(t, 3.0, ClipSpaceDistance(p, Near))
+	assert.Equal(t, -1.0, ClipSpaceDistance(p, Far))
+	assert.Equal(t, 2.5, ClipSpaceDistance(p, Left))
+	assert.Equal(t, 1.5, ClipSpaceDistance(p, Right))
+	assert.Equal(t, 0.0, ClipSpaceDistance(p, Bottom))
+	assert.Equal(t, 4.0, ClipSpaceDistance(p, Top))
+}
+
+func TestClipPolygonHomogeneous(t *testing.T) {
+	// 右側のクリップ面（x = w）をまたぐ三角形
+	polygon := []ClipVertex{
+		{Position: Vector4D{0, 0, 0.5, 1}, Attributes: []float64{0, 10}},
+		{Position: Vector4D{3, 0, 0.5, 1}, Attributes: []float64{3, 40}},
+		{Position: Vector4D{0, 0.5, 0.5, 1}, Attributes: []float64{0, 10}},
+	}
+
+	result := ClipPolygonHomogeneous(polygon)
+
+	assert.Len(t, result, 4)
+	for _, v := range result {
+		assert.LessOrEqual(t, v.Position[0], v.Position[3]+1e-9)
+		// 頂点属性も位置と同じ比率で補間される
+		assert.InDelta(t, v.Position[0], v.Attributes[0], 1e-9)
+		assert.InDelta(t, 10+10*v.Position[0], v.Attributes[1], 1e-9)
+	}
+}
+
+func TestClipPolygonHomogeneous_wが負の頂点(t *testing.T) {
+	// カメラの後ろ（w < 0）の頂点は前方クリップ面で除去される
+	projection := NewPerspectiveMatrix4(math.Pi/2, 1, 0.1, 10)
+	polygon := []ClipVertex{
+		{Position: projection.MulVector4(Vector4D{0, 0, 1, 1})},
+		{Position: projection.MulVector4(Vector4D{0.5, 0, -1, 1})},
+		{Position: projection.MulVector4(Vector4D{0, 0.5, 1, 1})},
+	}
+
+	result := ClipPolygonHomogeneous(polygon)
+
+	assert.NotEmpty(t, result)
+	for _, v := range result {
+		assert.GreaterOrEqual(t, v.Position[2], -1e-9)
+		assert.Greater(t, v.Position[3], 0.0)
+	}
+}
+
+func TestClipPolygonHomogeneous_全て外側(t *testing.T) {
+	polygon := []ClipVertex{
+		{Position: Vector4D{2, 0, 0.5, 1}},
+		{Position: Vector4D{3, 0, 0.5, 1}},
+		{Position: Vector4D{2, 0.5, 0.5, 1}},
+	}
+
+	assert.Empty(t, ClipPolygonHomogeneous(polygon))
+}
+
+func TestClassifyClipSpacePoints(t *testing.T) {
+	assert.Equal(t, CullInside, ClassifyClipSpacePoints([]Vector4D{{0, 0, 0.5, 1}, {0.5, 0.5, 0.5, 1}}))
+	assert.Equal(t, CullIntersecting, ClassifyClipSpacePoints([]Vector4D{{0, 0, 0.5, 1}, {2, 0, 0.5, 1}}))
+	// 別々のクリップ面の外側にある場合は交差として扱う（保守的に判定する）
+	assert.Equal(t, CullIntersecting, ClassifyClipSpacePoints([]Vector4D{{2, 0, 0.5, 1}, {-2, 0, 0.5, 1}}))
+	assert.Equal(t, CullOutside, ClassifyClipSpacePoints([]Vector4D{{2, 0, 0.5, 1}, {3, 0, 0.5, 1}}))
+}
+
+func TestIsFrontFacingHomogeneous(t *testing.T) {
+	projection := NewPerspectiveMatrix4(math.Pi/2, 1.5, 0.1, 10)
+	triangles := [][3]Vector3D{
+		{{-1, 1, 2}, {-1, -1, 2}, {1, 1, 2}},
+		{{-1, 1, 2}, {1, 1, 2}, {-1, -1, 2}},
+		{{3, 0, 1}, {3, 1, 5}, {4, -1, 2}},
+		// カメラの後ろの頂点を含む
+		{{0, 0, 1}, {0, 1, -2}, {1, 0, 1}},
+		{{0, 0, 1}, {1, 0, 1}, {0, 1, -2}},
+	}
+
+	for _, triangle := range triangles {
+		var clip [3]Vector4D
+		for i, v := range triangle {
+			clip[i] = projection.MulVector4(Vector4D{v[0], v[1], v[2], 1})
+		}
+
+		// 透視投影ではカメラ座標系での判定と一致する
+		assert.Equal(t, IsFrontFacing(triangle), IsFrontFacingHomogeneous(clip), "%v", triangle)
+	}
+}
+
+func TestWorld_TransformClipSpace_カメラ座標系でのクリッピングと同じ結果になること(t *testing.T) {
+	world := newTestWorld(16, 12, newTestTetrahedra(
+		Vector3D{0, 0, 2},
+		Vector3D{0.5, 0.1, 1.5},
+		Vector3D{-0.2, -0.1, 0.3},
+		Vector3D{0, 0, -2},
+	)...)
+	world.Camera.Direction = Vector3D{0.1, 0.2, 0}
+
+	expected, expectedStats := world.TransformWithStats()
+
+	result, stats := world.TransformClipSpace()
+
+	assert.Equal(t, expectedStats, stats)
+	assert.Equal(t, len(expected), len(result))
+	for key, value := range expected {
+		assert.Equal(t, value.Color, result[key].Color, "%v", key)
+		assert.InDelta(t, value.Depth, result[key].Depth, 1e-6, "%v", key)
+	}
+
+	// ClipSpaceを指定するとTransformもクリップ空間でクリッピングする
+	world.Clipping.ClipSpace = true
+	assert.Equal(t, result, world.Transform())
+}
+
+func TestWorld_NDCObjects_平行投影(t *testing.T) {
+	ndcVertices := func(z float64) []Vector3D {
+		projection := NewOrthographicMatrix4(2, 1.5, 0.1, 10)
+		world := World{
+			LocatedObjects: []LocatedObject{
+				{
+					Location: Vector3D{0, 0, z},
+					Scale:    Vector3D{1, 1, 1},
+					Object:   NewPlaneObject(0.5, 0.5, color.RGBA{255, 0, 0, 255}),
+				},
+			},
+			Viewport: Viewport{Width: 16, Height: 12},
+			Clipping: Clipping{
+				NearDistance: 0.1,
+				FarDistance:  10.0,
+				FieldOfView:  math.Pi / 4,
+				Projection:   &projection,
+			},
+		}
+		objects, _ := world.NDCObjects()
+		assert.Len(t, objects, 1)
+		vertices := []Vector3D{}
+		objects[0].VertexMatrix.EachVertex(func(_ int, v Vertex) bool {
+			vertices = append(vertices, Vector3D{v.X(), v.Y(), 0})
+			return true
+		})
+		return vertices
+	}
+
+	// 平行投影では距離によって画面上の大きさが変わらない
+	near := ndcVertices(2)
+	far := ndcVertices(8)
+	assert.Equal(t, near, far)
+	assert.InDelta(t, 0.25/0.75, near[0].Y(), 1e-9)
+	assert.InDelta(t, -0.25, near[0].X(), 1e-9)
+}
+
+func TestWorld_Transform_平行投影(t *testing.T) {
+	render := func(z float64) FrameBuffer {
+		projection := NewOrthographicMatrix4(2, 1.5, 0.1, 10)
+		world := newTestWorld(16, 12, newTestPlane(z, 0.5, color.RGBA{255, 0, 0, 255}))
+		world.Clipping.Projection = &projection
+		return world.Transform()
+	}
+
+	near := render(2)
+	far := render(8)
+
+	// 平行投影では距離によって画面上の大きさが変わらない（幅2・高さ1.5の範囲に一辺0.5の平面が4x4画素で映る）
+	assert.Len(t, near, 16)
+	assert.Len(t, far, 16)
+	for key := range near {
+		_, ok := far[key]
+		assert.True(t, ok, "%v", key)
+	}
+	// 画面の中心に映る
+	_, ok := near[FrameBufferKey{X: 8, Y: 6}]
+	assert.True(t, ok)
+}
+
+func TestWorld_NDCObjects_クリッピング(t *testing.T) {
+	world := newTestWorld(16, 12, newTestPlane(2, 10, color.RGBA{}))
+
+	objects, stats := world.NDCObjects()
+
+	assert.Equal(t, 1, stats.Clipped)
+	// 画面全体を覆う平面はNDCの範囲にクリッピングされる
+	_, box := objects[0].BoundingBox()
+	assert.InDeltaSlice(t, []float64{-1, -1}, box.Min[:2], 1e-9)
+	assert.InDeltaSlice(t, []float64{1, 1}, box.Max[:2], 1e-9)
+}
@@ -0,0 +1,559 @@
+package domain
+
+import (
+	"cmp"
+	"image/color"
+	"slices"
+)
+
+const (
+	// CSGTolerance 面の前後を判定する距離の、2つのオブジェクトを囲む境界ボックスの対角線の長さに対する比率
+	// この距離より平面に近い頂点は平面上にあるものとして扱います
+	CSGTolerance = 1e-5
+	// maxTJunctionPasses T字の継ぎ目で三角形を分割し直す回数の上限
+	maxTJunctionPasses = 16
+)
+
+// Union は2つの閉じたオブジェクトの和（どちらかの内側）を返します
+// 結果の三角形は元の三角形の色を引き継ぎ、CullModeとTextureはoのものです
+func (o Object) Union(other Object) Object {
+	c := newCSG(o, other)
+	a, b := c.newNode(o), c.newNode(other)
+	a.clipTo(b)
+	b.clipTo(a)
+	// 同じ平面上で重なる面をaとbの両方に残さないように、bの中でaと重なる面を取り除く
+	b.invert()
+	b.clipTo(a)
+	b.invert()
+	a.build(b.allPolygons())
+	return c.toObject(o, a.allPolygons())
+}
+
+// Difference は閉じたオブジェクトoからotherを取り除いた差を返します
+// otherで削った面はotherの三角形の色を引き継ぎ、内側を向くように裏返します（法線の属性も反転します）
+func (o Object) Difference(other Object) Object {
+	c := newCSG(o, other)
+	a, b := c.newNode(o), c.newNode(other)
+	// 空の木を裏返しても全体を表す立体にはならないので、どちらかが空の場合は先に決める
+	if a.plane == nil || b.plane == nil {
+		return c.toObject(o, a.allPolygons())
+	}
+	a.invert()
+	a.clipTo(b)
+	b.clipTo(a)
+	b.invert()
+	b.clipTo(a)
+	b.invert()
+	a.build(b.allPolygons())
+	a.invert()
+	return c.toObject(o, a.allPolygons())
+}
+
+// Intersection は2つの閉じたオブジェクトの積（両方の内側）を返します
+func (o Object) Intersection(other Object) Object {
+	c := newCSG(o, other)
+	a, b := c.newNode(o), c.newNode(other)
+	if a.plane == nil || b.plane == nil {
+		return c.toObject(o, nil)
+	}
+	a.invert()
+	b.clipTo(a)
+	b.invert()
+	a.clipTo(b)
+	b.clipTo(a)
+	a.build(b.allPolygons())
+	a.invert()
+	return c.toObject(o, a.allPolygons())
+}
+
+// csgVertex はCSGの多角形の頂点です
+type csgVertex struct {
+	position Vector3D
+	// attributes 全ての頂点属性の値を連結したもの（VertexAttributeValues）
+	attributes []float64
+}
+
+// csgPlane は表面の向き（CalcNormalFromPoints）を法線とする平面です。normal・p = w の点が平面上にあります
+type csgPlane struct {
+	normal Vector3D
+	w      float64
+}
+
+// csgPolygon はCSGで扱う凸多角形です
+type csgPolygon struct {
+	vertices []csgVertex
+	plane    csgPlane
+	color    color.RGBA
+}
+
+// csgNode はBSP木の節です
+// 節の平面上にある多角形を持ち、表側（法線の向き）と裏側の多角形をそれぞれ子の節に分けます
+type csgNode struct {
+	csg      *csg
+	plane    *csgPlane
+	front    *csgNode
+	back     *csgNode
+	polygons []csgPolygon
+}
+
+// csg は2つのオブジェクトに共通するCSGの設定です
+type csg struct {
+	// size 2つのオブジェクトを囲む境界ボックスの対角線の長さ
+	size float64
+	// epsilon 平面上にあるとみなす距離
+	epsilon float64
+	// layout 両方のオブジェクトが同じ頂点属性を持つ場合の属性の名前と要素数（異なる場合は属性を引き継がない）
+	layout []VertexAttribute
+	// normalOffset 頂点属性の値の中の法線の位置（法線がない場合は-1）
+	normalOffset int
+}
+
+func newCSG(a, b Object) *csg {
+	c := &csg{normalOffset: -1}
+
+	boxes := make([]Vector3D, 0, 4)
+	for _, o := range []Object{a, b} {
+		if ok, box := o.BoundingBox(); ok {
+			boxes = append(boxes, box.Min, box.Max)
+		}
+	}
+	if len(boxes) > 0 {
+		minimum, maximum := boxes[0], boxes[0]
+		for _, p := range boxes[1:] {
+			for k := range p {
+				minimum[k] = min(minimum[k], p[k])
+				maximum[k] = max(maximum[k], p[k])
+			}
+		}
+		c.size = maximum.Sub(minimum).Distance()
+	}
+	c.epsilon = max(c.size*CSGTolerance, minMergeTolerance)
+
+	layoutA, layoutB := attributeLayout(a.Attributes), attributeLayout(b.Attributes)
+	if slices.EqualFunc(layoutA, layoutB, func(a, b VertexAttribute) bool { return a.Name == b.Name && a.Size == b.Size }) {
+		c.layout = layoutA
+		offset := 0
+		for _, attribute := range c.layout {
+			if attribute.Name == AttributeNormal && attribute.Size == 3 {
+				c.normalOffset = offset
+			}
+			offset += attribute.Size
+		}
+	}
+	return c
+}
+
+// newNode はオブジェクトの三角形からBSP木を作ります
+// 範囲外の添字番号を含む三角形と面積が0の三角形は使いません
+func (c *csg) newNode(o Object) *csgNode {
+	node := &csgNode{csg: c}
+	if len(o.Triangles) == 0 {
+		return node
+	}
+
+	vertexCount := o.VertexMatrix.Len()
+	polygons := make([]csgPolygon, 0, len(o.Triangles))
+	for i, triangle := range o.Triangles {
+		if min(triangle[0], triangle[1], triangle[2]) < 0 || max(triangle[0], triangle[1], triangle[2]) >= vertexCount {
+			continue
+		}
+		positions := [3]Vector3D{o.VertexMatrix.GetVertex(triangle[0]), o.VertexMatrix.GetVertex(triangle[1]), o.VertexMatrix.GetVertex(triangle[2])}
+		if IsDegenerateTriangle(positions) {
+			continue
+		}
+
+		vertices := make([]csgVertex, 0, 3)
+		for k, index := range triangle {
+			vertex := csgVertex{position: positions[k]}
+			if c.layout != nil {
+				vertex.attributes = o.VertexAttributeValues(index)
+			}
+			vertices = append(vertices, vertex)
+		}
+		normal := CalcNormalFromPoints(positions[0], positions[1], positions[2])
+		polygons = append(polygons, csgPolygon{
+			vertices: vertices,
+			plane:    csgPlane{normal: normal, w: normal.Dot(positions[0])},
+			color:    o.TriangleColor(i),
+		})
+	}
+
+	node.build(polygons)
+	return node
+}
+
+// flip は多角形を裏返します。頂点の順番を逆にし、平面と法線の属性を反転します
+func (c *csg) flip(polygon csgPolygon) csgPolygon {
+	vertices := make([]csgVertex, 0, len(polygon.vertices))
+	for i := len(polygon.vertices) - 1; i >= 0; i-- {
+		vertex := polygon.vertices[i]
+		if c.normalOffset >= 0 {
+			attributes := slices.Clone(vertex.attributes)
+			for k := c.normalOffset; k < c.normalOffset+3; k++ {
+				attributes[k] = -attributes[k]
+			}
+			vertex.attributes = attributes
+		}
+		vertices = append(vertices, vertex)
+	}
+	return csgPolygon{
+		vertices: vertices,
+		plane:    csgPlane{normal: polygon.plane.normal.MulScalar(-1), w: -polygon.plane.w},
+		color:    polygon.color,
+	}
+}
+
+// 平面に対する頂点・多角形の位置
+const (
+	csgCoplanar = 0
+	csgFront    = 1
+	csgBack     = 2
+	csgSpanning = csgFront | csgBack
+)
+
+// splitPolygon は多角形を平面で分割し、平面上・表側・裏側のいずれかに振り分けます
+// 平面上の多角形は、平面と同じ向きならcoplanarFront、逆向きならcoplanarBackに振り分けます
+func (c *csg) splitPolygon(plane csgPlane, polygon csgPolygon, coplanarFront, coplanarBack, front, back *[]csgPolygon) {
+	polygonType := csgCoplanar
+	types := make([]int, 0, len(polygon.vertices))
+	for _, vertex := range polygon.vertices {
+		t := plane.normal.Dot(vertex.position) - plane.w
+		vertexType := csgCoplanar
+		if t < -c.epsilon {
+			vertexType = csgBack
+		} else if t > c.epsilon {
+			vertexType = csgFront
+		}
+		polygonType |= vertexType
+		types = append(types, vertexType)
+	}
+
+	switch polygonType {
+	case csgCoplanar:
+		if plane.normal.Dot(polygon.plane.normal) > 0 {
+			*coplanarFront = append(*coplanarFront, polygon)
+		} else {
+			*coplanarBack = append(*coplanarBack, polygon)
+		}
+	case csgFront:
+		*front = append(*front, polygon)
+	case csgBack:
+		*back = append(*back, polygon)
+	case csgSpanning:
+		frontVertices := make([]csgVertex, 0, len(polygon.vertices)+1)
+		backVertices := make([]csgVertex, 0, len(polygon.vertices)+1)
+		for i, vertex := range polygon.vertices {
+			j := (i + 1) % len(polygon.vertices)
+			if types[i] != csgBack {
+				frontVertices = append(frontVertices, vertex)
+			}
+			if types[i] != csgFront {
+				backVertices = append(backVertices, vertex)
+			}
+			if types[i]|types[j] == csgSpanning {
+				// 辺が平面と交わる位置に頂点を作る
+				next := polygon.vertices[j]
+				edge := next.position.Sub(vertex.position)
+				t := (plane.w - plane.normal.Dot(vertex.position)) / plane.normal.Dot(edge)
+				split := csgVertex{
+					position:   vertex.position.Add(edge.MulScalar(t)),
+					attributes: lerpAttributeValues(vertex.attributes, next.attributes, t),
+				}
+				frontVertices = append(frontVertices, split)
+				backVertices = append(backVertices, split)
+			}
+		}
+		if len(frontVertices) >= 3 {
+			*front = append(*front, csgPolygon{vertices: frontVertices, plane: polygon.plane, color: polygon.color})
+		}
+		if len(backVertices) >= 3 {
+			*back = append(*back, csgPolygon{vertices: backVertices, plane: polygon.plane, color: polygon.color})
+		}
+	}
+}
+
+// build は多角形を木に加えます。節の平面がない場合は最初の多角形の平面を使います
+func (n *csgNode) build(polygons []csgPolygon) {
+	if len(polygons) == 0 {
+		return
+	}
+	if n.plane == nil {
+		plane := polygons[0].plane
+		n.plane = &plane
+	}
+
+	front := make([]csgPolygon, 0)
+	back := make([]csgPolygon, 0)
+	for _, polygon := range polygons {
+		n.csg.splitPolygon(*n.plane, polygon, &n.polygons, &n.polygons, &front, &back)
+	}
+	if len(front) > 0 {
+		if n.front == nil {
+			n.front = &csgNode{csg: n.csg}
+		}
+		n.front.build(front)
+	}
+	if len(back) > 0 {
+		if n.back == nil {
+			n.back = &csgNode{csg: n.csg}
+		}
+		n.back.build(back)
+	}
+}
+
+// invert は木が表す立体の内側と外側を入れ替えます
+func (n *csgNode) invert() {
+	for i, polygon := range n.polygons {
+		n.polygons[i] = n.csg.flip(polygon)
+	}
+	if n.plane != nil {
+		n.plane = &csgPlane{normal: n.plane.normal.MulScalar(-1), w: -n.plane.w}
+	}
+	if n.front != nil {
+		n.front.invert()
+	}
+	if n.back != nil {
+		n.back.invert()
+	}
+	n.front, n.back = n.back, n.front
+}
+
+// clipPolygons は木が表す立体の内側にある多角形の部分を取り除きます
+func (n *csgNode) clipPolygons(polygons []csgPolygon) []csgPolygon {
+	if n.plane == nil {
+		return slices.Clone(polygons)
+	}
+
+	front := make([]csgPolygon, 0)
+	back := make([]csgPolygon, 0)
+	for _, polygon := range polygons {
+		n.csg.splitPolygon(*n.plane, polygon, &front, &back, &front, &back)
+	}
+	if n.front != nil {
+		front = n.front.clipPolygons(front)
+	}
+	if n.back != nil {
+		back = n.back.clipPolygons(back)
+	} else {
+		// 葉の裏側は立体の内側
+		back = nil
+	}
+	return append(front, back...)
+}
+
+// clipTo は木の全ての多角形から、otherが表す立体の内側にある部分を取り除きます
+func (n *csgNode) clipTo(other *csgNode) {
+	n.polygons = other.clipPolygons(n.polygons)
+	if n.front != nil {
+		n.front.clipTo(other)
+	}
+	if n.back != nil {
+		n.back.clipTo(other)
+	}
+}
+
+// allPolygons は木の全ての多角形を返します
+func (n *csgNode) allPolygons() []csgPolygon {
+	polygons := slices.Clone(n.polygons)
+	if n.front != nil {
+		polygons = append(polygons, n.front.allPolygons()...)
+	}
+	if n.back != nil {
+		polygons = append(polygons, n.back.allPolygons()...)
+	}
+	return polygons
+}
+
+// csgMesh はCSGの結果を添字番号で表したメッシュです
+type csgMesh struct {
+	vertices  []Vector3D
+	values    [][]float64
+	triangles [][3]int
+	colors    []color.RGBA
+	layout    []VertexAttribute
+}
+
+// toObject は多角形を三角形に分割し、頂点をまとめたオブジェクトにします
+// 同じ位置（と同じ頂点属性）の頂点は VertexGrid でまとめ、面積が0の三角形とT字の継ぎ目を取り除きます
+func (c *csg) toObject(source Object, polygons []csgPolygon) Object {
+	grid := NewVertexGrid(max(c.size*MergeTolerance, minMergeTolerance))
+	triangles := make([][3]int, 0, len(polygons)*2)
+	colors := make([]color.RGBA, 0, len(polygons)*2)
+	for _, polygon := range polygons {
+		indexes := make([]int, 0, len(polygon.vertices))
+		for _, vertex := range polygon.vertices {
+			if c.layout != nil {
+				indexes = append(indexes, grid.AddVertexWithAttributes(vertex.position, vertex.attributes))
+			} else {
+				indexes = append(indexes, grid.AddVertex(vertex.position))
+			}
+		}
+		// 凸多角形なので最初の頂点から扇状に分割する
+		for i := 1; i+1 < len(indexes); i++ {
+			triangles = append(triangles, [3]int{indexes[0], indexes[i], indexes[i+1]})
+			colors = append(colors, polygon.color)
+		}
+	}
+	triangles, colors = CleanTrianglesWithColors(triangles, colors)
+
+	mesh := csgMesh{
+		vertices:  grid.Vertices(),
+		triangles: triangles,
+		colors:    colors,
+		layout:    c.layout,
+	}
+	if c.layout != nil {
+		mesh.values = grid.AttributeValues()
+	}
+	mesh.removeDegenerateTriangles()
+	mesh.splitTJunctions()
+
+	o := mesh.toObject()
+	normalizeNormals(o.Attributes)
+	if len(o.Triangles) > 0 {
+		// 補間し直した頂点属性が他の頂点と同じになった頂点をまとめる
+		o, _ = o.WeldVertices()
+	}
+	o.CullMode = source.CullMode
+	o.Texture = source.Texture
+	return o
+}
+
+// removeDegenerateTriangles は面積が0の三角形（IsDegenerateTriangle）を色と共に取り除きます
+func (m *csgMesh) removeDegenerateTriangles() {
+	triangles := make([][3]int, 0, len(m.triangles))
+	colors := make([]color.RGBA, 0, len(m.colors))
+	for i, triangle := range m.triangles {
+		if !IsDegenerateTriangle([3]Vector3D{m.vertices[triangle[0]], m.vertices[triangle[1]], m.vertices[triangle[2]]}) {
+			triangles = append(triangles, triangle)
+			colors = append(colors, m.colors[i])
+		}
+	}
+	m.triangles, m.colors = triangles, colors
+}
+
+// splitTJunctions は境界の辺の途中にある頂点（T字の継ぎ目）で、その辺を持つ三角形を分割します
+// BSP木による分割は隣の三角形の辺を分割しないため、分割した頂点が隣の三角形の辺の途中に残ります
+// 頂点属性がある場合は、辺の両端の値を補間した頂点を作って三角形の頂点属性を保ちます
+func (m *csgMesh) splitTJunctions() {
+	for pass := 0; pass < maxTJunctionPasses; pass++ {
+		if len(m.triangles) == 0 {
+			return
+		}
+		o := m.object()
+		h, sources := newHalfEdgeMesh(o)
+		issues := h.tJunctionIssues(func(f int) int { return sources[f] }, o.MergeTolerance())
+		if len(issues) == 0 {
+			return
+		}
+
+		// 三角形ごとに、最初に見つけた辺の途中にある頂点をまとめて分割する
+		type split struct {
+			from, to int
+			vertices []int
+		}
+		splits := make(map[int]*split, len(issues))
+		for _, issue := range issues {
+			t, v, from, to := issue.Triangles[0], issue.Vertices[0], issue.Vertices[1], issue.Vertices[2]
+			s, ok := splits[t]
+			if !ok {
+				s = &split{from: from, to: to}
+				splits[t] = s
+			}
+			if s.from == from && s.to == to {
+				s.vertices = append(s.vertices, v)
+			}
+		}
+
+		triangles := make([][3]int, 0, len(m.triangles)+len(issues))
+		colors := make([]color.RGBA, 0, len(m.triangles)+len(issues))
+		for t, triangle := range m.triangles {
+			s, ok := splits[t]
+			if !ok {
+				triangles = append(triangles, triangle)
+				colors = append(colors, m.colors[t])
+				continue
+			}
+			k := 0
+			for triangle[k] != s.from || triangle[(k+1)%3] != s.to {
+				k++
+			}
+			opposite := triangle[(k+2)%3]
+
+			// 辺の始点から近い順に頂点を並べ、始点・途中の頂点・終点を順に結ぶ
+			from, to := m.vertices[s.from], m.vertices[s.to]
+			slices.SortFunc(s.vertices, func(a, b int) int {
+				return cmp.Compare(m.vertices[a].DistanceTo(from), m.vertices[b].DistanceTo(from))
+			})
+			chain := append([]int{s.from}, s.vertices...)
+			chain = append(chain, s.to)
+			for i := 1; i+1 < len(chain); i++ {
+				chain[i] = m.edgeVertex(s.from, s.to, chain[i], m.vertices[chain[i]].DistanceTo(from)/to.DistanceTo(from))
+			}
+			for i := 0; i+1 < len(chain); i++ {
+				triangles = append(triangles, [3]int{chain[i], chain[i+1], opposite})
+				colors = append(colors, m.colors[t])
+			}
+		}
+		m.triangles, m.colors = triangles, colors
+	}
+}
+
+// edgeVertex は辺(from, to)の比率tの位置にある頂点vを、辺の両端の頂点属性を補間した頂点にして返します
+// 頂点属性がない場合や補間した値がvと同じ場合はvをそのまま使います
+func (m *csgMesh) edgeVertex(from, to, v int, t float64) int {
+	if m.layout == nil {
+		return v
+	}
+	attributes := lerpAttributeValues(m.values[from], m.values[to], t)
+	if equalAttributeValues(attributes, m.values[v], minMergeTolerance) {
+		return v
+	}
+	m.vertices = append(m.vertices, m.vertices[v])
+	m.values = append(m.values, attributes)
+	return len(m.vertices) - 1
+}
+
+// object は全ての頂点をそのままの添字番号で持つオブジェクトにします
+func (m *csgMesh) object() Object {
+	dObj := DynamicObject{
+		Vertices:              m.vertices,
+		Triangles:             m.triangles,
+		TriangleColors:        m.colors,
+		Attributes:            m.layout,
+		VertexAttributeValues: m.values,
+	}
+	return dObj.ToObject()
+}
+
+// toObject は使っている頂点だけを持つオブジェクトにします。Edgesは三角形の辺です
+func (m *csgMesh) toObject() Object {
+	if len(m.triangles) == 0 {
+		return Object{}
+	}
+
+	remap := make(map[int]int, len(m.vertices))
+	dObj := DynamicObject{
+		Vertices:       make([]Vertex, 0, len(m.vertices)),
+		Edges:          make([][2]int, 0, len(m.triangles)*3),
+		Triangles:      make([][3]int, 0, len(m.triangles)),
+		TriangleColors: m.colors,
+		Attributes:     m.layout,
+	}
+	for _, triangle := range m.triangles {
+		for k, index := range triangle {
+			if _, ok := remap[index]; !ok {
+				remap[index] = len(dObj.Vertices)
+				dObj.Vertices = append(dObj.Vertices, m.vertices[index])
+				if m.layout != nil {
+					dObj.VertexAttributeValues = append(dObj.VertexAttributeValues, m.values[index])
+				}
+			}
+			triangle[k] = remap[index]
+		}
+		dObj.Triangles = append(dObj.Triangles, triangle)
+		dObj.Edges = append(dObj.Edges, [2]int{triangle[0], triangle[1]}, [2]int{triangle[1], triangle[2]}, [2]int{triangle[2], triangle[0]})
+	}
+	dObj.Edges = CleanEdges(dObj.Edges)
+	return dObj.ToObject()
+}
@@ -0,0 +1,148 @@
+package domain
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// translateObject はオブジェクトの頂点を平行移動したオブジェクトを返します
+func translateObject(o Object, x, y, z float64) Object {
+	o.VertexMatrix.TransformMatrix4(NewTranslateMatrix4(x, y, z))
+	return o
+}
+
+// meshVolume は閉じたメッシュの体積を返します
+// 表面の向き（CalcNormalFromPoints）が外側を向く場合に正になります
+func meshVolume(o Object) float64 {
+	volume := 0.0
+	for _, triangle := range o.Triangles {
+		a := o.VertexMatrix.GetVertex(triangle[0])
+		b := o.VertexMatrix.GetVertex(triangle[1])
+		c := o.VertexMatrix.GetVertex(triangle[2])
+		volume -= a.Dot(b.Cross(c)) / 6
+	}
+	return volume
+}
+
+// assertCleanSolid は結果が問題のない閉じた立体であることを確認します
+func assertCleanSolid(t *testing.T, o Object) {
+	t.Helper()
+	report := o.Validate()
+	assert.True(t, report.IsValid(), "%v", report.Issues)
+	assertWeldedClosedMesh(t, o)
+	assert.Len(t, o.TriangleColors, len(o.Triangles))
+	assert.Len(t, o.Edges, len(triangleEdgeSet(o.Triangles)))
+}
+
+func TestObject_CSG_重なる箱(t *testing.T) {
+	red, blue := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 255, 255}
+	a := NewCubeObject(1, red)
+	b := translateObject(NewCubeObject(1, blue), 0.5, 0.5, 0.5)
+
+	union := a.Union(b)
+	assertCleanSolid(t, union)
+	assert.InDelta(t, 2-0.125, meshVolume(union), 1e-9)
+	// 表面の面積は2つの箱の面積から重なった部分の面積を引いたもの
+	areas := areaByColor(union)
+	assert.InDelta(t, 6-0.75, areas[red], 1e-9)
+	assert.InDelta(t, 6-0.75, areas[blue], 1e-9)
+
+	difference := a.Difference(b)
+	assertCleanSolid(t, difference)
+	assert.InDelta(t, 1-0.125, meshVolume(difference), 1e-9)
+	// 削った面はbの色を引き継ぐ
+	areas = areaByColor(difference)
+	assert.InDelta(t, 6-0.75, areas[red], 1e-9)
+	assert.InDelta(t, 0.75, areas[blue], 1e-9)
+
+	intersection := a.Intersection(b)
+	assertCleanSolid(t, intersection)
+	assert.InDelta(t, 0.125, meshVolume(intersection), 1e-9)
+	areas = areaByColor(intersection)
+	assert.InDelta(t, 0.75, areas[red], 1e-9)
+	assert.InDelta(t, 0.75, areas[blue], 1e-9)
+}
+
+func TestObject_CSG_同じ平面上の面(t *testing.T) {
+	a := NewCubeObject(1)
+
+	// 1つの面で接する箱
+	touching := translateObject(NewCubeObject(1), 1, 0, 0)
+	union := a.Union(touching)
+	assertCleanSolid(t, union)
+	assert.InDelta(t, 2, meshVolume(union), 1e-9)
+	ok, box := union.BoundingBox()
+	assert.True(t, ok)
+	assert.InDeltaSlice(t, []float64{-0.5, -0.5, -0.5}, box.Min[:], 1e-9)
+	assert.InDeltaSlice(t, []float64{1.5, 0.5, 0.5}, box.Max[:], 1e-9)
+	assert.Empty(t, a.Intersection(touching).Triangles)
+	assert.InDelta(t, 1, meshVolume(a.Difference(touching)), 1e-9)
+
+	// 4つの面が同じ平面上で重なる箱
+	shifted := translateObject(NewCubeObject(1), 0.5, 0, 0)
+	for name, result := range map[string]Object{
+		"union":        a.Union(shifted),
+		"difference":   a.Difference(shifted),
+		"intersection": a.Intersection(shifted),
+	} {
+		assertCleanSolid(t, result)
+		expected := map[string]float64{"union": 1.5, "difference": 0.5, "intersection": 0.5}[name]
+		assert.InDelta(t, expected, meshVolume(result), 1e-9, name)
+	}
+
+	// 同じ箱
+	assert.InDelta(t, 1, meshVolume(a.Union(a)), 1e-9)
+	assert.InDelta(t, 1, meshVolume(a.Intersection(a)), 1e-9)
+	assert.Empty(t, a.Difference(a).Triangles)
+}
+
+func TestObject_CSG_頂点属性(t *testing.T) {
+	sphere := NewUVSphereObject(0.6, 24, 12)
+	cube := NewCubeObject(1)
+
+	difference := cube.Difference(sphere)
+
+	assertCleanSolid(t, difference)
+	assert.Less(t, meshVolume(difference), 1.0)
+	assert.Greater(t, meshVolume(difference), 0.0)
+	// 削った球面の法線は内側（球の中心）を向き、面の向きと一致する
+	assertFacesMatchNormals(t, difference)
+	ok, uvs := difference.Attribute(AttributeUV)
+	assert.True(t, ok)
+	assert.Len(t, uvs.Values, difference.VertexMatrix.Len()*2)
+
+	// 頂点属性が異なるオブジェクト同士の結果は頂点属性を持たない
+	plain := translateObject(newWeldedCubeObject(), 0.5, 0, 0)
+	union := cube.Union(plain)
+	assert.Nil(t, union.Attributes)
+	assertCleanSolid(t, union)
+}
+
+func TestObject_CSG_空のオブジェクト(t *testing.T) {
+	a := NewCubeObject(1)
+
+	assert.InDelta(t, 1, meshVolume(a.Union(Object{})), 1e-9)
+	assert.InDelta(t, 1, meshVolume(a.Difference(Object{})), 1e-9)
+	assert.Empty(t, a.Intersection(Object{}).Triangles)
+	assert.InDelta(t, 1, meshVolume(Object{}.Union(a)), 1e-9)
+	assert.Empty(t, Object{}.Difference(a).Triangles)
+	assert.Empty(t, Object{}.Intersection(a).Triangles)
+}
+
+func TestObject_CSG_曲面(t *testing.T) {
+	a := NewIcosphereObject(0.5, 2)
+	b := translateObject(NewIcosphereObject(0.5, 2), 0.3, 0.1, 0)
+
+	union, difference, intersection := a.Union(b), a.Difference(b), a.Intersection(b)
+
+	for _, result := range []Object{union, difference, intersection} {
+		assertCleanSolid(t, result)
+	}
+	// 体積は包除原理を満たす
+	volumeA, volumeB := meshVolume(a), meshVolume(b)
+	assert.InDelta(t, volumeA+volumeB, meshVolume(union)+meshVolume(intersection), 1e-9)
+	assert.InDelta(t, volumeA, meshVolume(difference)+meshVolume(intersection), 1e-9)
+	assert.Greater(t, meshVolume(intersection), 0.0)
+}
@@ -0,0 +1,174 @@
+package domain
+
+import (
+	"math"
+)
+
+// BoundingBox は軸平行境界ボックス（AABB）を表します
+type BoundingBox struct {
+	Min Vector3D
+	Max Vector3D
+}
+
+// BoundingSphere は境界球を表します
+type BoundingSphere struct {
+	Center Vector3D
+	Radius float64
+}
+
+// Union は2つの境界ボックスを囲む境界ボックスを返します
+func (b BoundingBox) Union(other BoundingBox) BoundingBox {
+	for axis := 0; axis < 3; axis++ {
+		b.Min[axis] = math.Min(b.Min[axis], other.Min[axis])
+		b.Max[axis] = math.Max(b.Max[axis], other.Max[axis])
+	}
+	return b
+}
+
+// Center は境界ボックスの中心を返します
+func (b BoundingBox) Center() Vector3D {
+	return b.Min.Add(b.Max).MulScalar(0.5)
+}
+
+// Transform は変換行列を適用した球を囲む境界球を返します
+// 拡大縮小が軸ごとに異なる場合は、最も大きい拡大率で半径を拡大します
+func (s BoundingSphere) Transform(m Matrix4) BoundingSphere {
+	scale := 0.0
+	for col := 0; col < 3; col++ {
+		length := math.Sqrt(m[0][col]*m[0][col] + m[1][col]*m[1][col] + m[2][col]*m[2][col])
+		scale = math.Max(scale, length)
+	}
+	return BoundingSphere{
+		Center: m.MulPoint(s.Center),
+		Radius: s.Radius * scale,
+	}
+}
+
+// BoundingBox はオブジェクトの全頂点を囲む境界ボックスを返します
+// 頂点を1つも持たない場合はfalseを返します
+func (o Object) BoundingBox() (bool, BoundingBox) {
+	if o.VertexMatrix.Dense == nil || o.VertexMatrix.Len() == 0 {
+		return false, BoundingBox{}
+	}
+
+	box := BoundingBox{
+		Min: Vector3D{math.Inf(1), math.Inf(1), math.Inf(1)},
+		Max: Vector3D{math.Inf(-1), math.Inf(-1), math.Inf(-1)},
+	}
+	o.VertexMatrix.EachVertex(func(_ int, vertex Vertex) bool {
+		box = box.Union(BoundingBox{Min: vertex, Max: vertex})
+		return true
+	})
+	return true, box
+}
+
+// BoundingSphere はオブジェクトの全頂点を囲む境界球を返します
+// 中心は境界ボックスの中心、半径は中心から最も遠い頂点までの距離です
+// 頂点を1つも持たない場合はfalseを返します
+func (o Object) BoundingSphere() (bool, BoundingSphere) {
+	ok, box := o.BoundingBox()
+	if !ok {
+		return false, BoundingSphere{}
+	}
+
+	sphere := BoundingSphere{Center: box.Center()}
+	o.VertexMatrix.EachVertex(func(_ int, vertex Vertex) bool {
+		sphere.Radius = math.Max(sphere.Radius, vertex.DistanceTo(sphere.Center))
+		return true
+	})
+	return true, sphere
+}
+
+// CullResult は視錐台と境界ボリュームの位置関係を表します
+type CullResult int
+
+const (
+	// CullOutside 視錐台の完全に外側
+	CullOutside CullResult = iota
+	// CullIntersecting 視錐台の境界と交差する
+	CullIntersecting
+	// CullInside 視錐台の完全に内側
+	CullInside
+)
+
+// PlaneDistance は点からクリップ面までの符号付き距離を返します
+// 視錐台の外側が正、内側が負になります
+func (v ViewVolume) PlaneDistance(p Vector3D, clippingPlaneType ClippingPlaneType) float64 {
+	normal := v.PlaneNormal(clippingPlaneType)
+	return normal.Dot(p.Sub(v.PlanePoint(clippingPlaneType))) / normal.Distance()
+}
+
+// ClassifySphere は境界球（カメラ座標系）と視錐台の位置関係を判定します
+func (v ViewVolume) ClassifySphere(s BoundingSphere) CullResult {
+	result := CullInside
+	for _, clippingPlaneType := range ClippingPlaneTypes() {
+		distance := v.PlaneDistance(s.Center, clippingPlaneType)
+		if distance > s.Radius {
+			return CullOutside
+		}
+		if distance > -s.Radius {
+			result = CullIntersecting
+		}
+	}
+	return result
+}
+
+// ClassifyBox は境界ボックス（カメラ座標系）と視錐台の位置関係を判定します
+// クリップ面ごとに、法線方向に最も遠い頂点と最も近い頂点だけを調べます
+func (v ViewVolume) ClassifyBox(b BoundingBox) CullResult {
+	result := CullInside
+	for _, clippingPlaneType := range ClippingPlaneTypes() {
+		normal := v.PlaneNormal(clippingPlaneType)
+		var farthest, nearest Vector3D
+		for axis := 0; axis < 3; axis++ {
+			if normal[axis] >= 0 {
+				farthest[axis], nearest[axis] = b.Max[axis], b.Min[axis]
+			} else {
+				farthest[axis], nearest[axis] = b.Min[axis], b.Max[axis]
+			}
+		}
+		if v.PlaneDistance(nearest, clippingPlaneType) > 0 {
+			return CullOutside
+		}
+		if v.PlaneDistance(farthest, clippingPlaneType) > 0 {
+			result = CullIntersecting
+		}
+	}
+	return result
+}
+
+// CullingStats は視錐台カリングと面の向きによるカリングの統計です
+type CullingStats struct {
+	// Objects 全オブジェクト数
+	Objects int
+	// Culled 視錐台の外側にあるため描画しなかったオブジェクト数（頂点を持たないオブジェクトを含む）
+	Culled int
+	// Inside 視錐台の内側にあるためクリッピングを省略したオブジェクト数
+	Inside int
+	// Clipped 視錐台と交差するためクリッピングしたオブジェクト数
+	Clipped int
+	// CulledFaces 面の向き（CullMode）によって除外した三角形の数
+	CulledFaces int
+}
+
+// CullObject はオブジェクトを視錐台カリングし、描画する場合はカメラ座標系に変換したオブジェクトを返します
+// modelViewMatrixはオブジェクトのローカル座標系からカメラ座標系への変換行列です
+// 頂点を変換する前に境界球で判定し、交差する場合は変換後の境界ボックスで判定し直します
+func (v ViewVolume) CullObject(o Object, modelViewMatrix Matrix4) (CullResult, Object) {
+	ok, sphere := o.BoundingSphere()
+	if !ok {
+		return CullOutside, o
+	}
+
+	result := v.ClassifySphere(sphere.Transform(modelViewMatrix))
+	if result == CullOutside {
+		return CullOutside, o
+	}
+
+	o.VertexMatrix.TransformMatrix4(modelViewMatrix)
+	if result == CullIntersecting {
+		_, box := o.BoundingBox()
+		result = v.ClassifyBox(box)
+	}
+	return result, o
+}
@@ -0,0 +1,193 @@
+package domain
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestTetrahedra は指定した位置に小さな四面体を置きます
+func newTestTetrahedra(locations ...Vector3D) []LocatedObject {
+	locatedObjects := make([]LocatedObject, 0, len(locations))
+	for _, location := range locations {
+		locatedObjects = append(locatedObjects, LocatedObject{
+			Location: location,
+			Scale:    Vector3D{1, 1, 1},
+			Object:   NewTetrahedronObject(0.2),
+		})
+	}
+	return locatedObjects
+}
+
+func TestObject_BoundingBox(t *testing.T) {
+	obj := Object{
+		VertexMatrix: NewVertexMatrix([]Vector3D{{1, -2, 3}, {-1, 2, 0}, {0, 0, 5}}),
+	}
+
+	ok, result := obj.BoundingBox()
+
+	assert.True(t, ok)
+	assert.Equal(t, BoundingBox{Min: Vector3D{-1, -2, 0}, Max: Vector3D{1, 2, 5}}, result)
+
+	ok, _ = Object{}.BoundingBox()
+	assert.False(t, ok)
+}
+
+func TestObject_BoundingSphere(t *testing.T) {
+	obj := Object{
+		VertexMatrix: NewVertexMatrix([]Vector3D{{-1, 0, 0}, {1, 0, 0}, {0, 0.5, 0}}),
+	}
+
+	ok, result := obj.BoundingSphere()
+
+	assert.True(t, ok)
+	assert.Equal(t, Vector3D{0, 0.25, 0}, result.Center)
+	// 中心から最も遠い頂点までの距離
+	assert.InDelta(t, math.Sqrt(1+0.25*0.25), result.Radius, 1e-9)
+
+	ok, _ = Object{}.BoundingSphere()
+	assert.False(t, ok)
+}
+
+func TestBoundingSphere_Transform(t *testing.T) {
+	sphere := BoundingSphere{Center: Vector3D{1, 0, 0}, Radius: 1}
+	m := FromTRS(Vector3D{0, 0, 5}, Vector3D{0, math.Pi / 2, 0}, Vector3D{1, 3, 2})
+
+	result := sphere.Transform(m)
+
+	expected := m.MulPoint(Vector3D{1, 0, 0})
+	assert.InDelta(t, 0.0, result.Center.DistanceTo(expected), 1e-9)
+	// 最も大きい拡大率で拡大する
+	assert.InDelta(t, 3.0, result.Radius, 1e-9)
+}
+
+func TestViewVolume_ClassifySphere(t *testing.T) {
+	viewVolume := newTestWorld(16, 12).ViewVolume()
+
+	assert.Equal(t, CullInside, viewVolume.ClassifySphere(BoundingSphere{Center: Vector3D{0, 0, 2}, Radius: 0.1}))
+	// 前方クリップ面と交差する
+	assert.Equal(t, CullIntersecting, viewVolume.ClassifySphere(BoundingSphere{Center: Vector3D{0, 0, 0.1}, Radius: 0.05}))
+	// カメラの後ろ
+	assert.Equal(t, CullOutside, viewVolume.ClassifySphere(BoundingSphere{Center: Vector3D{0, 0, -2}, Radius: 0.1}))
+	// 後方クリップ面より奥
+	assert.Equal(t, CullOutside, viewVolume.ClassifySphere(BoundingSphere{Center: Vector3D{0, 0, 20}, Radius: 1}))
+	// 左側
+	assert.Equal(t, CullOutside, viewVolume.ClassifySphere(BoundingSphere{Center: Vector3D{-5, 0, 2}, Radius: 0.1}))
+}
+
+func TestViewVolume_ClassifyBox(t *testing.T) {
+	viewVolume := newTestWorld(16, 12).ViewVolume()
+
+	assert.Equal(t, CullInside, viewVolume.ClassifyBox(BoundingBox{Min: Vector3D{-0.1, -0.1, 1.9}, Max: Vector3D{0.1, 0.1, 2.1}}))
+	// 右側のクリップ面と交差する
+	assert.Equal(t, CullIntersecting, viewVolume.ClassifyBox(BoundingBox{Min: Vector3D{0, -0.1, 1.9}, Max: Vector3D{5, 0.1, 2.1}}))
+	// 上側
+	assert.Equal(t, CullOutside, viewVolume.ClassifyBox(BoundingBox{Min: Vector3D{-0.1, 5, 1.9}, Max: Vector3D{0.1, 6, 2.1}}))
+}
+
+func TestViewVolume_CullObject(t *testing.T) {
+	world := newTestWorld(16, 12)
+	viewVolume := world.ViewVolume()
+	obj := NewTetrahedronObject(0.2)
+
+	// 視錐台の外側の場合は頂点を変換しない
+	result, culled := viewVolume.CullObject(obj, NewTranslateMatrix4(0, 0, -2))
+	assert.Equal(t, CullOutside, result)
+	assert.Equal(t, obj.VertexMatrix.GetVertex(0), culled.VertexMatrix.GetVertex(0))
+
+	// 視錐台の内側の場合はカメラ座標系に変換する
+	result, inside := viewVolume.CullObject(obj, NewTranslateMatrix4(0, 0, 2))
+	assert.Equal(t, CullInside, result)
+	assert.Equal(t, obj.VertexMatrix.GetVertex(0).Add(Vector3D{0, 0, 2}), inside.VertexMatrix.GetVertex(0))
+
+	// 境界球は交差するが境界ボックスは内側にある場合は内側と判定する
+	plane := NewPlaneObject(2, 0.02, color.RGBA{})
+	result, _ = viewVolume.CullObject(plane, NewTranslateMatrix4(0, 0, 2.5))
+	assert.Equal(t, CullInside, result)
+
+	// 頂点を持たないオブジェクトは外側として扱う
+	result, _ = viewVolume.CullObject(Object{}, NewIdentityMatrix4())
+	assert.Equal(t, CullOutside, result)
+}
+
+func TestViewVolume_ClipObject_全ての三角形が外側の場合(t *testing.T) {
+	viewVolume := newTestWorld(16, 12).ViewVolume()
+	// 境界ボックスは視錐台と交差するが、三角形は視錐台の外側を通る
+	obj := Object{
+		VertexMatrix: NewVertexMatrix([]Vector3D{{-3, 1, 2}, {-1, 3, 2}, {-3, 3, 2}}),
+		Triangles:    [][3]int{{0, 1, 2}},
+	}
+
+	result := viewVolume.ClipObject(obj)
+
+	assert.Empty(t, result.Triangles)
+}
+
+func TestWorld_TransformWithStats(t *testing.T) {
+	world := newTestWorld(16, 12, newTestTetrahedra(
+		Vector3D{0, 0, 2},   // 内側
+		Vector3D{0.2, 0, 2}, // 内側
+		Vector3D{0.9, 0, 2}, // 右側のクリップ面と交差
+		Vector3D{0, 0, -2},  // カメラの後ろ
+		Vector3D{0, 0, 50},  // 後方クリップ面より奥
+		Vector3D{-10, 0, 2}, // 左側
+	)...)
+	world.Scene = []SceneNode{
+		{Name: "empty"},
+		{Name: "node", Location: Vector3D{0, 5, 2}, Scale: Vector3D{1, 1, 1}, Object: &world.LocatedObjects[0].Object},
+	}
+
+	frameBuffer, stats := world.TransformWithStats()
+
+	// 正面から見た四面体は手前の1面以外が裏面になる
+	assert.Equal(t, CullingStats{Objects: 7, Culled: 4, Inside: 2, Clipped: 1, CulledFaces: 9}, stats)
+	assert.Equal(t, world.Transform(), frameBuffer)
+}
+
+func TestWorld_TransformWithStats_カリングしない場合と同じ結果になること(t *testing.T) {
+	world := newTestWorld(16, 12, newTestTetrahedra(
+		Vector3D{0, 0, 2},
+		Vector3D{0.5, 0.1, 1.5},
+		Vector3D{0, 0, -2},
+		Vector3D{-10, 0, 2},
+	)...)
+
+	// カリングせずに全オブジェクトをクリッピングする
+	calculatedWorld := NewCalculatedWorld(world)
+	for _, obj := range world.CameraSpaceObjects() {
+		obj = world.ClipWithViewVolume(obj)
+		if len(obj.Triangles) == 0 {
+			continue
+		}
+		calculatedWorld.AddObject(world.TransformPerspectiveProjection(obj))
+	}
+	expected := calculatedWorld.RayTrace()
+
+	result, _ := world.TransformWithStats()
+
+	assert.Equal(t, expected, result)
+}
+
+func TestWorld_Transform_全てのオブジェクトが視錐台の外側の場合(t *testing.T) {
+	world := newTestWorld(16, 12, newTestTetrahedra(Vector3D{0, 0, -2})...)
+	world.LocatedObjects = append(world.LocatedObjects, LocatedObject{Scale: Vector3D{1, 1, 1}})
+
+	assert.NotPanics(t, func() {
+		assert.Empty(t, world.Transform())
+	})
+}
+
+func BenchmarkWorld_Transform_視錐台の外側のオブジェクトが多い場合(b *testing.B) {
+	locations := []Vector3D{{0, 0, 2}}
+	for i := 0; i < 100; i++ {
+		locations = append(locations, Vector3D{float64(i%10) - 20, 0, float64(i / 10)})
+	}
+	world := newTestWorld(16, 12, newTestTetrahedra(locations...)...)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		world.Transform()
+	}
+}
@@ -0,0 +1,551 @@
+package domain
+
+import (
+	"container/heap"
+	"image/color"
+	"math"
+)
+
+const (
+	// decimationBoundaryWeight 境界と色の境目に沿って頂点を留める平面の、二次誤差に掛ける重み
+	decimationBoundaryWeight = 1e3
+	// decimationSingularTolerance 最適な位置を求める連立方程式を解けないとみなす行列式の比率
+	decimationSingularTolerance = 1e-9
+)
+
+// Decimation はメッシュの簡略化（Object.Decimate）の止める条件です
+type Decimation struct {
+	// TargetTriangles 三角形の数がこの数以下になったら止める。0以下の場合は数では止めない
+	TargetTriangles int
+	// MaxError 辺を縮約する二次誤差（元の面の平面までの距離の二乗和）がこの値を超えたら止める。0以下の場合は誤差では止めない
+	MaxError float64
+}
+
+// Decimate は二次誤差（QEM、Garland-Heckbert）が小さい辺から順に縮約して三角形を減らしたオブジェクトを返します
+// 縮約した辺の2つの頂点は、二次誤差が最小になる位置の1つの頂点になります
+// 境界の辺と色が異なる三角形の境目の辺は、その辺に沿った平面の二次誤差を加えて形を保ちます
+// 位置が同じ頂点（UVの継ぎ目など）はまとめて動かし、継ぎ目の両側の頂点属性を辺に沿って補間します
+// 面が裏返る縮約と、メッシュの繋がり方が変わる縮約は行いません
+// Edgesは縮約した頂点を付け替えてから重複を除き（CleanEdges）、使わなくなった頂点は取り除きます
+// 頂点が残らない場合（頂点や三角形のないオブジェクト）は頂点を持たない空のオブジェクトを返します
+func (o Object) Decimate(d Decimation) Object {
+	if d.TargetTriangles <= 0 && d.MaxError <= 0 {
+		return o
+	}
+	if ok, _ := o.BoundingBox(); !ok {
+		return Object{CullMode: o.CullMode, Texture: o.Texture}
+	}
+
+	m := newDecimationMesh(o)
+	queue := &decimationQueue{}
+	keys, _ := m.initialEdges()
+	for _, key := range keys {
+		m.pushCandidate(queue, key[0], key[1])
+	}
+
+	for queue.Len() > 0 {
+		if d.TargetTriangles > 0 && m.triangleCount <= d.TargetTriangles {
+			break
+		}
+		c := heap.Pop(queue).(decimationCandidate)
+		if !m.isCurrent(c) {
+			continue
+		}
+		if d.MaxError > 0 && c.cost > d.MaxError {
+			break
+		}
+		ok, partners := m.collapsible(c)
+		if !ok {
+			continue
+		}
+		m.collapse(c, partners)
+		for _, neighbor := range m.neighbors(c.keep) {
+			m.pushCandidate(queue, c.keep, neighbor)
+		}
+	}
+
+	return m.toObject(o)
+}
+
+// quadric は平面までの距離の二乗和を表す4×4の対称行列です
+// 上三角の要素を行ごとに並べて保持します
+type quadric [10]float64
+
+// planeQuadric は単位法線nで点pを通る平面までの距離の二乗に重みweightを掛けた二次誤差です
+func planeQuadric(n, p Vector3D, weight float64) quadric {
+	a, b, c, d := n[0], n[1], n[2], -n.Dot(p)
+	return quadric{
+		a * a * weight, a * b * weight, a * c * weight, a * d * weight,
+		b * b * weight, b * c * weight, b * d * weight,
+		c * c * weight, c * d * weight,
+		d * d * weight,
+	}
+}
+
+func (q quadric) add(other quadric) quadric {
+	for i := range q {
+		q[i] += other[i]
+	}
+	return q
+}
+
+// evaluate は位置vの二次誤差を返します
+func (q quadric) evaluate(v Vector3D) float64 {
+	x, y, z := v[0], v[1], v[2]
+	return q[0]*x*x + 2*q[1]*x*y + 2*q[2]*x*z + 2*q[3]*x +
+		q[4]*y*y + 2*q[5]*y*z + 2*q[6]*y +
+		q[7]*z*z + 2*q[8]*z +
+		q[9]
+}
+
+// optimum は二次誤差が最小になる位置を返します
+// 面が平らで最小になる位置が1つに決まらない場合はfalseを返します
+func (q quadric) optimum() (bool, Vector3D) {
+	a11, a12, a13 := q[0], q[1], q[2]
+	a22, a23 := q[4], q[5]
+	a33 := q[7]
+	b1, b2, b3 := -q[3], -q[6], -q[8]
+
+	c11 := a22*a33 - a23*a23
+	c12 := a13*a23 - a12*a33
+	c13 := a12*a23 - a13*a22
+	det := a11*c11 + a12*c12 + a13*c13
+	scale := (a11 + a22 + a33) / 3
+	if !(math.Abs(det) > decimationSingularTolerance*scale*scale*scale) {
+		return false, Vector3D{}
+	}
+
+	c22 := a11*a33 - a13*a13
+	c23 := a12*a13 - a11*a23
+	c33 := a11*a22 - a12*a12
+	v := Vector3D{
+		(c11*b1 + c12*b2 + c13*b3) / det,
+		(c12*b1 + c22*b2 + c23*b3) / det,
+		(c13*b1 + c23*b2 + c33*b3) / det,
+	}
+	return IsFiniteVector3D(v), v
+}
+
+// decimationCandidate は縮約する辺の候補です。頂点removeを頂点keepにまとめ、位置positionに動かします
+type decimationCandidate struct {
+	cost         float64
+	keep, remove int
+	position     Vector3D
+	// keepVersion, removeVersion 候補を作った時点の頂点の更新回数（古くなった候補を見分ける）
+	keepVersion, removeVersion int
+}
+
+// decimationQueue は二次誤差が小さい順に候補を取り出す優先度付きキューです
+type decimationQueue []decimationCandidate
+
+func (q decimationQueue) Len() int           { return len(q) }
+func (q decimationQueue) Less(i, j int) bool { return q[i].cost < q[j].cost }
+func (q decimationQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *decimationQueue) Push(x any) {
+	*q = append(*q, x.(decimationCandidate))
+}
+
+func (q *decimationQueue) Pop() any {
+	old := *q
+	c := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return c
+}
+
+// decimationMesh は簡略化の途中のメッシュです
+// 位置が同じ頂点をまとめた番号（welded）で辺を縮約し、三角形は元の頂点の添字番号で保持します
+type decimationMesh struct {
+	// positions まとめた頂点ごとの位置
+	positions  []Vector3D
+	attributes [][]float64
+	welded     []int
+	// replaced 縮約で置き換えた頂点の、置き換え先の添字番号（置き換えていない頂点は自分自身）
+	replaced  []int
+	triangles [][3]int
+	colors    []color.RGBA
+	// removed 縮約で取り除いた三角形
+	removed       []bool
+	triangleCount int
+	// weldedTriangles まとめた頂点ごとの、その頂点を含む三角形の番号（取り除いた三角形を含むことがある）
+	weldedTriangles [][]int
+	quadrics        []quadric
+	versions        []int
+	alive           []bool
+}
+
+func newDecimationMesh(o Object) *decimationMesh {
+	grid := NewVertexGrid(o.MergeTolerance())
+	m := &decimationMesh{
+		attributes: make([][]float64, 0, o.VertexMatrix.Len()),
+		welded:     make([]int, 0, o.VertexMatrix.Len()),
+		replaced:   make([]int, 0, o.VertexMatrix.Len()),
+		triangles:  make([][3]int, 0, len(o.Triangles)),
+		colors:     make([]color.RGBA, 0, len(o.Triangles)),
+	}
+	o.VertexMatrix.EachVertex(func(i int, v Vertex) bool {
+		m.attributes = append(m.attributes, o.VertexAttributeValues(i))
+		m.welded = append(m.welded, grid.AddVertex(v))
+		m.replaced = append(m.replaced, i)
+		return true
+	})
+	m.positions = grid.Vertices()
+	m.weldedTriangles = make([][]int, len(m.positions))
+	m.quadrics = make([]quadric, len(m.positions))
+	m.versions = make([]int, len(m.positions))
+	m.alive = make([]bool, len(m.positions))
+
+	for i, triangle := range o.Triangles {
+		p := m.trianglePositions(triangle)
+		if m.welded[triangle[0]] == m.welded[triangle[1]] || m.welded[triangle[1]] == m.welded[triangle[2]] ||
+			m.welded[triangle[2]] == m.welded[triangle[0]] || IsDegenerateTriangle(p) {
+			continue
+		}
+		t := len(m.triangles)
+		m.triangles = append(m.triangles, triangle)
+		m.colors = append(m.colors, o.TriangleColor(i))
+		for _, index := range triangle {
+			w := m.welded[index]
+			m.weldedTriangles[w] = append(m.weldedTriangles[w], t)
+			m.alive[w] = true
+			m.quadrics[w] = m.quadrics[w].add(planeQuadric(CalcNormalFromPoints(p[0], p[1], p[2]), p[0], 1))
+		}
+	}
+	m.removed = make([]bool, len(m.triangles))
+	m.triangleCount = len(m.triangles)
+	m.addBoundaryQuadrics()
+	return m
+}
+
+// trianglePositions は三角形の頂点の位置を返します
+func (m *decimationMesh) trianglePositions(triangle [3]int) [3]Vector3D {
+	return [3]Vector3D{m.positions[m.welded[triangle[0]]], m.positions[m.welded[triangle[1]]], m.positions[m.welded[triangle[2]]]}
+}
+
+// initialEdges はまとめた頂点の番号の組（weldedEdgeKey）で表した辺を、三角形を順番に辿って見つけた順に返します
+// 辺ごとの、その辺を持つ三角形の番号も返します
+func (m *decimationMesh) initialEdges() ([][2]int, map[[2]int][]int) {
+	keys := make([][2]int, 0, len(m.triangles)*3/2)
+	edges := make(map[[2]int][]int, len(m.triangles)*3/2)
+	for t, triangle := range m.triangles {
+		for k := 0; k < 3; k++ {
+			key := weldedEdgeKey(m.welded[triangle[k]], m.welded[triangle[(k+1)%3]])
+			if _, ok := edges[key]; !ok {
+				keys = append(keys, key)
+			}
+			edges[key] = append(edges[key], t)
+		}
+	}
+	return keys, edges
+}
+
+// addBoundaryQuadrics は境界の辺と色の境目の辺に、辺を含み面に垂直な平面の二次誤差を加えます
+func (m *decimationMesh) addBoundaryQuadrics() {
+	keys, edges := m.initialEdges()
+	for _, key := range keys {
+		triangles := edges[key]
+		if len(triangles) == 2 && m.colors[triangles[0]] == m.colors[triangles[1]] {
+			continue
+		}
+		for _, t := range triangles {
+			p := m.trianglePositions(m.triangles[t])
+			a, b := m.positions[key[0]], m.positions[key[1]]
+			n := b.Sub(a).Cross(CalcNormalFromPoints(p[0], p[1], p[2])).Normalize()
+			q := planeQuadric(n, a, decimationBoundaryWeight)
+			m.quadrics[key[0]] = m.quadrics[key[0]].add(q)
+			m.quadrics[key[1]] = m.quadrics[key[1]].add(q)
+		}
+	}
+}
+
+// aliveTriangles はまとめた頂点wを含む、取り除いていない三角形の番号を返します
+func (m *decimationMesh) aliveTriangles(w int) []int {
+	triangles := make([]int, 0, len(m.weldedTriangles[w]))
+	for _, t := range m.weldedTriangles[w] {
+		if !m.removed[t] && m.containsWelded(t, w) {
+			triangles = append(triangles, t)
+		}
+	}
+	return triangles
+}
+
+func (m *decimationMesh) containsWelded(t, w int) bool {
+	for _, index := range m.triangles[t] {
+		if m.welded[index] == w {
+			return true
+		}
+	}
+	return false
+}
+
+// neighbors はまとめた頂点wと辺でつながる、まとめた頂点の番号を返します
+func (m *decimationMesh) neighbors(w int) []int {
+	neighbors := make([]int, 0, 8)
+	seen := make(map[int]bool, 8)
+	for _, t := range m.aliveTriangles(w) {
+		for _, index := range m.triangles[t] {
+			if n := m.welded[index]; n != w && !seen[n] {
+				seen[n] = true
+				neighbors = append(neighbors, n)
+			}
+		}
+	}
+	return neighbors
+}
+
+// pushCandidate は辺(a, b)を縮約する候補をキューに追加します
+// 二次誤差の最小の位置が決まらない場合は、両端と中点のうち二次誤差が小さい位置にします
+func (m *decimationMesh) pushCandidate(queue *decimationQueue, a, b int) {
+	q := m.quadrics[a].add(m.quadrics[b])
+	pa, pb := m.positions[a], m.positions[b]
+
+	candidates := []Vector3D{pa, pb, pa.Add(pb).MulScalar(0.5)}
+	if ok, optimum := q.optimum(); ok {
+		candidates = append([]Vector3D{optimum}, candidates...)
+	}
+	best, cost := candidates[0], q.evaluate(candidates[0])
+	for _, candidate := range candidates[1:] {
+		if c := q.evaluate(candidate); c < cost {
+			best, cost = candidate, c
+		}
+	}
+
+	heap.Push(queue, decimationCandidate{
+		cost:          math.Max(0, cost),
+		keep:          a,
+		remove:        b,
+		position:      best,
+		keepVersion:   m.versions[a],
+		removeVersion: m.versions[b],
+	})
+}
+
+// isCurrent は候補を作った後に両端の頂点が変わっていないかを判定します
+func (m *decimationMesh) isCurrent(c decimationCandidate) bool {
+	return m.alive[c.keep] && m.alive[c.remove] &&
+		m.versions[c.keep] == c.keepVersion && m.versions[c.remove] == c.removeVersion
+}
+
+// collapsible は候補の辺を縮約できるかを判定し、取り除く頂点の添字番号から置き換え先の添字番号への対応を返します
+// 次の場合は縮約しません
+// - 両端に共通して隣接する頂点が辺を持つ三角形以外にもある（メッシュの繋がり方が変わる）
+// - 境界の辺ではないのに両端が境界にある（メッシュがくびれる）
+// - 継ぎ目の頂点の置き換え先が辺を持つ三角形から決まらない（継ぎ目が崩れる）
+// - 周りの三角形が裏返るか潰れる
+func (m *decimationMesh) collapsible(c decimationCandidate) (bool, map[int]int) {
+	keepTriangles := m.aliveTriangles(c.keep)
+	removeTriangles := m.aliveTriangles(c.remove)
+
+	shared := make([]int, 0, 2)
+	for _, t := range keepTriangles {
+		if m.containsWelded(t, c.remove) {
+			shared = append(shared, t)
+		}
+	}
+	if len(shared) == 0 || len(shared) > 2 {
+		return false, nil
+	}
+
+	common := 0
+	keepNeighbors := make(map[int]bool, 8)
+	for _, n := range m.neighbors(c.keep) {
+		keepNeighbors[n] = true
+	}
+	for _, n := range m.neighbors(c.remove) {
+		if keepNeighbors[n] {
+			common++
+		}
+	}
+	if common != len(shared) {
+		return false, nil
+	}
+	if len(shared) == 2 && m.isBoundary(c.keep) && m.isBoundary(c.remove) {
+		return false, nil
+	}
+
+	partners := make(map[int]int, 2)
+	for _, t := range shared {
+		keepIndex, removeIndex := -1, -1
+		for _, index := range m.triangles[t] {
+			switch m.welded[index] {
+			case c.keep:
+				keepIndex = index
+			case c.remove:
+				removeIndex = index
+			}
+		}
+		if partner, ok := partners[removeIndex]; ok && partner != keepIndex {
+			return false, nil
+		}
+		partners[removeIndex] = keepIndex
+	}
+
+	isShared := func(t int) bool {
+		return t == shared[0] || (len(shared) == 2 && t == shared[1])
+	}
+	for _, t := range removeTriangles {
+		if isShared(t) {
+			continue
+		}
+		for _, index := range m.triangles[t] {
+			if m.welded[index] != c.remove {
+				continue
+			}
+			if _, ok := partners[index]; !ok {
+				return false, nil
+			}
+		}
+	}
+
+	for _, triangles := range [][]int{keepTriangles, removeTriangles} {
+		for _, t := range triangles {
+			if isShared(t) {
+				continue
+			}
+			before := m.trianglePositions(m.triangles[t])
+			after := before
+			for k, index := range m.triangles[t] {
+				if w := m.welded[index]; w == c.keep || w == c.remove {
+					after[k] = c.position
+				}
+			}
+			if IsDegenerateTriangle(after) {
+				return false, nil
+			}
+			if CalcNormalFromPoints(before[0], before[1], before[2]).Dot(CalcNormalFromPoints(after[0], after[1], after[2])) <= 0 {
+				return false, nil
+			}
+		}
+	}
+	return true, partners
+}
+
+// isBoundary はまとめた頂点wが境界の辺（1つの三角形だけが持つ辺）の端点であるかを判定します
+func (m *decimationMesh) isBoundary(w int) bool {
+	count := make(map[int]int, 8)
+	for _, t := range m.aliveTriangles(w) {
+		seen := make(map[int]bool, 2)
+		for _, index := range m.triangles[t] {
+			if n := m.welded[index]; n != w && !seen[n] {
+				seen[n] = true
+				count[n]++
+			}
+		}
+	}
+	for _, c := range count {
+		if c == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// collapse は候補の辺を縮約します
+// 取り除く頂点を使う三角形は置き換え先の頂点を使うように付け替え、辺を持つ三角形は取り除きます
+func (m *decimationMesh) collapse(c decimationCandidate, partners map[int]int) {
+	pKeep, pRemove := m.positions[c.keep], m.positions[c.remove]
+
+	// 頂点属性は縮約した位置を辺に射影した比率で補間する
+	ratio := 0.0
+	if edge := pRemove.Sub(pKeep); edge.Dot(edge) > 0 {
+		ratio = math.Max(0, math.Min(1, c.position.Sub(pKeep).Dot(edge)/edge.Dot(edge)))
+	}
+	for removeIndex, keepIndex := range partners {
+		m.attributes[keepIndex] = lerpAttributeValues(m.attributes[keepIndex], m.attributes[removeIndex], ratio)
+		m.replaced[removeIndex] = keepIndex
+	}
+
+	for _, t := range m.aliveTriangles(c.remove) {
+		if m.containsWelded(t, c.keep) {
+			m.removed[t] = true
+			m.triangleCount--
+			continue
+		}
+		for k, index := range m.triangles[t] {
+			if m.welded[index] == c.remove {
+				m.triangles[t][k] = partners[index]
+			}
+		}
+		m.weldedTriangles[c.keep] = append(m.weldedTriangles[c.keep], t)
+	}
+
+	m.positions[c.keep] = c.position
+	m.quadrics[c.keep] = m.quadrics[c.keep].add(m.quadrics[c.remove])
+	m.alive[c.remove] = false
+	m.versions[c.keep]++
+}
+
+// lerpAttributeValues は頂点属性の値aとbを比率tで線形補間します
+func lerpAttributeValues(a, b []float64, t float64) []float64 {
+	if len(a) == 0 {
+		return a
+	}
+	values := make([]float64, len(a))
+	for k := range values {
+		values[k] = a[k]*(1-t) + b[k]*t
+	}
+	return values
+}
+
+// resolve は縮約で置き換えた頂点を辿り、最終的な頂点の添字番号を返します
+func (m *decimationMesh) resolve(index int) int {
+	for m.replaced[index] != index {
+		index = m.replaced[index]
+	}
+	return index
+}
+
+// toObject は残った三角形と辺から、使わなくなった頂点を除いたオブジェクトを作ります
+func (m *decimationMesh) toObject(o Object) Object {
+	triangles := make([][3]int, 0, m.triangleCount)
+	triangleColors := make([]color.RGBA, 0, m.triangleCount)
+	for t, triangle := range m.triangles {
+		if !m.removed[t] {
+			triangles = append(triangles, triangle)
+			triangleColors = append(triangleColors, m.colors[t])
+		}
+	}
+	triangles, triangleColors = CleanTrianglesWithColors(triangles, triangleColors)
+
+	edges := make([][2]int, 0, len(o.Edges))
+	for _, edge := range o.Edges {
+		edges = append(edges, [2]int{m.resolve(edge[0]), m.resolve(edge[1])})
+	}
+	edges = CleanEdges(edges)
+
+	// 三角形か辺で使う頂点だけを残す
+	indexMap := make(map[int]int, len(m.welded))
+	vertices := make([]Vector3D, 0, len(m.welded))
+	attributes := make([][]float64, 0, len(m.welded))
+	newIndex := func(index int) int {
+		if i, ok := indexMap[index]; ok {
+			return i
+		}
+		indexMap[index] = len(vertices)
+		vertices = append(vertices, m.positions[m.welded[index]])
+		attributes = append(attributes, m.attributes[index])
+		return indexMap[index]
+	}
+	for i, triangle := range triangles {
+		triangles[i] = [3]int{newIndex(triangle[0]), newIndex(triangle[1]), newIndex(triangle[2])}
+	}
+	for i, edge := range edges {
+		edges[i] = [2]int{newIndex(edge[0]), newIndex(edge[1])}
+	}
+	if len(vertices) == 0 {
+		return Object{CullMode: o.CullMode, Texture: o.Texture}
+	}
+
+	newAttributes := newVertexAttributes(attributeLayout(o.Attributes), attributes)
+	normalizeNormals(newAttributes)
+
+	return Object{
+		VertexMatrix:   NewVertexMatrix(vertices),
+		Edges:          edges,
+		Triangles:      triangles,
+		TriangleColors: triangleColors,
+		CullMode:       o.CullMode,
+		Attributes:     newAttributes,
+		Texture:        o.Texture,
+	}
+}
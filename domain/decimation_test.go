@@ -0,0 +1,156 @@
+package domain
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// triangleArea は三角形の面積を返します
+func triangleArea(o Object, triangle [3]int) float64 {
+	a := o.VertexMatrix.GetVertex(triangle[0])
+	b := o.VertexMatrix.GetVertex(triangle[1])
+	c := o.VertexMatrix.GetVertex(triangle[2])
+	return b.Sub(a).Cross(c.Sub(a)).Distance() / 2
+}
+
+// areaByColor は色ごとの三角形の面積の合計を返します
+func areaByColor(o Object) map[color.RGBA]float64 {
+	areas := make(map[color.RGBA]float64)
+	for i, triangle := range o.Triangles {
+		areas[o.TriangleColors[i]] += triangleArea(o, triangle)
+	}
+	return areas
+}
+
+// triangleEdgeSet は三角形の辺の集合を返します
+func triangleEdgeSet(triangles [][3]int) map[[2]int]bool {
+	edges := make(map[[2]int]bool)
+	for _, triangle := range triangles {
+		for k := 0; k < 3; k++ {
+			edges[weldedEdgeKey(triangle[k], triangle[(k+1)%3])] = true
+		}
+	}
+	return edges
+}
+
+func TestObject_Decimate_目標の三角形の数(t *testing.T) {
+	o := NewIcosphereObject(1, 3)
+	assert.Greater(t, len(o.Triangles), 1000)
+
+	decimated := o.Decimate(Decimation{TargetTriangles: 200})
+
+	assert.LessOrEqual(t, len(decimated.Triangles), 200)
+	assert.Greater(t, len(decimated.Triangles), 150)
+	assert.Len(t, decimated.TriangleColors, len(decimated.Triangles))
+	assertWeldedClosedMesh(t, decimated)
+	assertConvexOutward(t, decimated)
+	assertFacesMatchNormals(t, decimated)
+
+	// 球の形を保つ
+	minimum, maximum := radiusRange(decimated)
+	assert.Greater(t, minimum, 0.9)
+	assert.Less(t, maximum, 1.1)
+
+	// Edgesは三角形の辺と一致し、全ての頂点を三角形で使う
+	edges := make(map[[2]int]bool)
+	for _, edge := range decimated.Edges {
+		edges[weldedEdgeKey(edge[0], edge[1])] = true
+	}
+	assert.Equal(t, triangleEdgeSet(decimated.Triangles), edges)
+	used := make(map[int]bool)
+	for _, triangle := range decimated.Triangles {
+		for _, index := range triangle {
+			used[index] = true
+		}
+	}
+	assert.Len(t, used, decimated.VertexMatrix.Len())
+}
+
+func TestObject_Decimate_誤差の上限(t *testing.T) {
+	// 平面上の格子は誤差0のまま減らせる
+	o := NewGridPlaneObject(2, 2, 8, 8)
+
+	decimated := o.Decimate(Decimation{MaxError: 1e-12})
+
+	assert.Less(t, len(decimated.Triangles), len(o.Triangles)/4)
+	ok, box := decimated.BoundingBox()
+	assert.True(t, ok)
+	// 境界は保つので、形と面積は変わらない
+	assert.InDeltaSlice(t, []float64{-1, -1, 0}, box.Min[:], 1e-9)
+	assert.InDeltaSlice(t, []float64{1, 1, 0}, box.Max[:], 1e-9)
+	area := 0.0
+	for _, triangle := range decimated.Triangles {
+		area += triangleArea(decimated, triangle)
+	}
+	assert.InDelta(t, 4.0, area, 1e-9)
+
+	// 曲面は誤差の上限を小さくするほど三角形が残る
+	sphere := NewUVSphereObject(1, 24, 12)
+	coarse := sphere.Decimate(Decimation{MaxError: 1e-2})
+	fine := sphere.Decimate(Decimation{MaxError: 1e-3})
+	assert.Less(t, len(coarse.Triangles), len(fine.Triangles))
+	assert.Less(t, len(fine.Triangles), len(sphere.Triangles))
+}
+
+func TestObject_Decimate_色の境目(t *testing.T) {
+	red, blue := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 255, 255}
+	o := NewGridPlaneObject(2, 2, 8, 8)
+	for i, triangle := range o.Triangles {
+		// 左半分は赤、右半分は青
+		centroid := o.VertexMatrix.GetVertex(triangle[0]).Add(o.VertexMatrix.GetVertex(triangle[1])).Add(o.VertexMatrix.GetVertex(triangle[2]))
+		if centroid[0] < 0 {
+			o.TriangleColors[i] = red
+		} else {
+			o.TriangleColors[i] = blue
+		}
+	}
+
+	decimated := o.Decimate(Decimation{TargetTriangles: 10})
+
+	// 色の境目の直線は保つ
+	areas := areaByColor(decimated)
+	assert.InDelta(t, 2.0, areas[red], 1e-9)
+	assert.InDelta(t, 2.0, areas[blue], 1e-9)
+	assert.Less(t, len(decimated.Triangles), len(o.Triangles)/4)
+}
+
+func TestObject_Decimate_継ぎ目がある立体(t *testing.T) {
+	o := NewUVSphereObject(1, 24, 12)
+
+	decimated := o.Decimate(Decimation{TargetTriangles: 150})
+
+	assert.LessOrEqual(t, len(decimated.Triangles), 150)
+	// 継ぎ目の両側の頂点はまとめて動かすので穴は開かない
+	assertWeldedClosedMesh(t, decimated)
+	assertFacesMatchNormals(t, decimated)
+	ok, uvs := decimated.Attribute(AttributeUV)
+	assert.True(t, ok)
+	assert.Len(t, uvs.Values, decimated.VertexMatrix.Len()*2)
+	for _, value := range uvs.Values {
+		assert.False(t, math.IsNaN(value))
+		assert.True(t, value >= 0 && value <= 1)
+	}
+}
+
+func TestObject_Decimate_止める条件がない(t *testing.T) {
+	o := NewCubeObject(1)
+
+	assert.Equal(t, o, o.Decimate(Decimation{}))
+	// 箱の面は2つの三角形で、これ以上減らすと形が変わる
+	assert.Len(t, o.Decimate(Decimation{MaxError: 1e-9}).Triangles, 12)
+}
+
+func TestObject_Decimate_頂点が残らない場合(t *testing.T) {
+	assert.NotPanics(t, func() {
+		assert.Equal(t, Object{CullMode: CullNone}, Object{CullMode: CullNone}.Decimate(Decimation{TargetTriangles: 1}))
+	})
+
+	// 頂点はあるが三角形も辺もないオブジェクト
+	o := Object{VertexMatrix: NewVertexMatrix([]Vector3D{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}})}
+	assert.NotPanics(t, func() {
+		assert.Equal(t, Object{}, o.Decimate(Decimation{TargetTriangles: 1}))
+	})
+}
@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"image/color"
+	"math"
+)
+
+// goldenAngle 黄金角（ラジアン）。レンズ上のサンプル点を偏りなく配置するために使用する
+var goldenAngle = math.Pi * (3 - math.Sqrt(5))
+
+// ProjectDepth はカメラ座標系のZを透視投影後の深度（NDCのz）に変換します
+func (w World) ProjectDepth(z float64) float64 {
+	zn := w.Clipping.NearDistance
+	zf := w.Clipping.FarDistance
+	return zf / (zf - zn) * (z - zn) / z
+}
+
+// LensSample はレンズの円盤上のi番目のサンプル点を返します（n点中）
+// 乱数を使わずに円盤上へ均等に分布させるため、黄金角によるらせん配置を使用します
+func LensSample(i, n int, radius float64) Vector3D {
+	r := radius * math.Sqrt((float64(i)+0.5)/float64(n))
+	theta := float64(i) * goldenAngle
+	return Vector3D{r * math.Cos(theta), r * math.Sin(theta), 0}
+}
+
+// PixelSample は画素内のi番目のサンプル位置を返します
+// 戻り値は画素の左上を(0,0)、右下を(1,1)とした座標です
+// 低食い違い量列（R2列）を使用し、0番目は画素の中心になります
+func PixelSample(i int) (float64, float64) {
+	const a1, a2 = 0.7548776662466927, 0.5698402909980532
+	x := 0.5 + float64(i)*a1
+	y := 0.5 + float64(i)*a2
+	return x - math.Floor(x), y - math.Floor(y)
+}
+
+// RayTraceThinLens は薄レンズモデルでレイトレースします
+// 1画素あたりLensSamples本のレイを、画素内の位置とレンズ上の位置を変えながら飛ばして色を平均します
+// ピント面（FocalDistance）上の物体は鮮明に、それ以外はぼけて描画されます
+// FocalDistanceがNearDistance以下の場合はピント面が決まらないため、ピンホールカメラとして描画します
+// どのレイも当たらなかった画素はFrameBufferに含めません
+func (w CalculatedWorld) RayTraceThinLens() FrameBuffer {
+	width := w.Origin.Viewport.Width
+	height := w.Origin.Viewport.Height
+	camera := w.Origin.Camera
+
+	if !(camera.FocalDistance > w.Origin.Clipping.NearDistance) {
+		pinhole := w
+		pinhole.Origin.Camera.ApertureRadius = 0
+		return pinhole.RayTrace()
+	}
+
+	samples := camera.LensSamples
+	if samples < 1 {
+		samples = 1
+	}
+
+	viewVolume := w.Origin.ViewVolume()
+	// 投影後の空間は視錐台の中心が原点になるため、オフアクシスのずれ（HorizontalShift）は含めない
+	minXframe := -viewVolume.NearClippingWidth / 2
+	maxXframe := viewVolume.NearClippingWidth / 2
+	minYframe := viewVolume.NearClippingHeight / 2
+	maxYframe := -viewVolume.NearClippingHeight / 2
+
+	// レイトレースは透視投影後の空間で行うため、ピント面の深度も投影後の値に変換する
+	focalDepth := w.Origin.ProjectDepth(camera.FocalDistance)
+
+	frameBuffer := make(FrameBuffer, width*height)
+	for xPixel := int32(0); xPixel < width; xPixel++ {
+		for yPixel := int32(0); yPixel < height; yPixel++ {
+			var r, g, b float64
+			hitCount := 0
+			minDepth := math.Inf(1)
+
+			for sampleIndex := 0; sampleIndex < samples; sampleIndex++ {
+				dx, dy := PixelSample(sampleIndex)
+				rayPoint := Vector3D{
+					((float64(xPixel)+dx)/float64(width))*(maxXframe-minXframe) + minXframe,
+					((float64(yPixel)+dy)/float64(height))*(maxYframe-minYframe) + minYframe,
+					w.Origin.Clipping.NearDistance,
+				}
+
+				// ピンホールのレイがピント面と交わる点に向けて、レンズ上の点からレイを飛ばす
+				focalPoint := rayPoint.MulScalar(focalDepth / rayPoint.Z())
+				rayOrigin := LensSample(sampleIndex, samples, camera.ApertureRadius)
+				rayDirection := focalPoint.Sub(rayOrigin).Normalize()
+
+				hit, sampleColor, depth := w.traceRay(rayOrigin, rayDirection)
+				if !hit {
+					sampleColor = backgroundColor
+				} else {
+					hitCount++
+					minDepth = math.Min(minDepth, depth)
+				}
+				r += float64(sampleColor.R)
+				g += float64(sampleColor.G)
+				b += float64(sampleColor.B)
+			}
+
+			if hitCount == 0 {
+				continue
+			}
+
+			n := float64(samples)
+			frameBuffer[FrameBufferKey{X: xPixel, Y: yPixel}] = FrameBufferValue{
+				Color: color.RGBA{uint8(math.Round(r / n)), uint8(math.Round(g / n)), uint8(math.Round(b / n)), 255},
+				Depth: minDepth,
+			}
+		}
+	}
+
+	return frameBuffer
+}
+
+// traceRay はレイと最も手前で交差する三角形の色と深度を返します
+func (w CalculatedWorld) traceRay(rayOrigin, rayDirection Vector3D) (bool, color.RGBA, float64) {
+	found := false
+	var nearestColor color.RGBA
+	nearestDepth := 0.0
+
+	for _, lObj := range w.Objects {
+		for triangleIndex, triangle := range lObj.Triangles {
+			hit, intersection := IntersectRayTriangleFrom(rayOrigin, rayDirection, lObj.VertexMatrix, triangle)
+			if !hit {
+				continue
+			}
+			depth := intersection.Z()
+			if !found || depth < nearestDepth {
+				found = true
+				nearestDepth = depth
+				// 交点の色を取得（テクスチャがない場合は三角形の色）
+				nearestColor = lObj.SurfaceColor(triangleIndex, intersection)
+			}
+		}
+	}
+
+	return found, nearestColor, nearestDepth
+}
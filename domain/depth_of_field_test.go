@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countBlendedPixels は三角形の色と背景色が混ざった画素の数を返します
+func countBlendedPixels(fb FrameBuffer) int {
+	count := 0
+	for _, v := range fb {
+		if v.Color != (color.RGBA{255, 0, 0, 255}) {
+			count++
+		}
+	}
+	return count
+}
+
+func TestWorld_ProjectDepth(t *testing.T) {
+	world := newTestWorld(40, 40, newTestPlane(2, 0.3, color.RGBA{255, 0, 0, 255}))
+
+	assert.InDelta(t, 0.0, world.ProjectDepth(0.1), 1e-9)
+	assert.InDelta(t, 1.0, world.ProjectDepth(10.0), 1e-9)
+}
+
+func TestLensSample(t *testing.T) {
+	for i := 0; i < 16; i++ {
+		p := LensSample(i, 16, 0.5)
+		assert.LessOrEqual(t, p.Distance(), 0.5)
+		assert.Equal(t, 0.0, p.Z())
+	}
+}
+
+func TestPixelSample(t *testing.T) {
+	// 0番目は画素の中心
+	x, y := PixelSample(0)
+	assert.Equal(t, 0.5, x)
+	assert.Equal(t, 0.5, y)
+
+	for i := 1; i < 16; i++ {
+		x, y := PixelSample(i)
+		assert.True(t, x >= 0 && x < 1)
+		assert.True(t, y >= 0 && y < 1)
+	}
+}
+
+func TestCalculatedWorld_RayTrace_絞りが0の場合はピンホールと同じ結果になること(t *testing.T) {
+	world := newTestWorld(40, 40, newTestPlane(2, 0.3, color.RGBA{255, 0, 0, 255}))
+	pinhole := world.Transform()
+	world.Camera = Camera{FocalDistance: 1.0, LensSamples: 16}
+	lens := world.Transform()
+
+	assert.Equal(t, pinhole, lens)
+}
+
+func TestCalculatedWorld_RayTraceThinLens_ピントの距離がない場合はピンホールと同じ結果になること(t *testing.T) {
+	world := newTestWorld(40, 40, newTestPlane(2, 0.3, color.RGBA{255, 0, 0, 255}))
+	pinhole := world.Transform()
+	for _, focalDistance := range []float64{0, -1, 0.1} {
+		world.Camera = Camera{ApertureRadius: 0.02, FocalDistance: focalDistance, LensSamples: 16}
+
+		assert.Equal(t, pinhole, world.Transform(), "%v", focalDistance)
+	}
+}
+
+func TestCalculatedWorld_RayTraceThinLens_ピント面の物体は鮮明に描画されること(t *testing.T) {
+	world := newTestWorld(40, 40, newTestPlane(2, 0.3, color.RGBA{255, 0, 0, 255}))
+	world.Camera = Camera{ApertureRadius: 0.02, FocalDistance: 2.0, LensSamples: 16}
+	focused := world.Transform()
+	world.Camera.FocalDistance = 0.5
+	defocused := world.Transform()
+
+	// 中心の画素はどちらも物体の色
+	assert.Equal(t, color.RGBA{255, 0, 0, 255}, focused[FrameBufferKey{X: 20, Y: 20}].Color)
+	assert.Equal(t, color.RGBA{255, 0, 0, 255}, defocused[FrameBufferKey{X: 20, Y: 20}].Color)
+
+	// ピントが外れている方が輪郭のぼけた画素が多い
+	assert.Greater(t, countBlendedPixels(defocused), countBlendedPixels(focused))
+}
@@ -0,0 +1,415 @@
+package domain
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+// FrameSequence は連続したフレームを表します
+type FrameSequence struct {
+	Frames []FrameBuffer
+	Width  int
+	Height int
+	// FPS 1秒あたりのフレーム数
+	FPS float64
+}
+
+// FrameTime はi番目のフレームの時刻を返します
+func FrameTime(i int, fps float64) time.Duration {
+	return time.Duration(math.Round(float64(i) / fps * float64(time.Second)))
+}
+
+// RenderSequence はワールドをframeCountフレーム分レンダリングします
+// i番目のフレームは時刻 i/fps 秒のワールド（World.At）をレンダリングしたものです
+// fpsには0より大きい値を指定します
+func (w World) RenderSequence(frameCount int, fps float64) FrameSequence {
+	frames := make([]FrameBuffer, 0, frameCount)
+	for i := 0; i < frameCount; i++ {
+		frames = append(frames, w.At(FrameTime(i, fps)).Transform())
+	}
+	return FrameSequence{
+		Frames: frames,
+		Width:  int(w.Viewport.Width),
+		Height: int(w.Viewport.Height),
+		FPS:    fps,
+	}
+}
+
+// frameDelay はフレームの表示時間を、1秒をunit等分した単位で返します
+// FPSが0以下の場合や、表示時間が形式の単位（1）未満・上限（16ビット）より大きい場合はエラーを返します
+func (s FrameSequence) frameDelay(unit float64) (int, error) {
+	if !(s.FPS > 0) || math.IsInf(s.FPS, 0) {
+		return 0, fmt.Errorf("invalid fps: %v", s.FPS)
+	}
+	delay := math.Round(unit / s.FPS)
+	if delay < 1 {
+		return 0, fmt.Errorf("fps is too large: %v", s.FPS)
+	}
+	if delay > math.MaxUint16 {
+		return 0, fmt.Errorf("fps is too small: %v", s.FPS)
+	}
+	return int(delay), nil
+}
+
+// Images は全フレームを画像に変換します
+func (s FrameSequence) Images() []*image.RGBA {
+	images := make([]*image.RGBA, 0, len(s.Frames))
+	for _, frame := range s.Frames {
+		images = append(images, frame.Image(s.Width, s.Height))
+	}
+	return images
+}
+
+// SavePNGSequence は全フレームを連番のPNGファイルとして保存します
+// pathFormatにはフレーム番号（0始まり）を埋め込む書式を指定します（例: "frame_%04d.png"）
+func (s FrameSequence) SavePNGSequence(pathFormat string) error {
+	for i, frame := range s.Frames {
+		if err := frame.SaveAsImage(s.Width, s.Height, fmt.Sprintf(pathFormat, i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveGIF は全フレームをアニメーションGIFとして保存します
+func (s FrameSequence) SaveGIF(path string, dither bool) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return s.EncodeGIF(file, dither)
+}
+
+// EncodeGIF は全フレームをアニメーションGIFとして書き出します
+// 全フレームの色からメディアンカット法で256色のパレットを作成し、
+// ditherがtrueの場合はフロイド-スタインバーグ法でディザリングします
+// フレームがない場合や、遅延時間を表せないFPS（0以下、約0.0015未満、または200より大きい）の場合はエラーを返します
+func (s FrameSequence) EncodeGIF(w io.Writer, dither bool) error {
+	if len(s.Frames) == 0 {
+		return fmt.Errorf("no frames")
+	}
+	// GIFの遅延時間は1/100秒単位
+	delay, err := s.frameDelay(100)
+	if err != nil {
+		return err
+	}
+
+	images := s.Images()
+
+	sources := make([]image.Image, 0, len(images))
+	for _, img := range images {
+		sources = append(sources, img)
+	}
+	palette := QuantizePalette(sources, 256)
+
+	var drawer draw.Drawer = draw.Src
+	if dither {
+		drawer = draw.FloydSteinberg
+	}
+
+	anim := gif.GIF{}
+	for _, img := range images {
+		paletted := image.NewPaletted(img.Bounds(), palette)
+		drawer.Draw(paletted, img.Bounds(), img, image.Point{})
+		anim.Image = append(anim.Image, paletted)
+		anim.Delay = append(anim.Delay, delay)
+	}
+
+	return gif.EncodeAll(w, &anim)
+}
+
+// SaveAPNG は全フレームをAPNG（アニメーションPNG）として保存します
+func (s FrameSequence) SaveAPNG(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return s.EncodeAPNG(file)
+}
+
+// EncodeAPNG は全フレームをAPNG（アニメーションPNG）として書き出します
+// APNGに対応していないビューアでは最初のフレームが表示されます
+// フレームがない場合や、遅延時間を表せないFPS（0以下、約0.015未満、または2000より大きい）の場合はエラーを返します
+func (s FrameSequence) EncodeAPNG(w io.Writer) error {
+	if len(s.Frames) == 0 {
+		return fmt.Errorf("no frames")
+	}
+	// 遅延時間はミリ秒単位で指定する
+	delay, err := s.frameDelay(1000)
+	if err != nil {
+		return err
+	}
+	delayNum := uint16(delay)
+	const delayDen = uint16(1000)
+
+	bw := bufio.NewWriter(w)
+
+	// PNGシグネチャ
+	if _, err := bw.Write([]byte("\x89PNG\r\n\x1a\n")); err != nil {
+		return err
+	}
+
+	// IHDR: 幅, 高さ, ビット深度8, カラータイプ6(RGBA), 圧縮0, フィルタ0, インターレースなし
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], uint32(s.Width))
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(s.Height))
+	ihdr[8] = 8
+	ihdr[9] = 6
+	if err := writePNGChunk(bw, "IHDR", ihdr); err != nil {
+		return err
+	}
+
+	// acTL: フレーム数, ループ回数（0は無限）
+	actl := make([]byte, 8)
+	binary.BigEndian.PutUint32(actl[0:], uint32(len(s.Frames)))
+	if err := writePNGChunk(bw, "acTL", actl); err != nil {
+		return err
+	}
+
+	sequenceNumber := uint32(0)
+	for i, img := range s.Images() {
+		// fcTL: シーケンス番号, 幅, 高さ, X/Yオフセット, 遅延時間, 破棄方法, 合成方法
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:], sequenceNumber)
+		binary.BigEndian.PutUint32(fctl[4:], uint32(s.Width))
+		binary.BigEndian.PutUint32(fctl[8:], uint32(s.Height))
+		binary.BigEndian.PutUint16(fctl[20:], delayNum)
+		binary.BigEndian.PutUint16(fctl[22:], delayDen)
+		if err := writePNGChunk(bw, "fcTL", fctl); err != nil {
+			return err
+		}
+		sequenceNumber++
+
+		data, err := compressRGBA(img)
+		if err != nil {
+			return err
+		}
+
+		if i == 0 {
+			// 最初のフレームは通常のPNGの画像としても扱われる
+			if err := writePNGChunk(bw, "IDAT", data); err != nil {
+				return err
+			}
+			continue
+		}
+
+		fdat := make([]byte, 4+len(data))
+		binary.BigEndian.PutUint32(fdat[0:], sequenceNumber)
+		copy(fdat[4:], data)
+		if err := writePNGChunk(bw, "fdAT", fdat); err != nil {
+			return err
+		}
+		sequenceNumber++
+	}
+
+	if err := writePNGChunk(bw, "IEND", nil); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// writePNGChunk はPNGのチャンク（長さ, 種類, データ, CRC）を書き出します
+func writePNGChunk(w io.Writer, chunkType string, data []byte) error {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header[0:], uint32(len(data)))
+	copy(header[4:], chunkType)
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+	footer := make([]byte, 4)
+	binary.BigEndian.PutUint32(footer, crc.Sum32())
+
+	for _, b := range [][]byte{header, data, footer} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compressRGBA は画像をPNGの画像データ（フィルタなしの走査線をzlibで圧縮したもの）に変換します
+func compressRGBA(img *image.RGBA) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+
+	bounds := img.Bounds()
+	row := make([]byte, 1+bounds.Dx()*4)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		// 先頭の1バイトはフィルタの種類（0: なし）
+		row[0] = 0
+		offset := img.PixOffset(bounds.Min.X, y)
+		copy(row[1:], img.Pix[offset:offset+bounds.Dx()*4])
+		if _, err := zw.Write(row); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// colorCount は色と出現回数の組です
+type colorCount struct {
+	Color color.RGBA
+	Count int
+}
+
+// QuantizePalette は画像群で使われている色から最大maxColors色のパレットを作成します
+// 色数がmaxColors以下の場合は全ての色をそのまま使い、超える場合はメディアンカット法で減色します
+func QuantizePalette(images []image.Image, maxColors int) color.Palette {
+	histogram := map[color.RGBA]int{}
+	for _, img := range images {
+		bounds := img.Bounds()
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+				histogram[c]++
+			}
+		}
+	}
+
+	colors := make([]colorCount, 0, len(histogram))
+	for c, count := range histogram {
+		colors = append(colors, colorCount{Color: c, Count: count})
+	}
+	// 結果を毎回同じにするため色の順に並べる
+	sort.Slice(colors, func(i, j int) bool {
+		a, b := colors[i].Color, colors[j].Color
+		if a.R != b.R {
+			return a.R < b.R
+		}
+		if a.G != b.G {
+			return a.G < b.G
+		}
+		if a.B != b.B {
+			return a.B < b.B
+		}
+		return a.A < b.A
+	})
+
+	if len(colors) <= maxColors {
+		palette := make(color.Palette, 0, len(colors))
+		for _, c := range colors {
+			palette = append(palette, c.Color)
+		}
+		return palette
+	}
+
+	boxes := [][]colorCount{colors}
+	for len(boxes) < maxColors {
+		// 色の範囲が最も広い箱を、範囲が最も広いチャンネルの中央値で分割する
+		target, channel, widest := -1, 0, 0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			c, r := widestChannel(box)
+			if r > widest {
+				target, channel, widest = i, c, r
+			}
+		}
+		if target < 0 {
+			break
+		}
+
+		box := boxes[target]
+		sort.SliceStable(box, func(i, j int) bool {
+			return channelValue(box[i].Color, channel) < channelValue(box[j].Color, channel)
+		})
+		total := 0
+		for _, c := range box {
+			total += c.Count
+		}
+		// 出現回数で重み付けした中央値で分割する（両側に最低1色は残す）
+		median, acc := 1, 0
+		for i, c := range box[:len(box)-1] {
+			acc += c.Count
+			if acc*2 >= total {
+				median = i + 1
+				break
+			}
+		}
+
+		boxes[target] = box[:median]
+		boxes = append(boxes, box[median:])
+	}
+
+	palette := make(color.Palette, 0, len(boxes))
+	for _, box := range boxes {
+		palette = append(palette, averageColor(box))
+	}
+	return palette
+}
+
+// channelValue はチャンネル（0:R, 1:G, 2:B, 3:A）の値を返します
+func channelValue(c color.RGBA, channel int) uint8 {
+	switch channel {
+	case 0:
+		return c.R
+	case 1:
+		return c.G
+	case 2:
+		return c.B
+	}
+	return c.A
+}
+
+// widestChannel は色の範囲が最も広いチャンネルとその範囲を返します
+func widestChannel(box []colorCount) (int, int) {
+	channel, widest := 0, -1
+	for ch := 0; ch < 4; ch++ {
+		min, max := 255, 0
+		for _, c := range box {
+			v := int(channelValue(c.Color, ch))
+			if v < min {
+				min = v
+			}
+			if v > max {
+				max = v
+			}
+		}
+		if max-min > widest {
+			channel, widest = ch, max-min
+		}
+	}
+	return channel, widest
+}
+
+// averageColor は出現回数で重み付けした平均色を返します
+func averageColor(box []colorCount) color.RGBA {
+	var r, g, b, a, total float64
+	for _, c := range box {
+		n := float64(c.Count)
+		r += float64(c.Color.R) * n
+		g += float64(c.Color.G) * n
+		b += float64(c.Color.B) * n
+		a += float64(c.Color.A) * n
+		total += n
+	}
+	return color.RGBA{
+		uint8(math.Round(r / total)),
+		uint8(math.Round(g / total)),
+		uint8(math.Round(b / total)),
+		uint8(math.Round(a / total)),
+	}
+}
@@ -0,0 +1,185 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newMovingTestPlane は1秒かけて左から右へ動く赤い平面を作ります
+func newMovingTestPlane() LocatedObject {
+	plane := newTestPlane(2, 0.3, color.RGBA{255, 0, 0, 255})
+	plane.Animation = &ObjectAnimation{
+		Location: Track[Vector3D]{
+			{Time: 0, Value: Vector3D{-0.2, 0, 2}},
+			{Time: time.Second, Value: Vector3D{0.2, 0, 2}},
+		},
+	}
+	return plane
+}
+
+// pngChunkTypes はPNGのチャンクの種類を順に返します
+func pngChunkTypes(data []byte) []string {
+	types := []string{}
+	offset := 8
+	for offset+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[offset:]))
+		types = append(types, string(data[offset+4:offset+8]))
+		offset += 12 + length
+	}
+	return types
+}
+
+func TestFrameTime(t *testing.T) {
+	assert.Equal(t, time.Duration(0), FrameTime(0, 24))
+	assert.Equal(t, 500*time.Millisecond, FrameTime(12, 24))
+}
+
+func TestWorld_RenderSequence(t *testing.T) {
+	world := newTestWorld(16, 12, newMovingTestPlane())
+
+	result := world.RenderSequence(3, 2)
+
+	assert.Len(t, result.Frames, 3)
+	assert.Equal(t, 16, result.Width)
+	assert.Equal(t, 12, result.Height)
+	assert.Equal(t, 2.0, result.FPS)
+	// 各フレームは時刻 i/fps のワールドをレンダリングしたもの
+	assert.Equal(t, world.At(500*time.Millisecond).Transform(), result.Frames[1])
+	assert.NotEqual(t, result.Frames[0], result.Frames[2])
+}
+
+func TestFrameBuffer_Image(t *testing.T) {
+	fb := FrameBuffer{
+		{X: 1, Y: 0}: {Color: color.RGBA{255, 0, 0, 255}},
+	}
+
+	img := fb.Image(2, 1)
+
+	assert.Equal(t, backgroundColor, img.RGBAAt(0, 0))
+	assert.Equal(t, color.RGBA{255, 0, 0, 255}, img.RGBAAt(1, 0))
+}
+
+func TestFrameSequence_SavePNGSequence(t *testing.T) {
+	dir := t.TempDir()
+	sequence := newTestWorld(16, 12, newMovingTestPlane()).RenderSequence(3, 2)
+
+	err := sequence.SavePNGSequence(filepath.Join(dir, "frame_%04d.png"))
+
+	assert.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		file, err := os.Open(filepath.Join(dir, fmt.Sprintf("frame_%04d.png", i)))
+		assert.NoError(t, err)
+		img, err := png.Decode(file)
+		file.Close()
+		assert.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 16, 12), img.Bounds())
+	}
+}
+
+func TestFrameSequence_EncodeGIF(t *testing.T) {
+	sequence := newTestWorld(16, 12, newMovingTestPlane()).RenderSequence(3, 10)
+
+	for _, dither := range []bool{false, true} {
+		var buf bytes.Buffer
+		err := sequence.EncodeGIF(&buf, dither)
+		assert.NoError(t, err)
+
+		decoded, err := gif.DecodeAll(&buf)
+		assert.NoError(t, err)
+		assert.Len(t, decoded.Image, 3)
+		assert.Equal(t, []int{10, 10, 10}, decoded.Delay)
+		// 色数が少ないので減色されずに元の色になる
+		r, g, b, _ := decoded.Image[0].At(0, 0).RGBA()
+		assert.Equal(t, []uint32{0xffff, 0xffff, 0xffff}, []uint32{r, g, b})
+	}
+}
+
+func TestFrameSequence_EncodeAPNG(t *testing.T) {
+	sequence := newTestWorld(16, 12, newMovingTestPlane()).RenderSequence(3, 10)
+
+	var buf bytes.Buffer
+	err := sequence.EncodeAPNG(&buf)
+	assert.NoError(t, err)
+
+	// 最初のフレームは通常のPNGとして読み込める
+	img, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+	first := sequence.Images()[0]
+	for y := 0; y < 12; y++ {
+		for x := 0; x < 16; x++ {
+			assert.Equal(t, first.RGBAAt(x, y), color.RGBAModel.Convert(img.At(x, y)))
+		}
+	}
+
+	assert.Equal(t,
+		[]string{"IHDR", "acTL", "fcTL", "IDAT", "fcTL", "fdAT", "fcTL", "fdAT", "IEND"},
+		pngChunkTypes(buf.Bytes()))
+}
+
+func TestFrameSequence_Encode_不正なFPS(t *testing.T) {
+	sequence := newTestWorld(16, 12, newMovingTestPlane()).RenderSequence(1, 10)
+
+	for _, fps := range []float64{0, -10, math.NaN(), math.Inf(1), 0.001} {
+		sequence.FPS = fps
+		assert.Error(t, sequence.EncodeGIF(io.Discard, false), "%v", fps)
+		assert.Error(t, sequence.EncodeAPNG(io.Discard), "%v", fps)
+	}
+
+	// GIFは1/100秒単位なので、APNGより小さいFPSまで表せる
+	sequence.FPS = 0.01
+	assert.NoError(t, sequence.EncodeGIF(io.Discard, false))
+	assert.Error(t, sequence.EncodeAPNG(io.Discard))
+
+	// 遅延時間が0になるFPSは表せない（APNGは1/1000秒単位なのでGIFより大きいFPSまで表せる）
+	sequence.FPS = 500
+	assert.Error(t, sequence.EncodeGIF(io.Discard, false))
+	assert.NoError(t, sequence.EncodeAPNG(io.Discard))
+	sequence.FPS = 5000
+	assert.Error(t, sequence.EncodeAPNG(io.Discard))
+}
+
+func TestFrameSequence_Encode_フレームがない場合(t *testing.T) {
+	sequence := FrameSequence{Width: 16, Height: 12, FPS: 10}
+
+	assert.Error(t, sequence.EncodeGIF(io.Discard, false))
+	assert.Error(t, sequence.EncodeAPNG(io.Discard))
+}
+
+func TestQuantizePalette(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 16), uint8(y * 16), 0, 255})
+		}
+	}
+
+	// 色数が上限以下の場合はそのまま
+	palette := QuantizePalette([]image.Image{img}, 256)
+	assert.Len(t, palette, 256)
+
+	// 上限を超える場合は減色する
+	palette = QuantizePalette([]image.Image{img}, 16)
+	assert.Len(t, palette, 16)
+	// 減色後のパレットで最も近い色との差は小さい
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			original := img.RGBAAt(x, y)
+			nearest := palette.Convert(original).(color.RGBA)
+			assert.InDelta(t, float64(original.R), float64(nearest.R), 40)
+			assert.InDelta(t, float64(original.G), float64(nearest.G), 40)
+		}
+	}
+}
@@ -0,0 +1,374 @@
+package domain
+
+import (
+	"image/color"
+	"math"
+)
+
+// Vector2D は2次元のベクトル（輪郭線や断面の点）を表します
+type Vector2D [2]float64
+
+func (v1 Vector2D) X() float64 {
+	return v1[0]
+}
+
+func (v1 Vector2D) Y() float64 {
+	return v1[1]
+}
+
+func (v1 Vector2D) Add(v2 Vector2D) Vector2D {
+	return Vector2D{v1[0] + v2[0], v1[1] + v2[1]}
+}
+
+func (v1 Vector2D) Sub(v2 Vector2D) Vector2D {
+	return Vector2D{v1[0] - v2[0], v1[1] - v2[1]}
+}
+
+func (v1 Vector2D) MulScalar(v float64) Vector2D {
+	return Vector2D{v1[0] * v, v1[1] * v}
+}
+
+func (v Vector2D) Distance() float64 {
+	return math.Sqrt(v[0]*v[0] + v[1]*v[1])
+}
+
+// Normalize は長さを1にしたベクトルを返します。長さが0のベクトルは零ベクトルのまま返します
+func (v Vector2D) Normalize() Vector2D {
+	distance := v.Distance()
+	if distance == 0 {
+		return Vector2D{}
+	}
+	return Vector2D{v[0] / distance, v[1] / distance}
+}
+
+// 以下の生成関数で作るオブジェクトは、プリミティブと同じく外側から見て表面になる頂点の順番で作り、
+// 法線（AttributeNormal）とテクスチャ座標（AttributeUV）を持ちます。colorsの扱いもプリミティブと同じです
+
+// NewLatheObject はXY平面上の輪郭線（xを半径、yを高さとする）をY軸の周りに1周回転させたオブジェクト（回転体）を作ります
+// 輪郭線は下から上に向かって並べると外側が表面になります。最初と最後の点が同じ場合は閉じた輪郭線として扱い、向きは自動で揃えます
+// segmentsは円周の分割数（3以上）です。Y軸上の点（x = 0）では三角形が1点に集まります
+// 面の順番は輪郭線の線分ごとに、円周方向の各マスです
+// 輪郭線の点が2つ未満の場合や、全ての点が同じ位置にある場合は空のオブジェクトを返します
+func NewLatheObject(profile []Vector2D, segments int, colors ...color.RGBA) Object {
+	segments = atLeast(segments, 3)
+	if len(profile) < 2 {
+		return Object{}
+	}
+	if isClosedPolyline(profile) {
+		profile = counterClockwise(profile[:len(profile)-1])
+		profile = append(profile, profile[0])
+	}
+
+	lengths := polylineLengths(profile)
+	total := lengths[len(lengths)-1]
+	if total == 0 {
+		return Object{}
+	}
+
+	b := newPrimitiveBuilder()
+	for i := 0; i < len(profile)-1; i++ {
+		from, to := profile[i], profile[i+1]
+		direction := to.Sub(from)
+		if direction.Distance() == 0 {
+			continue
+		}
+		// 進行方向の右側が外側
+		normal := Vector2D{direction[1], -direction[0]}.Normalize()
+
+		b.addIndexedGrid(segments, 1, func(column, row int) (Vector3D, Vector3D, [2]float64) {
+			u := float64(column) / float64(segments)
+			ring := ringDirection(u)
+			p := from.Add(direction.MulScalar(float64(row)))
+			return Vector3D{ring[0] * p[0], p[1], ring[2] * p[0]},
+				Vector3D{ring[0] * normal[0], normal[1], ring[2] * normal[0]},
+				[2]float64{u, 1 - (lengths[i]+float64(row)*direction.Distance())/total}
+		})
+	}
+	return b.toObject(colors)
+}
+
+// NewExtrudeObject はXY平面上の多角形をZ方向にdepthだけ押し出したオブジェクトを作ります
+// Z方向の中心は原点です。多角形の頂点の回り方はどちらでも構いません（凹多角形も扱えます）
+// capsがtrueの場合は両端（-Z側と+Z側）を耳刈り法で三角形に分割して塞ぎます
+// 面の順番は側面（多角形の辺ごと）、-Z側、+Z側です
+func NewExtrudeObject(polygon []Vector2D, depth float64, caps bool, colors ...color.RGBA) Object {
+	polygon = counterClockwise(openPolygon(polygon))
+	if len(polygon) < 3 {
+		return Object{}
+	}
+
+	b := newPrimitiveBuilder()
+	b.addProfileSides(polygon, 1, false, func(point Vector2D, normal Vector2D, row int) (Vector3D, Vector3D) {
+		return Vector3D{point[0], point[1], (float64(row) - 0.5) * depth}, Vector3D{normal[0], normal[1], 0}
+	})
+	if caps {
+		b.addProfileCap(polygon, func(point Vector2D) Vector3D {
+			return Vector3D{point[0], point[1], -depth / 2}
+		}, Vector3D{0, 0, -1})
+		b.addProfileCap(polygon, func(point Vector2D) Vector3D {
+			return Vector3D{point[0], point[1], depth / 2}
+		}, Vector3D{0, 0, 1})
+	}
+	return b.toObject(colors)
+}
+
+// SweepFrameMethod は経路に沿って断面を置く座標系の計算方法です
+type SweepFrameMethod int
+
+const (
+	// SweepRotationMinimizing 回転最小化フレーム（二重反射法）。断面が経路の周りにねじれず、直線部分でも安定します
+	SweepRotationMinimizing SweepFrameMethod = iota
+	// SweepFrenet フレネ標構。法線は曲率の中心を向きます。曲率が0の部分では直前の法線を引き継ぎます
+	SweepFrenet
+)
+
+// SweepFrame は経路上の点での断面の座標系を表します
+// 断面の点(x, y)は Origin + Normal*x + Binormal*y に置きます
+type SweepFrame struct {
+	Origin   Vector3D
+	Tangent  Vector3D
+	Normal   Vector3D
+	Binormal Vector3D
+}
+
+// SweepFrames は経路の各点での断面の座標系を計算します
+// 最初と最後の点が同じ（誤差ClippingEpsilon未満）場合は閉じた経路として扱い、最後の点を除いて返します
+func SweepFrames(path []Vector3D, method SweepFrameMethod) []SweepFrame {
+	closed := len(path) > 2 && path[0].DistanceTo(path[len(path)-1]) < ClippingEpsilon
+	if closed {
+		path = path[:len(path)-1]
+	}
+	if len(path) < 2 {
+		return []SweepFrame{}
+	}
+
+	// 接線は前後の点の差分（端点では片側の差分）
+	tangents := make([]Vector3D, len(path))
+	for i := range path {
+		prev, next := neighborIndexes(i, len(path), closed)
+		tangents[i] = path[next].Sub(path[prev]).Normalize()
+	}
+
+	frames := make([]SweepFrame, len(path))
+	initialNormal, _ := planeBasis(tangents[0])
+	for i := range path {
+		frame := SweepFrame{Origin: path[i], Tangent: tangents[i]}
+		switch {
+		case i == 0 && method == SweepFrenet:
+			frame.Normal = frenetNormal(tangents, i, closed, initialNormal)
+		case i == 0:
+			frame.Normal = initialNormal
+		case method == SweepFrenet:
+			frame.Normal = frenetNormal(tangents, i, closed, frames[i-1].Normal)
+		default:
+			frame.Normal = rotationMinimizingNormal(frames[i-1], path[i], tangents[i])
+		}
+		frame.Binormal = frame.Tangent.Cross(frame.Normal)
+		frames[i] = frame
+	}
+	return frames
+}
+
+// frenetNormal はi番目の点での接線の変化の向き（曲率の中心の向き）を法線として返します
+// 曲率が0の場合は、直前の法線を接線に直交するように補正して返します
+func frenetNormal(tangents []Vector3D, i int, closed bool, previous Vector3D) Vector3D {
+	prev, next := neighborIndexes(i, len(tangents), closed)
+	t := tangents[i]
+	change := tangents[next].Sub(tangents[prev])
+	normal := change.Sub(t.MulScalar(change.Dot(t)))
+	if normal.Distance() > ClippingEpsilon {
+		return normal.Normalize()
+	}
+
+	normal = previous.Sub(t.MulScalar(previous.Dot(t)))
+	if normal.Distance() > ClippingEpsilon {
+		return normal.Normalize()
+	}
+	u, _ := planeBasis(t)
+	return u
+}
+
+// neighborIndexes はn個の点のうちi番目の前後の点の番号を返します
+// 閉じていない場合、端点では自分自身の番号を返します
+func neighborIndexes(i, n int, closed bool) (int, int) {
+	if closed {
+		return (i + n - 1) % n, (i + 1) % n
+	}
+	prev, next := i-1, i+1
+	if prev < 0 {
+		prev = 0
+	}
+	if next > n-1 {
+		next = n - 1
+	}
+	return prev, next
+}
+
+// rotationMinimizingNormal は直前のフレームを二重反射法で次の点に移した法線を返します
+// （W. Wang et al. "Computation of Rotation Minimizing Frames", 2008）
+func rotationMinimizingNormal(previous SweepFrame, origin, tangent Vector3D) Vector3D {
+	reflect := func(v, axis Vector3D) Vector3D {
+		c := axis.Dot(axis)
+		if c == 0 {
+			return v
+		}
+		return v.Sub(axis.MulScalar(2 / c * axis.Dot(v)))
+	}
+
+	// 2点を結ぶ線分の垂直二等分面で反射する
+	v1 := origin.Sub(previous.Origin)
+	normal := reflect(previous.Normal, v1)
+	reflectedTangent := reflect(previous.Tangent, v1)
+	// 反射した接線が次の接線に重なるように、もう一度反射する
+	normal = reflect(normal, tangent.Sub(reflectedTangent))
+
+	// 誤差を除くため接線に直交させる
+	normal = normal.Sub(tangent.MulScalar(normal.Dot(tangent)))
+	if normal.Distance() == 0 {
+		u, _ := planeBasis(tangent)
+		return u
+	}
+	return normal.Normalize()
+}
+
+// NewSweepObject は断面の多角形（profile）を経路（path）に沿って動かしたオブジェクトを作ります
+// 断面の頂点の回り方はどちらでも構いません。経路の最初と最後の点が同じ場合は閉じた経路（リング状）になり、端は塞ぎません
+// （閉じた経路を回転最小化フレームで動かすと、始点と終点で断面のねじれがずれることがあります）
+// capsがtrueの場合は、開いた経路の両端を耳刈り法で三角形に分割して塞ぎます
+// 面の順番は側面の各マス、始点側、終点側です
+func NewSweepObject(profile []Vector2D, path []Vector3D, method SweepFrameMethod, caps bool, colors ...color.RGBA) Object {
+	profile = counterClockwise(openPolygon(profile))
+	frames := SweepFrames(path, method)
+	if len(profile) < 3 || len(frames) < 2 {
+		return Object{}
+	}
+	closed := len(frames) < len(path)
+
+	place := func(frame SweepFrame, v Vector2D) Vector3D {
+		return frame.Origin.Add(frame.Normal.MulScalar(v[0])).Add(frame.Binormal.MulScalar(v[1]))
+	}
+	orient := func(frame SweepFrame, v Vector2D) Vector3D {
+		return frame.Normal.MulScalar(v[0]).Add(frame.Binormal.MulScalar(v[1]))
+	}
+
+	rows := len(frames) - 1
+	if closed {
+		rows = len(frames)
+	}
+	b := newPrimitiveBuilder()
+	b.addProfileSides(profile, rows, true, func(point Vector2D, normal Vector2D, row int) (Vector3D, Vector3D) {
+		frame := frames[row%len(frames)]
+		return place(frame, point), orient(frame, normal)
+	})
+	if caps && !closed {
+		first, last := frames[0], frames[len(frames)-1]
+		b.addProfileCap(profile, func(point Vector2D) Vector3D {
+			return place(first, point)
+		}, first.Tangent.MulScalar(-1))
+		b.addProfileCap(profile, func(point Vector2D) Vector3D {
+			return place(last, point)
+		}, last.Tangent)
+	}
+	return b.toObject(colors)
+}
+
+// addProfileSides は反時計回りの多角形（断面）の辺をrows段の格子にして側面を追加します
+// fは断面の点と外向きの法線と段の番号から、位置と法線を返します
+// smoothがtrueの場合は頂点の法線を隣り合う辺の法線の平均にし、falseの場合は辺ごとに頂点を分けて角を立てます
+func (b *primitiveBuilder) addProfileSides(polygon []Vector2D, rows int, smooth bool, f func(point Vector2D, normal Vector2D, row int) (Vector3D, Vector3D)) {
+	loop := make([]Vector2D, 0, len(polygon)+1)
+	loop = append(append(loop, polygon...), polygon[0])
+	lengths := polylineLengths(loop)
+	total := lengths[len(lengths)-1]
+	edgeNormal := func(i int) Vector2D {
+		direction := polygon[(i+1)%len(polygon)].Sub(polygon[i])
+		return Vector2D{direction[1], -direction[0]}.Normalize()
+	}
+	uv := func(i, row int) [2]float64 {
+		return [2]float64{lengths[i] / total, float64(row) / float64(rows)}
+	}
+
+	if smooth {
+		columns := len(polygon)
+		b.addIndexedGrid(columns, rows, func(column, row int) (Vector3D, Vector3D, [2]float64) {
+			i := column % len(polygon)
+			normal := edgeNormal(i).Add(edgeNormal((i + len(polygon) - 1) % len(polygon))).Normalize()
+			position, n := f(polygon[i], normal, row)
+			return position, n, uv(column, row)
+		})
+		return
+	}
+
+	for i := range polygon {
+		normal := edgeNormal(i)
+		b.addIndexedGrid(1, rows, func(column, row int) (Vector3D, Vector3D, [2]float64) {
+			position, n := f(polygon[(i+column)%len(polygon)], normal, row)
+			return position, n, uv(i+column, row)
+		})
+	}
+}
+
+// addProfileCap は多角形を耳刈り法で三角形に分割し、1つの面として追加します
+// テクスチャ座標は多角形の境界ボックスに合わせます
+func (b *primitiveBuilder) addProfileCap(polygon []Vector2D, place func(point Vector2D) Vector3D, normal Vector3D) {
+	min, max := polygon[0], polygon[0]
+	for _, p := range polygon {
+		min = Vector2D{math.Min(min[0], p[0]), math.Min(min[1], p[1])}
+		max = Vector2D{math.Max(max[0], p[0]), math.Max(max[1], p[1])}
+	}
+	size := max.Sub(min)
+
+	first := len(b.vertices)
+	for _, p := range polygon {
+		u, v := 0.0, 0.0
+		if size[0] > 0 {
+			u = (p[0] - min[0]) / size[0]
+		}
+		if size[1] > 0 {
+			v = (max[1] - p[1]) / size[1]
+		}
+		b.addVertex(place(p), normal, u, v)
+	}
+
+	triangles := TriangulatePolygon2D(polygon)
+	for i := range triangles {
+		for k := range triangles[i] {
+			triangles[i][k] += first
+		}
+	}
+	b.addFace(triangles)
+}
+
+// isClosedPolyline は折れ線の最初と最後の点が同じかを判定します
+func isClosedPolyline(polyline []Vector2D) bool {
+	return len(polyline) > 3 && polyline[0] == polyline[len(polyline)-1]
+}
+
+// openPolygon は最初と最後の点が同じ場合に最後の点を除いた多角形を返します
+func openPolygon(polygon []Vector2D) []Vector2D {
+	if len(polygon) > 1 && polygon[0] == polygon[len(polygon)-1] {
+		return polygon[:len(polygon)-1]
+	}
+	return polygon
+}
+
+// counterClockwise は多角形の頂点を反時計回りに揃えた新しいスライスを返します
+func counterClockwise(polygon []Vector2D) []Vector2D {
+	result := make([]Vector2D, len(polygon))
+	copy(result, polygon)
+	if signedArea2D(polygon) < 0 {
+		for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+			result[i], result[j] = result[j], result[i]
+		}
+	}
+	return result
+}
+
+// polylineLengths は折れ線の始点から各点までの長さを返します
+func polylineLengths(polyline []Vector2D) []float64 {
+	lengths := make([]float64, len(polyline))
+	for i := 1; i < len(polyline); i++ {
+		lengths[i] = lengths[i-1] + polyline[i].Sub(polyline[i-1]).Distance()
+	}
+	return lengths
+}
@@ -0,0 +1,199 @@
+package domain
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// lShape はL字型の多角形（時計回り）です
+var lShape = []Vector2D{{0, 0}, {0, 2}, {1, 2}, {1, 1}, {2, 1}, {2, 0}}
+
+// openEdgeCount は逆向きの辺を持たない（境界にある）辺の数を返します
+func openEdgeCount(triangles [][3]int) int {
+	directedEdges := make(map[[2]int]bool)
+	for _, triangle := range triangles {
+		for k := 0; k < 3; k++ {
+			directedEdges[[2]int{triangle[k], triangle[(k+1)%3]}] = true
+		}
+	}
+	count := 0
+	for edge := range directedEdges {
+		if !directedEdges[[2]int{edge[1], edge[0]}] {
+			count++
+		}
+	}
+	return count
+}
+
+func TestNewLatheObject_閉じた輪郭線(t *testing.T) {
+	// 時計回りに並べても向きは自動で揃える
+	profile := []Vector2D{{0, -0.5}, {0, 0.5}, {1, 0.5}, {1, -0.5}, {0, -0.5}}
+
+	o := NewLatheObject(profile, 16)
+
+	assert.NotEmpty(t, o.Triangles)
+	assertWeldedClosedMesh(t, o)
+	assertFacesMatchNormals(t, o)
+	assertConvexOutward(t, o)
+	assertUVInRange(t, o, 1)
+
+	ok, box := o.BoundingBox()
+	assert.True(t, ok)
+	assert.InDelta(t, -1.0, box.Min[0], 1e-9)
+	assert.InDelta(t, 1.0, box.Max[0], 1e-9)
+	assert.InDelta(t, -0.5, box.Min[1], 1e-9)
+	assert.InDelta(t, 0.5, box.Max[1], 1e-9)
+}
+
+func TestNewLatheObject_開いた輪郭線(t *testing.T) {
+	// 下から上に並べた輪郭線（つぼのような形）は外側が表面になる
+	profile := []Vector2D{{0.5, 0}, {1, 0.5}, {0.5, 1}}
+
+	o := NewLatheObject(profile, 12)
+
+	assert.Len(t, o.Triangles, 2*12*2)
+	assertFacesMatchNormals(t, o)
+	for _, triangle := range o.Triangles {
+		a := o.VertexMatrix.GetVertex(triangle[0])
+		b := o.VertexMatrix.GetVertex(triangle[1])
+		c := o.VertexMatrix.GetVertex(triangle[2])
+		centroid := a.Add(b).Add(c).MulScalar(1.0 / 3)
+		outward := Vector3D{centroid[0], 0, centroid[2]}
+		assert.Greater(t, CalcNormalFromPoints(a, b, c).Dot(outward), 0.0, "%v", triangle)
+	}
+}
+
+func TestNewLatheObject_点が足りない(t *testing.T) {
+	for name, profile := range map[string][]Vector2D{
+		"点がない":   nil,
+		"1つの点":   {{0.5, 0}},
+		"全て同じ位置": {{0.5, 0}, {0.5, 0}, {0.5, 0}},
+	} {
+		o := NewLatheObject(profile, 8)
+		assert.Empty(t, o.Triangles, name)
+	}
+}
+
+func TestNewExtrudeObject_凹多角形(t *testing.T) {
+	o := NewExtrudeObject(lShape, 1, true)
+
+	// 側面6面×2 + 両端4個×2
+	assert.Len(t, o.Triangles, 20)
+	assertWeldedClosedMesh(t, o)
+	assertFacesMatchNormals(t, o)
+	assertUVInRange(t, o, 1)
+
+	ok, box := o.BoundingBox()
+	assert.True(t, ok)
+	assert.Equal(t, Vector3D{0, 0, -0.5}, box.Min)
+	assert.Equal(t, Vector3D{2, 2, 0.5}, box.Max)
+}
+
+func TestNewExtrudeObject_端を塞がない(t *testing.T) {
+	o := NewExtrudeObject(lShape, 1, false)
+
+	assert.Len(t, o.Triangles, 12)
+	assert.Equal(t, 12, openEdgeCount(weldedTriangles(o)))
+	assertFacesMatchNormals(t, o)
+}
+
+func TestNewExtrudeObject_頂点が足りない(t *testing.T) {
+	o := NewExtrudeObject([]Vector2D{{0, 0}, {1, 0}}, 1, true)
+
+	assert.Empty(t, o.Triangles)
+}
+
+func TestSweepFrames_正規直交(t *testing.T) {
+	path := helixPath(40)
+
+	for _, method := range []SweepFrameMethod{SweepRotationMinimizing, SweepFrenet} {
+		frames := SweepFrames(path, method)
+		assert.Len(t, frames, len(path))
+		for _, frame := range frames {
+			assert.InDelta(t, 1.0, frame.Tangent.Distance(), 1e-9)
+			assert.InDelta(t, 1.0, frame.Normal.Distance(), 1e-9)
+			assert.InDelta(t, 1.0, frame.Binormal.Distance(), 1e-9)
+			assert.InDelta(t, 0.0, frame.Tangent.Dot(frame.Normal), 1e-9)
+			assert.InDelta(t, 0.0, frame.Tangent.Dot(frame.Binormal), 1e-9)
+			assert.InDelta(t, 0.0, frame.Normal.Dot(frame.Binormal), 1e-9)
+		}
+	}
+}
+
+func TestSweepFrames_回転最小化_直線(t *testing.T) {
+	path := []Vector3D{{0, 0, 0}, {0, 0, 1}, {0, 0, 2}, {0, 0, 3}}
+
+	frames := SweepFrames(path, SweepRotationMinimizing)
+
+	for _, frame := range frames {
+		assert.InDelta(t, 0.0, frame.Normal.Sub(frames[0].Normal).Distance(), 1e-9)
+		assert.Equal(t, Vector3D{0, 0, 1}, frame.Tangent)
+	}
+}
+
+func TestSweepFrames_フレネ_円(t *testing.T) {
+	path := make([]Vector3D, 0, 33)
+	for i := 0; i <= 32; i++ {
+		angle := 2 * math.Pi * float64(i) / 32
+		path = append(path, Vector3D{math.Cos(angle), 0, math.Sin(angle)})
+	}
+
+	frames := SweepFrames(path, SweepFrenet)
+
+	// 閉じた経路は最後の点を除く
+	assert.Len(t, frames, 32)
+	for _, frame := range frames {
+		// 法線は円の中心を向く
+		assert.InDelta(t, -1.0, frame.Normal.Dot(frame.Origin), 1e-9)
+	}
+}
+
+func TestSweepFrames_点が足りない(t *testing.T) {
+	assert.Empty(t, SweepFrames([]Vector3D{{0, 0, 0}}, SweepFrenet))
+}
+
+// helixPath はY軸の周りを2周するらせんの経路を返します
+func helixPath(points int) []Vector3D {
+	path := make([]Vector3D, 0, points)
+	for i := 0; i < points; i++ {
+		angle := 4 * math.Pi * float64(i) / float64(points-1)
+		path = append(path, Vector3D{math.Cos(angle), 0.1 * angle, math.Sin(angle)})
+	}
+	return path
+}
+
+func TestNewSweepObject_らせん(t *testing.T) {
+	profile := []Vector2D{{-0.1, -0.1}, {0.1, -0.1}, {0.1, 0.1}, {-0.1, 0.1}}
+
+	for _, method := range []SweepFrameMethod{SweepRotationMinimizing, SweepFrenet} {
+		o := NewSweepObject(profile, helixPath(40), method, true)
+
+		// 側面39段×4辺×2 + 両端2個×2
+		assert.Len(t, o.Triangles, 39*4*2+4)
+		assertWeldedClosedMesh(t, o)
+		assertFacesMatchNormals(t, o)
+		assertUVInRange(t, o, 1)
+		o.VertexMatrix.EachVertex(func(i int, v Vertex) bool {
+			assert.True(t, IsFiniteVector3D(v), "%d", i)
+			return true
+		})
+	}
+}
+
+func TestNewSweepObject_閉じた経路(t *testing.T) {
+	path := make([]Vector3D, 0, 25)
+	for i := 0; i <= 24; i++ {
+		angle := 2 * math.Pi * float64(i) / 24
+		path = append(path, Vector3D{math.Cos(angle), math.Sin(angle), 0})
+	}
+	profile := []Vector2D{{0.2, 0}, {0, 0.2}, {-0.2, 0}, {0, -0.2}}
+
+	// フレネ標構なら始点と終点の断面が一致するので、ドーナツ状の閉じた立体になる
+	o := NewSweepObject(profile, path, SweepFrenet, true)
+
+	assert.Len(t, o.Triangles, 24*4*2)
+	assertWeldedClosedMesh(t, o)
+	assertFacesMatchNormals(t, o)
+}
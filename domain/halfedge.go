@@ -0,0 +1,544 @@
+package domain
+
+import (
+	"image/color"
+	"slices"
+)
+
+// HalfEdge は三角形の辺を向き付きで表した半辺です
+// 三角形fの半辺は3f, 3f+1, 3f+2番目で、三角形の頂点の順番に並びます
+type HalfEdge struct {
+	// Origin 始点の頂点の添字番号
+	Origin int
+	// Twin 同じ辺を逆向きに辿る隣の三角形の半辺の番号
+	// 境界の辺、3つ以上の三角形が共有する辺、向きが揃っていない辺では-1
+	Twin int
+	// Next 同じ三角形の次の半辺の番号
+	Next int
+	// Prev 同じ三角形の前の半辺の番号
+	Prev int
+	// Face 半辺を持つ三角形の番号
+	Face int
+}
+
+// HalfEdgeMesh は三角形メッシュを半辺で表したデータ構造です
+// 三角形・辺・頂点の隣接関係を、メッシュ全体を走査せずに辿れます
+// 位置が同じ頂点（UVの継ぎ目などで分かれた頂点）は同じ頂点として隣接関係を作ります
+// 半辺の始点は元の頂点の添字番号のままなので、オブジェクトに戻しても頂点属性は変わりません
+type HalfEdgeMesh struct {
+	Vertices       []Vector3D
+	HalfEdges      []HalfEdge
+	TriangleColors []color.RGBA
+	// Attributes 頂点ごとの属性（Objectから引き継ぐ）
+	Attributes []VertexAttribute
+	CullMode   CullMode
+	Texture    Texture
+	// welded 頂点ごとの、位置が同じ頂点をまとめた番号
+	welded []int
+	// outgoing まとめた頂点ごとの、その頂点を始点とする半辺の番号
+	outgoing [][]int
+	// edgeHalfEdges 辺（まとめた頂点の番号の組、weldedEdgeKey）ごとの、その辺の半辺の番号
+	edgeHalfEdges map[[2]int][]int
+	// edges 辺を半辺の順番に見つけた順に並べたもの
+	edges [][2]int
+}
+
+// NewHalfEdgeMesh はオブジェクトの三角形から半辺のメッシュを作ります
+// 範囲外の添字番号を含む三角形と同じ位置の頂点を含む三角形は取り除きます。頂点を持たないオブジェクト（Object{}）は空のメッシュになります
+func NewHalfEdgeMesh(o Object) HalfEdgeMesh {
+	m, _ := newHalfEdgeMesh(o)
+	return m
+}
+
+// newHalfEdgeMesh は半辺のメッシュと、三角形ごとの元のオブジェクトの三角形の番号を返します
+func newHalfEdgeMesh(o Object) (HalfEdgeMesh, []int) {
+	if o.VertexMatrix.Dense == nil {
+		m := HalfEdgeMesh{Vertices: []Vector3D{}, CullMode: o.CullMode, Texture: o.Texture}
+		m.build(nil)
+		return m, []int{}
+	}
+
+	grid := NewVertexGrid(o.MergeTolerance())
+	vertices := make([]Vector3D, 0, o.VertexMatrix.Len())
+	welded := make([]int, 0, o.VertexMatrix.Len())
+	o.VertexMatrix.EachVertex(func(i int, v Vertex) bool {
+		vertices = append(vertices, v)
+		welded = append(welded, grid.AddVertex(v))
+		return true
+	})
+
+	triangles := make([][3]int, 0, len(o.Triangles))
+	triangleColors := make([]color.RGBA, 0, len(o.Triangles))
+	sources := make([]int, 0, len(o.Triangles))
+	for i, triangle := range o.Triangles {
+		if slices.ContainsFunc(triangle[:], func(index int) bool { return index < 0 || index >= len(welded) }) {
+			continue
+		}
+		if welded[triangle[0]] == welded[triangle[1]] || welded[triangle[1]] == welded[triangle[2]] || welded[triangle[2]] == welded[triangle[0]] {
+			continue
+		}
+		triangles = append(triangles, triangle)
+		sources = append(sources, i)
+		triangleColors = append(triangleColors, o.TriangleColor(i))
+	}
+
+	m := HalfEdgeMesh{
+		Vertices:       vertices,
+		welded:         welded,
+		TriangleColors: triangleColors,
+		Attributes:     o.Attributes,
+		CullMode:       o.CullMode,
+		Texture:        o.Texture,
+	}
+	m.build(triangles)
+	return m, sources
+}
+
+// build は三角形から半辺と隣接関係を作ります
+func (m *HalfEdgeMesh) build(triangles [][3]int) {
+	m.HalfEdges = make([]HalfEdge, 0, len(triangles)*3)
+	weldedCount := 0
+	for _, w := range m.welded {
+		weldedCount = max(weldedCount, w+1)
+	}
+	m.outgoing = make([][]int, weldedCount)
+	m.edgeHalfEdges = make(map[[2]int][]int, len(triangles)*3/2)
+	m.edges = make([][2]int, 0, len(triangles)*3/2)
+
+	for f, triangle := range triangles {
+		for k := 0; k < 3; k++ {
+			h := len(m.HalfEdges)
+			m.HalfEdges = append(m.HalfEdges, HalfEdge{
+				Origin: triangle[k],
+				Twin:   -1,
+				Next:   3*f + (k+1)%3,
+				Prev:   3*f + (k+2)%3,
+				Face:   f,
+			})
+			w := m.welded[triangle[k]]
+			m.outgoing[w] = append(m.outgoing[w], h)
+
+			key := weldedEdgeKey(w, m.welded[triangle[(k+1)%3]])
+			if _, ok := m.edgeHalfEdges[key]; !ok {
+				m.edges = append(m.edges, key)
+			}
+			m.edgeHalfEdges[key] = append(m.edgeHalfEdges[key], h)
+		}
+	}
+
+	// 逆向きの半辺が1つだけある辺を対にする
+	for _, key := range m.edges {
+		halfEdges := m.edgeHalfEdges[key]
+		if len(halfEdges) != 2 {
+			continue
+		}
+		a, b := halfEdges[0], halfEdges[1]
+		if m.weldedOrigin(a) != m.weldedOrigin(b) {
+			m.HalfEdges[a].Twin = b
+			m.HalfEdges[b].Twin = a
+		}
+	}
+}
+
+// ToObject は半辺のメッシュをオブジェクトに戻します
+// Edgesは三角形の辺です。頂点を持たないメッシュは頂点のないオブジェクト（Object{}と同じ）になります
+func (m HalfEdgeMesh) ToObject() Object {
+	if len(m.Vertices) == 0 {
+		return Object{CullMode: m.CullMode, Texture: m.Texture}
+	}
+
+	triangles := make([][3]int, 0, m.FaceCount())
+	for f := 0; f < m.FaceCount(); f++ {
+		triangles = append(triangles, m.Triangle(f))
+	}
+	edges := make([][2]int, 0, len(m.edges))
+	for _, key := range m.edges {
+		h := m.edgeHalfEdges[key][0]
+		edges = append(edges, [2]int{m.HalfEdges[h].Origin, m.Destination(h)})
+	}
+
+	return Object{
+		VertexMatrix:   NewVertexMatrix(m.Vertices),
+		Edges:          CleanEdges(edges),
+		Triangles:      triangles,
+		TriangleColors: m.TriangleColors,
+		CullMode:       m.CullMode,
+		Attributes:     m.Attributes,
+		Texture:        m.Texture,
+	}
+}
+
+// FaceCount は三角形の数を返します
+func (m HalfEdgeMesh) FaceCount() int {
+	return len(m.HalfEdges) / 3
+}
+
+// Triangle はf番目の三角形の頂点の添字番号を返します
+func (m HalfEdgeMesh) Triangle(f int) [3]int {
+	return [3]int{m.HalfEdges[3*f].Origin, m.HalfEdges[3*f+1].Origin, m.HalfEdges[3*f+2].Origin}
+}
+
+// Destination は半辺hの終点の頂点の添字番号を返します
+func (m HalfEdgeMesh) Destination(h int) int {
+	return m.HalfEdges[m.HalfEdges[h].Next].Origin
+}
+
+// weldedOrigin は半辺hの始点のまとめた頂点の番号を返します
+func (m HalfEdgeMesh) weldedOrigin(h int) int {
+	return m.welded[m.HalfEdges[h].Origin]
+}
+
+// edgeKey は半辺hの辺のキーを返します
+func (m HalfEdgeMesh) edgeKey(h int) [2]int {
+	return weldedEdgeKey(m.weldedOrigin(h), m.welded[m.Destination(h)])
+}
+
+// EdgeCount は辺の数を返します
+func (m HalfEdgeMesh) EdgeCount() int {
+	return len(m.edges)
+}
+
+// FaceNeighbors はf番目の三角形と辺を共有する三角形の番号を返します
+// 向きが揃っていない三角形や、3つ以上の三角形が共有する辺の三角形も含みます
+func (m HalfEdgeMesh) FaceNeighbors(f int) []int {
+	neighbors := make([]int, 0, 3)
+	for k := 0; k < 3; k++ {
+		for _, h := range m.edgeHalfEdges[m.edgeKey(3*f+k)] {
+			if g := m.HalfEdges[h].Face; g != f && !slices.Contains(neighbors, g) {
+				neighbors = append(neighbors, g)
+			}
+		}
+	}
+	return neighbors
+}
+
+// VertexNeighbors はv番目の頂点と辺でつながる頂点（1-ring）の添字番号を返します
+// 位置が同じ頂点は、最初に見つけた三角形で使われている添字番号を1つだけ返します
+func (m HalfEdgeMesh) VertexNeighbors(v int) []int {
+	outgoing := m.outgoing[m.welded[v]]
+	neighbors := make([]int, 0, len(outgoing)+1)
+	seen := make([]int, 0, len(outgoing)+1)
+	for _, h := range outgoing {
+		// 三角形の中でvにつながる2つの頂点（次の頂点と前の頂点）
+		for _, n := range []int{m.Destination(h), m.HalfEdges[m.HalfEdges[h].Prev].Origin} {
+			if !slices.Contains(seen, m.welded[n]) {
+				seen = append(seen, m.welded[n])
+				neighbors = append(neighbors, n)
+			}
+		}
+	}
+	return neighbors
+}
+
+// VertexFaces はv番目の頂点（と位置が同じ頂点）を含む三角形の番号を返します
+func (m HalfEdgeMesh) VertexFaces(v int) []int {
+	outgoing := m.outgoing[m.welded[v]]
+	faces := make([]int, 0, len(outgoing))
+	for _, h := range outgoing {
+		faces = append(faces, m.HalfEdges[h].Face)
+	}
+	return faces
+}
+
+// IsBoundaryEdge は半辺hの辺が境界の辺（1つの三角形だけが持つ辺）であるかを判定します
+func (m HalfEdgeMesh) IsBoundaryEdge(h int) bool {
+	return len(m.edgeHalfEdges[m.edgeKey(h)]) == 1
+}
+
+// IsBoundaryVertex はv番目の頂点が境界の辺の端点であるかを判定します
+func (m HalfEdgeMesh) IsBoundaryVertex(v int) bool {
+	for _, h := range m.outgoing[m.welded[v]] {
+		if m.IsBoundaryEdge(h) || m.IsBoundaryEdge(m.HalfEdges[h].Prev) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsClosed は境界の辺がないかを判定します
+func (m HalfEdgeMesh) IsClosed() bool {
+	for _, key := range m.edges {
+		if len(m.edgeHalfEdges[key]) == 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// BoundaryLoops は境界の辺を繋いだ輪を、頂点の添字番号の列で返します
+// 輪の向きは三角形の辺の向きと同じで、添字番号は境界の辺を持つ三角形で使われているものです
+func (m HalfEdgeMesh) BoundaryLoops() [][]int {
+	loops := make([][]int, 0, 1)
+	for _, halfEdges := range m.boundaryLoopHalfEdges() {
+		loop := make([]int, 0, len(halfEdges))
+		for _, h := range halfEdges {
+			loop = append(loop, m.HalfEdges[h].Origin)
+		}
+		loops = append(loops, loop)
+	}
+	return loops
+}
+
+// boundaryLoopHalfEdges は境界の輪ごとに、輪を辿る境界の半辺の番号を返します
+func (m HalfEdgeMesh) boundaryLoopHalfEdges() [][]int {
+	visited := make([]bool, len(m.HalfEdges))
+	loops := make([][]int, 0, 1)
+	for start := range m.HalfEdges {
+		if visited[start] || !m.IsBoundaryEdge(start) {
+			continue
+		}
+
+		loop := make([]int, 0, 8)
+		h := start
+		for h >= 0 && !visited[h] {
+			visited[h] = true
+			loop = append(loop, h)
+			h = m.nextBoundaryHalfEdge(h, visited)
+		}
+		loops = append(loops, loop)
+	}
+	return loops
+}
+
+// nextBoundaryHalfEdge は境界の半辺hの終点から出る次の境界の半辺を返します（ない場合は-1）
+// 終点の周りの三角形を対の半辺で回り、同じ扇形の中の境界の半辺に進みます
+// 向きが揃っていない辺などで回れない場合は、終点から出るまだ辿っていない境界の半辺に進みます
+func (m HalfEdgeMesh) nextBoundaryHalfEdge(h int, visited []bool) int {
+	outgoing := m.outgoing[m.welded[m.Destination(h)]]
+	next := m.HalfEdges[h].Next
+	for i := 0; i < len(outgoing) && !m.IsBoundaryEdge(next) && m.HalfEdges[next].Twin >= 0; i++ {
+		next = m.HalfEdges[m.HalfEdges[next].Twin].Next
+	}
+	if m.IsBoundaryEdge(next) {
+		return next
+	}
+	for _, candidate := range outgoing {
+		if !visited[candidate] && m.IsBoundaryEdge(candidate) {
+			return candidate
+		}
+	}
+	return -1
+}
+
+// NonManifoldEdges は3つ以上の三角形が共有する辺の両端の頂点の添字番号を返します
+// 添字番号は最初の三角形で使われているもので、小さい順に並べます
+func (m HalfEdgeMesh) NonManifoldEdges() [][2]int {
+	edges := make([][2]int, 0)
+	for _, key := range m.edges {
+		if halfEdges := m.edgeHalfEdges[key]; len(halfEdges) > 2 {
+			edges = append(edges, weldedEdgeKey(m.HalfEdges[halfEdges[0]].Origin, m.Destination(halfEdges[0])))
+		}
+	}
+	return edges
+}
+
+// NonManifoldVertices は周りの三角形が1つの扇形に繋がっていない頂点（2つの面が1点で接する頂点など）の添字番号を返します
+// 位置が同じ頂点は1つだけ返します
+func (m HalfEdgeMesh) NonManifoldVertices() []int {
+	vertices := make([]int, 0)
+	seen := make([]bool, len(m.outgoing))
+	for v, w := range m.welded {
+		if seen[w] {
+			continue
+		}
+		seen[w] = true
+		if m.vertexFanCount(v) > 1 {
+			vertices = append(vertices, v)
+		}
+	}
+	return vertices
+}
+
+// vertexFanCount はv番目の頂点の周りの三角形が、頂点を端点とする辺で繋がったいくつの塊に分かれるかを返します
+func (m HalfEdgeMesh) vertexFanCount(v int) int {
+	faces := m.VertexFaces(v)
+	if len(faces) == 0 {
+		return 0
+	}
+	parents := make(map[int]int, len(faces))
+	for _, f := range faces {
+		parents[f] = f
+	}
+	var find func(f int) int
+	find = func(f int) int {
+		if parents[f] != f {
+			parents[f] = find(parents[f])
+		}
+		return parents[f]
+	}
+
+	for _, h := range m.outgoing[m.welded[v]] {
+		// vを端点とする2つの辺（hとその前の半辺）を共有する三角形を繋ぐ
+		for _, edge := range []int{h, m.HalfEdges[h].Prev} {
+			for _, other := range m.edgeHalfEdges[m.edgeKey(edge)] {
+				parents[find(m.HalfEdges[other].Face)] = find(m.HalfEdges[h].Face)
+			}
+		}
+	}
+
+	count := 0
+	for _, f := range faces {
+		if find(f) == f {
+			count++
+		}
+	}
+	return count
+}
+
+// IsManifold は3つ以上の三角形が共有する辺も、1点で接する頂点もないかを判定します
+func (m HalfEdgeMesh) IsManifold() bool {
+	return len(m.NonManifoldEdges()) == 0 && len(m.NonManifoldVertices()) == 0
+}
+
+// IsConsistentlyOriented は辺を共有する2つの三角形が、全て辺を逆向きに辿っているか（向きが揃っているか）を判定します
+func (m HalfEdgeMesh) IsConsistentlyOriented() bool {
+	for _, key := range m.edges {
+		halfEdges := m.edgeHalfEdges[key]
+		if len(halfEdges) == 2 && m.weldedOrigin(halfEdges[0]) == m.weldedOrigin(halfEdges[1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// EulerCharacteristic はオイラー標数（頂点数 - 辺の数 + 三角形の数）を返します
+// 頂点は三角形が使う頂点を、位置が同じ頂点をまとめて数えます。閉じた球面と同相なメッシュでは2になります
+func (m HalfEdgeMesh) EulerCharacteristic() int {
+	vertices := 0
+	for _, outgoing := range m.outgoing {
+		if len(outgoing) > 0 {
+			vertices++
+		}
+	}
+	return vertices - m.EdgeCount() + m.FaceCount()
+}
+
+// ConnectedComponents は辺で繋がった三角形の塊ごとに、三角形の番号を返します
+func (m HalfEdgeMesh) ConnectedComponents() [][]int {
+	visited := make([]bool, m.FaceCount())
+	components := make([][]int, 0, 1)
+	for start := 0; start < m.FaceCount(); start++ {
+		if visited[start] {
+			continue
+		}
+		visited[start] = true
+		component := []int{start}
+		for i := 0; i < len(component); i++ {
+			for _, g := range m.FaceNeighbors(component[i]) {
+				if !visited[g] {
+					visited[g] = true
+					component = append(component, g)
+				}
+			}
+		}
+		components = append(components, component)
+	}
+	return components
+}
+
+// OrientFaces は辺を共有する三角形の向きを揃えたメッシュと、裏返した三角形の数を返します
+// 繋がった塊ごとに向きを揃え、閉じた塊は表面が外側を向く方、開いた塊は裏返す三角形が少ない方に揃えます
+// 3つ以上の三角形が共有する辺は辿りません。メビウスの帯のように向きを揃えられない場合は揃えられる所まで揃えます
+func (m HalfEdgeMesh) OrientFaces() (HalfEdgeMesh, int) {
+	triangles, flipped := m.orientation()
+
+	count := 0
+	for _, f := range flipped {
+		if f {
+			count++
+		}
+	}
+
+	oriented := m
+	oriented.build(triangles)
+	return oriented, count
+}
+
+// orientation は向きを揃えた三角形と、三角形ごとに裏返したかを返します（OrientFaces）
+func (m HalfEdgeMesh) orientation() ([][3]int, []bool) {
+	triangles := make([][3]int, 0, m.FaceCount())
+	for f := 0; f < m.FaceCount(); f++ {
+		triangles = append(triangles, m.Triangle(f))
+	}
+	flipped := make([]bool, len(triangles))
+	// hasDirectedEdge は三角形が辺(a, b)をこの向きで辿るかを判定します
+	hasDirectedEdge := func(f, a, b int) bool {
+		t := triangles[f]
+		for k := 0; k < 3; k++ {
+			if m.welded[t[k]] == a && m.welded[t[(k+1)%3]] == b {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, component := range m.ConnectedComponents() {
+		visited := map[int]bool{component[0]: true}
+		queue := []int{component[0]}
+		closed := true
+		for i := 0; i < len(queue); i++ {
+			f := queue[i]
+			for k := 0; k < 3; k++ {
+				a, b := m.welded[triangles[f][k]], m.welded[triangles[f][(k+1)%3]]
+				halfEdges := m.edgeHalfEdges[weldedEdgeKey(a, b)]
+				if len(halfEdges) == 1 {
+					closed = false
+				}
+				if len(halfEdges) != 2 {
+					continue
+				}
+				for _, h := range halfEdges {
+					g := m.HalfEdges[h].Face
+					if g == f || visited[g] {
+						continue
+					}
+					visited[g] = true
+					if hasDirectedEdge(g, a, b) {
+						triangles[g][1], triangles[g][2] = triangles[g][2], triangles[g][1]
+						flipped[g] = !flipped[g]
+					}
+					queue = append(queue, g)
+				}
+			}
+		}
+
+		// 閉じた塊は符号付き体積が正の場合に内側を向いているので全て裏返す
+		// 表面の向き（CalcNormalFromPoints）は右手系の外積と逆向きなので、外側を向く場合に符号付き体積は負になる
+		// 開いた塊は裏返す三角形が少ない方の向きに揃える
+		invert := false
+		if closed {
+			invert = signedVolume(m.Vertices, triangles, component) > 0
+		} else {
+			count := 0
+			for _, f := range component {
+				if flipped[f] {
+					count++
+				}
+			}
+			invert = count*2 > len(component)
+		}
+		if invert {
+			for _, f := range component {
+				triangles[f][1], triangles[f][2] = triangles[f][2], triangles[f][1]
+				flipped[f] = !flipped[f]
+			}
+		}
+	}
+	return triangles, flipped
+}
+
+// faceArea は三角形の面積を返します
+func (m HalfEdgeMesh) faceArea(triangle [3]int) float64 {
+	a, b, c := m.Vertices[triangle[0]], m.Vertices[triangle[1]], m.Vertices[triangle[2]]
+	return b.Sub(a).Cross(c.Sub(a)).Distance() / 2
+}
+
+// signedVolume は三角形と原点が作る四面体の符号付き体積の合計を返します
+func signedVolume(vertices []Vector3D, triangles [][3]int, faces []int) float64 {
+	volume := 0.0
+	for _, f := range faces {
+		a, b, c := vertices[triangles[f][0]], vertices[triangles[f][1]], vertices[triangles[f][2]]
+		volume += a.Dot(b.Cross(c)) / 6
+	}
+	return volume
+}
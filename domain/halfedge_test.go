@@ -0,0 +1,204 @@
+package domain
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTrianglesObject は頂点と三角形だけのオブジェクトを作ります
+func newTrianglesObject(vertices []Vector3D, triangles [][3]int) Object {
+	colors := make([]color.RGBA, len(triangles))
+	for i := range colors {
+		colors[i] = color.RGBA{uint8(i), 0, 0, 255}
+	}
+	return Object{
+		VertexMatrix:   NewVertexMatrix(vertices),
+		Triangles:      triangles,
+		TriangleColors: colors,
+	}
+}
+
+// flipTriangles は指定した三角形を裏返したオブジェクトを返します
+func flipTriangles(o Object, faces ...int) Object {
+	triangles := make([][3]int, len(o.Triangles))
+	copy(triangles, o.Triangles)
+	for _, f := range faces {
+		triangles[f][1], triangles[f][2] = triangles[f][2], triangles[f][1]
+	}
+	o.Triangles = triangles
+	return o
+}
+
+func TestNewHalfEdgeMesh_閉じた立体(t *testing.T) {
+	// UVの継ぎ目で分かれた頂点は同じ頂点として扱う
+	o := NewIcosphereObject(1, 1)
+
+	m := NewHalfEdgeMesh(o)
+
+	// 42頂点 - 120辺 + 80面 = 2
+	assert.Equal(t, 80, m.FaceCount())
+	assert.Equal(t, 120, m.EdgeCount())
+	assert.Equal(t, 2, m.EulerCharacteristic())
+	assert.True(t, m.IsClosed())
+	assert.True(t, m.IsManifold())
+	assert.True(t, m.IsConsistentlyOriented())
+	assert.Empty(t, m.BoundaryLoops())
+	assert.Len(t, m.ConnectedComponents(), 1)
+
+	for h, halfEdge := range m.HalfEdges {
+		// 対の半辺は逆向きで、対の対は自分自身
+		assert.GreaterOrEqual(t, halfEdge.Twin, 0)
+		twin := m.HalfEdges[halfEdge.Twin]
+		assert.Equal(t, h, twin.Twin)
+		assert.Equal(t, m.Vertices[halfEdge.Origin], m.Vertices[m.Destination(halfEdge.Twin)])
+		assert.Equal(t, h, m.HalfEdges[m.HalfEdges[halfEdge.Next].Next].Next)
+		assert.Equal(t, h, m.HalfEdges[halfEdge.Prev].Next)
+	}
+	for f := 0; f < m.FaceCount(); f++ {
+		assert.Len(t, m.FaceNeighbors(f), 3)
+	}
+
+	// 元の20面体の頂点は5つ、辺の中点は6つの頂点とつながる
+	assert.Len(t, m.VertexNeighbors(0), 5)
+	assert.Len(t, m.VertexFaces(0), 5)
+	assert.Len(t, m.VertexNeighbors(12), 6)
+	assert.False(t, m.IsBoundaryVertex(0))
+}
+
+func TestHalfEdgeMesh_ToObject(t *testing.T) {
+	o := NewGridPlaneObject(2, 2, 2, 2, color.RGBA{1, 2, 3, 255})
+
+	converted := NewHalfEdgeMesh(o).ToObject()
+
+	assert.Equal(t, o.Triangles, converted.Triangles)
+	assert.Equal(t, o.TriangleColors, converted.TriangleColors)
+	assert.Equal(t, o.Attributes, converted.Attributes)
+	assert.Equal(t, o.VertexMatrix.Len(), converted.VertexMatrix.Len())
+	assert.Equal(t, triangleEdgeSet(o.Triangles), triangleEdgeSet(converted.Triangles))
+	assert.Len(t, converted.Edges, len(triangleEdgeSet(o.Triangles)))
+}
+
+func TestHalfEdgeMesh_境界(t *testing.T) {
+	// 3×3の格子点を持つ平面
+	m := NewHalfEdgeMesh(NewGridPlaneObject(2, 2, 2, 2))
+
+	assert.False(t, m.IsClosed())
+	assert.Equal(t, 1, m.EulerCharacteristic())
+
+	loops := m.BoundaryLoops()
+	assert.Len(t, loops, 1)
+	assert.Len(t, loops[0], 8)
+	assert.NotContains(t, loops[0], 4)
+
+	// 中心の頂点だけが境界ではない
+	for v := range m.Vertices {
+		assert.Equal(t, v != 4, m.IsBoundaryVertex(v), "%d", v)
+	}
+	// 中心の頂点は上下左右と対角線の先の2つの頂点とつながる
+	assert.Len(t, m.VertexNeighbors(4), 6)
+	assert.Len(t, m.VertexFaces(4), 6)
+
+	// 境界の半辺には対がない
+	for h, halfEdge := range m.HalfEdges {
+		assert.Equal(t, m.IsBoundaryEdge(h), halfEdge.Twin < 0)
+	}
+}
+
+func TestHalfEdgeMesh_多様体ではないメッシュ(t *testing.T) {
+	vertices := []Vector3D{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {0, -1, 0}, {0, 0, 1}, {-1, 0, 0}, {-1, -1, 0}}
+
+	// 3つの三角形が1本の辺(0, 1)を共有する
+	fin := NewHalfEdgeMesh(newTrianglesObject(vertices, [][3]int{{0, 1, 2}, {1, 0, 3}, {0, 1, 4}}))
+	assert.Equal(t, [][2]int{{0, 1}}, fin.NonManifoldEdges())
+	assert.False(t, fin.IsManifold())
+	assert.Len(t, fin.FaceNeighbors(0), 2)
+
+	// 2つの三角形が頂点0だけで接する
+	bowtie := NewHalfEdgeMesh(newTrianglesObject(vertices, [][3]int{{0, 1, 2}, {0, 5, 6}}))
+	assert.Empty(t, bowtie.NonManifoldEdges())
+	assert.Equal(t, []int{0}, bowtie.NonManifoldVertices())
+	assert.False(t, bowtie.IsManifold())
+	assert.Len(t, bowtie.ConnectedComponents(), 2)
+	assert.Len(t, bowtie.BoundaryLoops(), 2)
+
+	// 位置が同じ頂点をまとめても、返す添字番号は三角形で使われているもの
+	split := NewHalfEdgeMesh(newTrianglesObject(append([]Vector3D{{0, 0, 0}}, vertices...), [][3]int{{1, 2, 3}, {2, 0, 4}, {0, 2, 5}}))
+	assert.Equal(t, [][2]int{{1, 2}}, split.NonManifoldEdges())
+}
+
+func TestHalfEdgeMesh_向きが揃っていないメッシュ(t *testing.T) {
+	// 2つの三角形が辺(1, 2)を同じ向きで持つので、辺の半辺に対がない
+	vertices := []Vector3D{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {1, 1, 0}}
+	m := NewHalfEdgeMesh(newTrianglesObject(vertices, [][3]int{{0, 1, 2}, {1, 2, 3}}))
+
+	assert.False(t, m.IsConsistentlyOriented())
+	var loops [][]int
+	assert.NotPanics(t, func() { loops = m.BoundaryLoops() })
+	boundary := make([]int, 0, 4)
+	for _, loop := range loops {
+		boundary = append(boundary, loop...)
+	}
+	// 境界の4つの半辺（0→1、2→0、2→3、3→1）を全て辿る
+	assert.ElementsMatch(t, []int{0, 2, 2, 3}, boundary)
+
+	// UVの継ぎ目を挟んで裏返った三角形も向きが揃っていないとみなす
+	o := NewUVSphereObject(1, 8, 4)
+	for f := range o.Triangles {
+		assert.False(t, NewHalfEdgeMesh(flipTriangles(o, f)).IsConsistentlyOriented(), "%d", f)
+	}
+}
+
+func TestNewHalfEdgeMesh_空のオブジェクト(t *testing.T) {
+	m := NewHalfEdgeMesh(Object{})
+
+	assert.Equal(t, 0, m.FaceCount())
+	assert.Empty(t, m.BoundaryLoops())
+	assert.Empty(t, m.ConnectedComponents())
+	oriented, count := m.OrientFaces()
+	assert.Equal(t, 0, count)
+	assert.Empty(t, oriented.ToObject().Triangles)
+}
+
+func TestHalfEdgeMesh_OrientFaces(t *testing.T) {
+	o := NewIcosphereObject(1, 1)
+
+	// 向きが揃っている場合は何もしない
+	_, count := NewHalfEdgeMesh(o).OrientFaces()
+	assert.Equal(t, 0, count)
+
+	// 一部の三角形が裏返っている場合は周りに揃える
+	broken := NewHalfEdgeMesh(flipTriangles(o, 3, 10, 11))
+	assert.False(t, broken.IsConsistentlyOriented())
+	assert.Less(t, broken.HalfEdges[9].Twin, 0)
+	oriented, count := broken.OrientFaces()
+	assert.Equal(t, 3, count)
+	assert.True(t, oriented.IsConsistentlyOriented())
+	assert.Equal(t, o.Triangles[3], oriented.Triangle(3))
+	assertConvexOutward(t, oriented.ToObject())
+
+	// 全て裏返っている場合は外側に向け直す
+	allFlipped := make([]int, len(o.Triangles))
+	for i := range allFlipped {
+		allFlipped[i] = i
+	}
+	inverted := NewHalfEdgeMesh(flipTriangles(o, allFlipped...))
+	assert.True(t, inverted.IsConsistentlyOriented())
+	oriented, count = inverted.OrientFaces()
+	assert.Equal(t, len(o.Triangles), count)
+	assertConvexOutward(t, oriented.ToObject())
+	// 色は三角形と共に残る
+	assert.Equal(t, o.TriangleColors, oriented.TriangleColors)
+}
+
+func TestHalfEdgeMesh_OrientFaces_開いたメッシュ(t *testing.T) {
+	o := NewGridPlaneObject(2, 2, 2, 2)
+
+	// 開いたメッシュは裏返す三角形が少ない方の向きに揃える
+	oriented, count := NewHalfEdgeMesh(flipTriangles(o, 0, 1, 5)).OrientFaces()
+
+	assert.Equal(t, 3, count)
+	assert.True(t, oriented.IsConsistentlyOriented())
+	assert.Equal(t, o.Triangles, oriented.ToObject().Triangles)
+}
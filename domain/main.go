@@ -13,8 +13,13 @@ import (
 type World struct {
 	Camera         Camera
 	LocatedObjects []LocatedObject
-	Viewport       Viewport
-	Clipping       Clipping
+	// Scene シーングラフのルートノード
+	// 子ノードは親ノードの変換を引き継ぐ
+	Scene    []SceneNode
+	Viewport Viewport
+	Clipping Clipping
+	// ClipPlanes ワールド座標系の切断面（断面図）。ビューボリュームのクリップ面とは別に適用する
+	ClipPlanes []ClipPlane
 }
 
 type Viewport struct {
@@ -32,32 +37,120 @@ type Clipping struct {
 	FarDistance float64
 	// FieldOfView 視野角(単位：ラジアン)
 	FieldOfView float64
+	// HorizontalShift 前方クリップ面での視錐台の水平方向のずれ（オフアクシス投影）
+	// 0の場合は左右対称の視錐台になる
+	HorizontalShift float64
+	// ClipSpace trueの場合は投影後のクリップ空間（-w ≤ x,y ≤ w, 0 ≤ z ≤ w）でクリッピングする
+	ClipSpace bool
+	// Projection カメラ座標系からクリップ空間への投影行列（平行投影など）
+	// 指定した場合はFieldOfViewなどから作る透視投影行列の代わりに使い、常にクリップ空間でクリッピングする
+	Projection *Matrix4
 }
 
 func (w World) Transform() FrameBuffer {
-	calculatedWorld := NewCalculatedWorld(w)
-	for _, locatedObj := range w.LocatedObjects {
-		obj := locatedObj.Object
+	frameBuffer, _ := w.TransformWithStats()
+	return frameBuffer
+}
+
+// TransformWithStats はワールドをレンダリングし、カリングの統計と共に返します
+// 視錐台の外側にあるオブジェクトは頂点を変換せずに除外し、内側にあるオブジェクトはクリッピングを省略します
+// 面の向きによるカリング（Object.CullMode）はクリッピングの前に行います
+// Clipping.ClipSpaceがtrueかClipping.Projectionを指定した場合はTransformClipSpaceでレンダリングします
+func (w World) TransformWithStats() (FrameBuffer, CullingStats) {
+	if w.Clipping.ClipSpace || w.Clipping.Projection != nil {
+		return w.TransformClipSpace()
+	}
 
-		// ワールド座標変換
-		obj.VertexMatrix.TransformScale(locatedObj.Scale.X(), locatedObj.Scale.Y(), locatedObj.Scale.Z())
-		obj.VertexMatrix.TransformRotate(locatedObj.Rotation.X(), locatedObj.Rotation.Y(), locatedObj.Rotation.Z())
-		obj.VertexMatrix.TransformTranslate(locatedObj.Location.X(), locatedObj.Location.Y(), locatedObj.Location.Z())
+	viewVolume := w.ViewVolume()
+	viewMatrix := w.Camera.ViewMatrix()
+	stats := CullingStats{}
 
-		// カメラ座標変換
-		obj.VertexMatrix.TransformTranslate(-w.Camera.Location.X(), -w.Camera.Location.Y(), -w.Camera.Location.Z())
-		obj.VertexMatrix.TransformRotate(-w.Camera.Direction.X(), -w.Camera.Direction.Y(), -w.Camera.Direction.Z())
+	calculatedWorld := NewCalculatedWorld(w)
+	w.EachObject(func(obj Object, modelMatrix Matrix4) {
+		stats.Objects++
+
+		result, obj := viewVolume.CullObject(obj, viewMatrix.Mul(modelMatrix))
+		switch result {
+		case CullOutside:
+			stats.Culled++
+			return
+		case CullInside:
+			stats.Inside++
+		case CullIntersecting:
+			stats.Clipped++
+		}
 
-		// ビューボリュームでクリッピング
-		obj = w.ClipWithViewVolume(obj)
+		// 面の向きによるカリング（クリッピングの前に行い、除外した面はクリッピングしない）
+		obj, culledFaces := obj.CullFaces()
+		stats.CulledFaces += culledFaces
+		if len(obj.Triangles) == 0 {
+			return
+		}
+
+		if result == CullIntersecting {
+			// ビューボリュームでクリッピング
+			obj = viewVolume.ClipObject(obj)
+			if len(obj.Triangles) == 0 {
+				return
+			}
+		}
 
 		// 透視投影
 		obj = w.TransformPerspectiveProjection(obj)
 
 		calculatedWorld.AddObject(obj)
+	})
+
+	return calculatedWorld.RayTrace(), stats
+}
+
+// EachObject はLocatedObjectsとシーングラフの全オブジェクトを、ワールド座標系への変換行列と共に列挙します
+// ClipPlanesを指定した場合は、ワールド座標系に変換して切断したオブジェクトを単位行列と共に渡します
+// Textureを持つオブジェクトにはオブジェクト座標系の頂点の位置（AttributeObjectPosition）を追加して渡します
+func (w World) EachObject(f func(obj Object, modelMatrix Matrix4)) {
+	if len(w.ClipPlanes) > 0 {
+		sectioned := f
+		f = func(obj Object, modelMatrix Matrix4) {
+			sectioned(w.SectionObject(obj, modelMatrix), NewIdentityMatrix4())
+		}
+	}
+	// テクスチャを持つオブジェクトは、変換前（オブジェクト座標系）の頂点の位置を属性として持たせる
+	textured := f
+	f = func(obj Object, modelMatrix Matrix4) {
+		textured(obj.withTextureAttributes(), modelMatrix)
 	}
+	for _, locatedObj := range w.LocatedObjects {
+		f(locatedObj.Object, locatedObj.ModelMatrix())
+	}
+	w.WalkScene(func(node SceneNode, worldMatrix Matrix4) {
+		if node.Object == nil {
+			return
+		}
+		f(*node.Object, worldMatrix)
+	})
+}
 
-	return calculatedWorld.RayTrace()
+// CameraSpaceObjects はLocatedObjectsとシーングラフの全オブジェクトをカメラ座標系に変換して返します
+// 頂点を持たないオブジェクトは除外します
+func (w World) CameraSpaceObjects() []Object {
+	objects := make([]Object, 0, len(w.LocatedObjects))
+	viewMatrix := w.Camera.ViewMatrix()
+	w.EachObject(func(obj Object, modelMatrix Matrix4) {
+		if ok, _ := obj.BoundingBox(); !ok {
+			return
+		}
+		obj.VertexMatrix.TransformMatrix4(viewMatrix.Mul(modelMatrix))
+		objects = append(objects, obj)
+	})
+	return objects
+}
+
+// TransformToCameraSpace はオブジェクトにワールド座標変換とカメラ座標変換を適用します
+// 2つの変換を1つの行列に合成してから頂点に適用します
+func (w World) TransformToCameraSpace(locatedObj LocatedObject) Object {
+	obj := locatedObj.Object
+	obj.VertexMatrix.TransformMatrix4(w.Camera.ViewMatrix().Mul(locatedObj.ModelMatrix()))
+	return obj
 }
 
 func (w World) ClipWithViewVolume(o Object) Object {
@@ -69,28 +162,17 @@ func (w World) ClipWithViewVolume(o Object) Object {
 // NDC（Normalized Device Coordinates・正規化デバイス座標）に変換する
 // x,y∈[−1,1], z∈[0,1] に変換する
 func (w World) TransformPerspectiveProjection(o Object) Object {
-	aspect := float64(w.Viewport.Width) / float64(w.Viewport.Height)
-	tan := math.Tan(w.Clipping.FieldOfView / 2.0)
-
-	zn := w.Clipping.NearDistance
-	zf := w.Clipping.FarDistance
-
-	// 左手座標系なので一般的な式
-	// https://www.notion.so/t-kuni/28fb12fb627480edb34ff2935f5392d5?v=28fb12fb6274802ead99000c2486b3bf&source=copy_link#297b12fb627480dca89bcb0709acc844
-	projectionMatrix := mat.NewDense(4, 4, []float64{
-		1 / (aspect * tan), 0, 0, 0, // X軸
-		0, 1 / tan, 0, 0, // Y軸
-		0, 0, zf / (zf - zn), -(zf * zn) / (zf - zn), // Z軸
-		0, 0, 1, 0, // 同次座標
-	})
+	projectionMatrix := w.ProjectionMatrix().Dense()
 
 	var projected mat.Dense
 	projected.Mul(projectionMatrix, &o.VertexMatrix)
 
 	// mは転置されて4行N列になっている
 	_, colCnt := projected.Dims()
+	ws := make([]float64, 0, colCnt)
 	for colIdx := 0; colIdx < colCnt; colIdx++ {
 		w := projected.At(3, colIdx)
+		ws = append(ws, w)
 		projected.Set(0, colIdx, projected.At(0, colIdx)/w)
 		projected.Set(1, colIdx, projected.At(1, colIdx)/w)
 		projected.Set(2, colIdx, projected.At(2, colIdx)/w)
@@ -99,7 +181,8 @@ func (w World) TransformPerspectiveProjection(o Object) Object {
 
 	o.VertexMatrix = VartexMatrix{Dense: &projected}
 
-	return o
+	// テクスチャを透視補正して補間するため、wの逆数を残す
+	return o.withInverseW(ws)
 }
 
 type ViewVolume struct {
@@ -140,14 +223,18 @@ func (w World) ViewVolume() ViewVolume {
 	farClippingHeightHalf := farClippingHeight / 2
 	farClippingWidthHalf := farClippingWidth / 2
 
-	nearTopRight := Vector3D{nearClippingWidthHalf, nearClippingHeightHalf, w.Clipping.NearDistance}
-	nearTopLeft := Vector3D{-nearClippingWidthHalf, nearClippingHeightHalf, w.Clipping.NearDistance}
-	nearBottomRight := Vector3D{nearClippingWidthHalf, -nearClippingHeightHalf, w.Clipping.NearDistance}
-	nearBottomLeft := Vector3D{-nearClippingWidthHalf, -nearClippingHeightHalf, w.Clipping.NearDistance}
-	farTopRight := Vector3D{farClippingWidthHalf, farClippingHeightHalf, w.Clipping.FarDistance}
-	farTopLeft := Vector3D{-farClippingWidthHalf, farClippingHeightHalf, w.Clipping.FarDistance}
-	farBottomRight := Vector3D{farClippingWidthHalf, -farClippingHeightHalf, w.Clipping.FarDistance}
-	farBottomLeft := Vector3D{-farClippingWidthHalf, -farClippingHeightHalf, w.Clipping.FarDistance}
+	// オフアクシス投影の場合は視錐台を水平方向にずらす（ずれは距離に比例する）
+	nearShift := w.Clipping.HorizontalShift
+	farShift := w.Clipping.HorizontalShift * w.Clipping.FarDistance / w.Clipping.NearDistance
+
+	nearTopRight := Vector3D{nearShift + nearClippingWidthHalf, nearClippingHeightHalf, w.Clipping.NearDistance}
+	nearTopLeft := Vector3D{nearShift - nearClippingWidthHalf, nearClippingHeightHalf, w.Clipping.NearDistance}
+	nearBottomRight := Vector3D{nearShift + nearClippingWidthHalf, -nearClippingHeightHalf, w.Clipping.NearDistance}
+	nearBottomLeft := Vector3D{nearShift - nearClippingWidthHalf, -nearClippingHeightHalf, w.Clipping.NearDistance}
+	farTopRight := Vector3D{farShift + farClippingWidthHalf, farClippingHeightHalf, w.Clipping.FarDistance}
+	farTopLeft := Vector3D{farShift - farClippingWidthHalf, farClippingHeightHalf, w.Clipping.FarDistance}
+	farBottomRight := Vector3D{farShift + farClippingWidthHalf, -farClippingHeightHalf, w.Clipping.FarDistance}
+	farBottomLeft := Vector3D{farShift - farClippingWidthHalf, -farClippingHeightHalf, w.Clipping.FarDistance}
 
 	nearPlaneNormal := CalcNormalFromPoints(nearTopRight, nearTopLeft, nearBottomLeft)
 	farPlaneNormal := CalcNormalFromPoints(farTopLeft, farTopRight, farBottomRight)
@@ -206,8 +293,12 @@ type FrameBufferValue struct {
 
 type FrameBuffer map[FrameBufferKey]FrameBufferValue
 
-// SaveAsImage はFrameBufferを画像ファイルとして保存します
-func (fb FrameBuffer) SaveAsImage(width int, height int, path string) error {
+// backgroundColor 何も描画されていない画素の色
+var backgroundColor = color.RGBA{255, 255, 255, 255}
+
+// Image はFrameBufferを画像に変換します
+// 何も描画されていない画素は背景色になります
+func (fb FrameBuffer) Image(width int, height int) *image.RGBA {
 	img := image.NewRGBA(image.Rect(0, 0, width, height))
 
 	for y := 0; y < height; y++ {
@@ -216,11 +307,18 @@ func (fb FrameBuffer) SaveAsImage(width int, height int, path string) error {
 			if value, ok := fb[key]; ok {
 				img.Set(x, y, value.Color)
 			} else {
-				img.Set(x, y, color.RGBA{255, 255, 255, 255})
+				img.Set(x, y, backgroundColor)
 			}
 		}
 	}
 
+	return img
+}
+
+// SaveAsImage はFrameBufferを画像ファイルとして保存します
+func (fb FrameBuffer) SaveAsImage(width int, height int, path string) error {
+	img := fb.Image(width, height)
+
 	file, err := os.Create(path)
 	if err != nil {
 		return err
@@ -231,14 +329,22 @@ func (fb FrameBuffer) SaveAsImage(width int, height int, path string) error {
 }
 
 func (w CalculatedWorld) RayTrace() FrameBuffer {
+	if w.Origin.Clipping.Projection != nil {
+		return w.RayTraceParallel()
+	}
+	if w.Origin.Camera.ApertureRadius > 0 {
+		return w.RayTraceThinLens()
+	}
+
 	width := w.Origin.Viewport.Width
 	height := w.Origin.Viewport.Height
 
 	viewVolume := w.Origin.ViewVolume()
-	minXframe := viewVolume.NearTopLeft.X()
-	maxXframe := viewVolume.NearTopRight.X()
-	minYframe := viewVolume.NearTopLeft.Y()
-	maxYframe := viewVolume.NearBottomLeft.Y()
+	// 投影後の空間は視錐台の中心が原点になるため、オフアクシスのずれ（HorizontalShift）は含めない
+	minXframe := -viewVolume.NearClippingWidth / 2
+	maxXframe := viewVolume.NearClippingWidth / 2
+	minYframe := viewVolume.NearClippingHeight / 2
+	maxYframe := -viewVolume.NearClippingHeight / 2
 
 	frameBuffer := make(FrameBuffer, width*height)
 	for xPixel := int32(0); xPixel < width; xPixel++ {
@@ -257,16 +363,14 @@ func (w CalculatedWorld) RayTrace() FrameBuffer {
 						continue
 					}
 					depth := intersection.Z()
+					if math.IsNaN(depth) || math.IsInf(depth, 0) {
+						// 数値誤差で交点が求まらない場合は描画しない
+						continue
+					}
 					key := FrameBufferKey{X: xPixel, Y: yPixel}
 					if v, ok := frameBuffer[key]; !ok || depth < v.Depth {
-						// 三角形の色を取得
-						var triangleColor color.RGBA
-						if triangleIndex < len(lObj.TriangleColors) {
-							triangleColor = lObj.TriangleColors[triangleIndex]
-						} else {
-							triangleColor = color.RGBA{0, 0, 0, 255} // デフォルト色
-						}
-						frameBuffer[key] = FrameBufferValue{Color: triangleColor, Depth: depth}
+						// 交点の色を取得（テクスチャがない場合は三角形の色）
+						frameBuffer[key] = FrameBufferValue{Color: lObj.SurfaceColor(triangleIndex, intersection), Depth: depth}
 					}
 				}
 			}
@@ -312,8 +416,10 @@ func (v ViewVolume) PlanePoint(clippingPlaneType ClippingPlaneType) Vector3D {
 	return Vector3D{}
 }
 
+// ClassifyEdgeByPlane は点がクリップ面の内側にあるかを判定します
+// クリップ面からの距離がClippingEpsilon以下の点（クリップ面上の点）は内側として扱います
 func (v ViewVolume) ClassifyEdgeByPlane(vertex Vector3D, clippingPlaneType ClippingPlaneType) bool {
-	return ClassifyEdgeByPlane(vertex, v.PlaneNormal(clippingPlaneType), v.PlanePoint(clippingPlaneType))
+	return ClassifyEdgeByPlaneWithEpsilon(vertex, v.PlaneNormal(clippingPlaneType), v.PlanePoint(clippingPlaneType), ClippingEpsilon)
 }
 
 type ClippingPlaneType int
@@ -332,11 +438,25 @@ func ClippingPlaneTypes() []ClippingPlaneType {
 }
 
 func (v ViewVolume) SutherlandHodgman(triangle [3]Vector3D) []Vector3D {
-	work1Vertices := make([]Vector3D, 0, 10)
-	work1Vertices = append(work1Vertices, triangle[0])
-	work1Vertices = append(work1Vertices, triangle[1])
-	work1Vertices = append(work1Vertices, triangle[2])
-	work2Vertices := make([]Vector3D, 0, 10)
+	polygon := v.ClipPolygon([]ClipVertex{
+		{Position: Vector4D{triangle[0][0], triangle[0][1], triangle[0][2], 1}},
+		{Position: Vector4D{triangle[1][0], triangle[1][1], triangle[1][2], 1}},
+		{Position: Vector4D{triangle[2][0], triangle[2][1], triangle[2][2], 1}},
+	})
+
+	vertices := make([]Vector3D, 0, len(polygon))
+	for _, vertex := range polygon {
+		vertices = append(vertices, vertex.Vector3D())
+	}
+	return vertices
+}
+
+// ClipPolygon はカメラ座標系の多角形をSutherland-Hodgmanアルゴリズムでクリッピングします
+// 頂点の位置はwを1とした同次座標で指定します
+// 交点の頂点属性は、位置と同じ比率（IntersectPlaneParameter）で線形補間します
+func (v ViewVolume) ClipPolygon(polygon []ClipVertex) []ClipVertex {
+	work1Vertices := polygon
+	work2Vertices := make([]ClipVertex, 0, 10)
 
 	for _, clippingPlaneType := range ClippingPlaneTypes() {
 		for i := 0; i < len(work1Vertices); i++ {
@@ -346,21 +466,21 @@ func (v ViewVolume) SutherlandHodgman(triangle [3]Vector3D) []Vector3D {
 			fromVertex := work1Vertices[fromIndex]
 			toVertex := work1Vertices[toIndex]
 
-			fromInside := v.ClassifyEdgeByPlane(fromVertex, clippingPlaneType)
-			toInside := v.ClassifyEdgeByPlane(toVertex, clippingPlaneType)
+			fromInside := v.ClassifyEdgeByPlane(fromVertex.Vector3D(), clippingPlaneType)
+			toInside := v.ClassifyEdgeByPlane(toVertex.Vector3D(), clippingPlaneType)
 
 			if fromInside && toInside {
 				// 内から内
 				work2Vertices = append(work2Vertices, toVertex)
 			} else if fromInside && !toInside {
 				// 内から外
-				intersectionPoint := v.IntersectPlaneIntersectionPoint(fromVertex, toVertex, clippingPlaneType)
-				work2Vertices = append(work2Vertices, intersectionPoint)
+				t := v.IntersectPlaneParameter(fromVertex.Vector3D(), toVertex.Vector3D(), clippingPlaneType)
+				work2Vertices = append(work2Vertices, fromVertex.Lerp(toVertex, t))
 			} else if !fromInside && toInside {
 				// 外から内
 				// 先に交点を追加する。その後、内側の頂点を追加する。（反時計周りの頂点の順番を維持するため）
-				intersectionPoint := v.IntersectPlaneIntersectionPoint(fromVertex, toVertex, clippingPlaneType)
-				work2Vertices = append(work2Vertices, intersectionPoint)
+				t := v.IntersectPlaneParameter(fromVertex.Vector3D(), toVertex.Vector3D(), clippingPlaneType)
+				work2Vertices = append(work2Vertices, fromVertex.Lerp(toVertex, t))
 				work2Vertices = append(work2Vertices, toVertex)
 			} else {
 				// 外から外
@@ -368,7 +488,7 @@ func (v ViewVolume) SutherlandHodgman(triangle [3]Vector3D) []Vector3D {
 			}
 		}
 		work1Vertices = work2Vertices
-		work2Vertices = make([]Vector3D, 0, 10)
+		work2Vertices = make([]ClipVertex, 0, 10)
 	}
 
 	return work1Vertices
@@ -376,15 +496,18 @@ func (v ViewVolume) SutherlandHodgman(triangle [3]Vector3D) []Vector3D {
 
 func (v ViewVolume) ClipObject(o Object) Object {
 	newObject := NewDynamicObject()
+	newObject.Attributes = attributeLayout(o.Attributes)
 
 	for i, triangle := range o.Triangles {
-		triangleVertices := [3]Vector3D{
-			o.VertexMatrix.GetVertex(triangle[0]),
-			o.VertexMatrix.GetVertex(triangle[1]),
-			o.VertexMatrix.GetVertex(triangle[2]),
+		polygon := make([]ClipVertex, 0, 3)
+		for _, index := range triangle {
+			vertex := o.VertexMatrix.GetVertex(index)
+			polygon = append(polygon, ClipVertex{
+				Position:   Vector4D{vertex[0], vertex[1], vertex[2], 1},
+				Attributes: o.VertexAttributeValues(index),
+			})
 		}
-		vertices := v.SutherlandHodgman(triangleVertices)
-		triangles := Triangulate(vertices)
+		polygon = v.ClipPolygon(polygon)
 
 		// 元の三角形の色を取得
 		var originalColor color.RGBA
@@ -394,19 +517,30 @@ func (v ViewVolume) ClipObject(o Object) Object {
 			originalColor = color.RGBA{0, 0, 0, 255} // デフォルト色
 		}
 
-		// 新しく生成された三角形すべてに元の色を設定
-		for _, triangle := range triangles {
-			newObject.AddTriangleWithColor(triangle, originalColor)
+		// 新しく生成された三角形すべてに元の色を設定（凸多角形なので扇状に分割する）
+		for j := 1; j < len(polygon)-1; j++ {
+			newObject.AddClipVerticesWithColor([3]ClipVertex{polygon[0], polygon[j], polygon[j+1]}, originalColor)
 		}
 	}
 
-	return v.MargeVertices(newObject.ToObject())
+	// 全ての三角形がビューボリュームの外側にある場合は頂点を持たない空のオブジェクトを返す
+	if len(newObject.Vertices) == 0 {
+		return Object{CullMode: o.CullMode, Texture: o.Texture}
+	}
+
+	// クリップ面上で潰れた三角形は取り除く
+	clipped, _ := v.MargeVertices(newObject.ToObject()).RemoveDegenerateTriangles()
+	clipped.CullMode = o.CullMode
+	clipped.Texture = o.Texture
+	return clipped
 }
 
 type VertexGrid struct {
 	grid     map[[3]int][]int
 	vertices []Vertex
-	epsilon  float64
+	// attributes 頂点ごとの頂点属性の値（AddVertexWithAttributesで追加した場合のみ）
+	attributes [][]float64
+	epsilon    float64
 }
 
 func NewVertexGrid(epsilon float64) VertexGrid {
@@ -421,11 +555,27 @@ func (vg VertexGrid) Vertices() []Vertex {
 	return vg.vertices
 }
 
+// AttributeValues は頂点ごとの頂点属性の値を頂点の添字番号の順に返します
+func (vg VertexGrid) AttributeValues() [][]float64 {
+	return vg.attributes
+}
+
 func (vg VertexGrid) makeKey(v Vector3D) [3]int {
 	return [3]int{int(math.Floor(v[0] / vg.epsilon)), int(math.Floor(v[1] / vg.epsilon)), int(math.Floor(v[2] / vg.epsilon))}
 }
 
 func (vg VertexGrid) SearchVertex(v Vector3D) (bool, int) {
+	return vg.searchVertex(v, func(int) bool { return true })
+}
+
+// searchVertexWithAttributes は位置に加えて頂点属性の値も一致する頂点を探します
+func (vg VertexGrid) searchVertexWithAttributes(v Vector3D, attributes []float64) (bool, int) {
+	return vg.searchVertex(v, func(candidateVertexIndex int) bool {
+		return equalAttributeValues(attributes, vg.attributes[candidateVertexIndex], vg.epsilon)
+	})
+}
+
+func (vg VertexGrid) searchVertex(v Vector3D, match func(candidateVertexIndex int) bool) (bool, int) {
 	baseGridKey := vg.makeKey(v)
 	for _, dx := range []int{0, 1, -1} {
 		for _, dy := range []int{0, 1, -1} {
@@ -434,7 +584,7 @@ func (vg VertexGrid) SearchVertex(v Vector3D) (bool, int) {
 				if candidateVertexIndexes, ok := vg.grid[gridKey]; ok {
 					for _, candidateVertexIndex := range candidateVertexIndexes {
 						candidateVertex := vg.vertices[candidateVertexIndex]
-						if v.DistanceTo(candidateVertex) < vg.epsilon {
+						if v.DistanceTo(candidateVertex) < vg.epsilon && match(candidateVertexIndex) {
 							return true, candidateVertexIndex
 						}
 					}
@@ -452,20 +602,40 @@ func (vg *VertexGrid) AddVertex(v Vector3D) int {
 	if existSameLocation {
 		return sameLocationVertexIndex
 	} else {
-		nextIndex := len(vg.vertices)
-		gridKey := vg.makeKey(v)
-		vg.grid[gridKey] = append(vg.grid[gridKey], nextIndex)
-		vg.vertices = append(vg.vertices, v)
-		return nextIndex
+		return vg.appendVertex(v, nil)
+	}
+}
+
+// AddVertexWithAttributes は頂点属性を持つ頂点を追加します。
+// 位置が同じでも頂点属性の値が異なる頂点（UVの継ぎ目など）は別の頂点として追加します。
+// 追加した頂点の新しい添字番号を返します。
+func (vg *VertexGrid) AddVertexWithAttributes(v Vector3D, attributes []float64) int {
+	existSameVertex, sameVertexIndex := vg.searchVertexWithAttributes(v, attributes)
+	if existSameVertex {
+		return sameVertexIndex
+	} else {
+		return vg.appendVertex(v, attributes)
 	}
 }
 
+func (vg *VertexGrid) appendVertex(v Vector3D, attributes []float64) int {
+	nextIndex := len(vg.vertices)
+	gridKey := vg.makeKey(v)
+	vg.grid[gridKey] = append(vg.grid[gridKey], nextIndex)
+	vg.vertices = append(vg.vertices, v)
+	vg.attributes = append(vg.attributes, attributes)
+	return nextIndex
+}
+
+// MargeVertices は同じ位置（と同じ頂点属性）の頂点を1つにまとめます
+// 許容誤差はオブジェクトの大きさに比例させる（MergeTolerance）ため、小さなオブジェクトも潰れません
+// 頂点をまとめた結果、退化した三角形や重複する三角形は色と共に破棄します
 func (v ViewVolume) MargeVertices(o Object) Object {
-	grid := NewVertexGrid(1e-2)
+	grid := NewVertexGrid(o.MergeTolerance())
 
 	vertexMap := make(map[int]int, 50)
 	o.VertexMatrix.EachVertex(func(i int, vertex Vertex) bool {
-		vertexMap[i] = grid.AddVertex(vertex)
+		vertexMap[i] = grid.AddVertexWithAttributes(vertex, o.VertexAttributeValues(i))
 		return true
 	})
 
@@ -478,12 +648,18 @@ func (v ViewVolume) MargeVertices(o Object) Object {
 	for _, triangle := range o.Triangles {
 		newTriangles = append(newTriangles, [3]int{vertexMap[triangle[0]], vertexMap[triangle[1]], vertexMap[triangle[2]]})
 	}
+	// 元の三角形の色を引き継ぐ（破棄した三角形の色も除く）
+	newTriangles, newTriangleColors := CleanTrianglesWithColors(newTriangles, o.TriangleColors)
 
 	dObj := DynamicObject{
 		Vertices:       grid.Vertices(),
 		Edges:          CleanEdges(newEdges),
-		Triangles:      CleanTriangles(newTriangles),
-		TriangleColors: o.TriangleColors, // 元の三角形の色をそのまま引き継ぐ
+		Triangles:      newTriangles,
+		TriangleColors: newTriangleColors,
+		Attributes:     attributeLayout(o.Attributes),
+	}
+	if len(dObj.Attributes) > 0 {
+		dObj.VertexAttributeValues = grid.AttributeValues()
 	}
 	return dObj.ToObject()
 }
@@ -494,17 +670,45 @@ func (v ViewVolume) IntersectPlaneIntersectionPoint(fromVertex, toVertex Vector3
 	return IntersectPlaneIntersectionPoint(planeNormal, planePoint, fromVertex, toVertex)
 }
 
+// IntersectPlaneParameter はクリップ面と線分の交点の位置を、始点を0・終点を1とした比率で返します
+func (v ViewVolume) IntersectPlaneParameter(fromVertex, toVertex Vector3D, clippingPlaneType ClippingPlaneType) float64 {
+	planeNormal := v.PlaneNormal(clippingPlaneType)
+	planePoint := v.PlanePoint(clippingPlaneType)
+	return IntersectPlaneParameter(planeNormal, planePoint, fromVertex, toVertex)
+}
+
 type Camera struct {
 	Location  Vector3D
 	Direction Vector3D
 	// Up        *mat.Dense
+
+	// ApertureRadius レンズの半径（被写界深度）
+	// レイトレースは透視投影後の空間で行うため、単位は投影後の座標（NDC）です
+	// 0の場合はピンホールカメラとして扱う
+	ApertureRadius float64
+	// FocalDistance ピントが合う距離（カメラ座標系のZ）
+	// NearDistanceより大きい値を指定する（NearDistance以下の場合はピンホールカメラとして扱う）
+	FocalDistance float64
+	// LensSamples 1画素あたりのレイの本数。ApertureRadiusが0より大きい場合のみ使用する
+	LensSamples int
+
+	// Animation 時間によって変化するカメラの設定。World.Atで評価する
+	Animation *CameraAnimation
 }
 
 type LocatedObject struct {
 	Location Vector3D
 	Scale    Vector3D
+	// Rotation X軸、Y軸、Z軸それぞれの回転角度（ラジアン）
 	Rotation Vector3D
-	Object   Object
+	// RotationOrder Rotationを適用する順番（既定値はZ→Y→X軸の順）
+	RotationOrder RotationOrder
+	// Quaternion 四元数による回転。指定した場合はRotationより優先する
+	// 軸と角度で指定する場合はAxisAngle.Quaternionで変換する
+	Quaternion *Quaternion
+	Object     Object
+	// Animation 時間によって変化するLocation/Rotation/Scale。World.Atで評価する
+	Animation *ObjectAnimation
 }
 
 type Object struct {
@@ -517,6 +721,12 @@ type Object struct {
 	// 右ねじの法則に従って法線の方向が決まります。
 	Triangles      [][3]int
 	TriangleColors []color.RGBA
+	// CullMode 面の向きによるカリングの方法（既定値は裏面を描画しない）
+	CullMode CullMode
+	// Attributes 頂点ごとの属性（色・法線・UVなど）。クリッピングで作られる頂点では線形補間される
+	Attributes []VertexAttribute
+	// Texture 表面の色を決めるテクスチャ。指定した場合はTriangleColorsの代わりに使う
+	Texture Texture
 }
 
 func NewPlaneObject(width, height float64, c color.RGBA) Object {
@@ -577,6 +787,10 @@ type DynamicObject struct {
 	Edges          [][2]int
 	Triangles      [][3]int
 	TriangleColors []color.RGBA
+	// Attributes 頂点属性の名前と要素数（値はVertexAttributeValuesに保持する）
+	Attributes []VertexAttribute
+	// VertexAttributeValues 頂点ごとに全ての頂点属性の値を連結したもの
+	VertexAttributeValues [][]float64
 }
 
 func NewDynamicObject() DynamicObject {
@@ -600,12 +814,22 @@ func (o *DynamicObject) AddTriangleWithColor(triangle [3]Vector3D, triangleColor
 	o.TriangleColors = append(o.TriangleColors, triangleColor)
 }
 
+// AddClipVerticesWithColor は頂点属性を持つ三角形を追加します
+// 頂点の位置はwを1とした同次座標です
+func (o *DynamicObject) AddClipVerticesWithColor(triangle [3]ClipVertex, triangleColor color.RGBA) {
+	o.AddTriangleWithColor([3]Vector3D{triangle[0].Vector3D(), triangle[1].Vector3D(), triangle[2].Vector3D()}, triangleColor)
+	if len(o.Attributes) > 0 {
+		o.VertexAttributeValues = append(o.VertexAttributeValues, triangle[0].Attributes, triangle[1].Attributes, triangle[2].Attributes)
+	}
+}
+
 func (o *DynamicObject) ToObject() Object {
 	return Object{
 		VertexMatrix:   NewVertexMatrix(o.Vertices),
 		Edges:          o.Edges,
 		Triangles:      o.Triangles,
 		TriangleColors: o.TriangleColors,
+		Attributes:     newVertexAttributes(o.Attributes, o.VertexAttributeValues),
 	}
 }
 
@@ -810,6 +1034,10 @@ func (v Vector3D) Distance() float64 {
 
 func (v Vector3D) Normalize() Vector3D {
 	distance := v.Distance()
+	if distance == 0 {
+		// 長さが0のベクトルは向きを持たないため、零ベクトルのまま返す
+		return Vector3D{}
+	}
 	return Vector3D{v[0] / distance, v[1] / distance, v[2] / distance}
 }
 
@@ -8,6 +8,25 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// newTestWorld は画面の大きさと配置するオブジェクトを指定して、テスト用のワールドを作ります
+// 視錐台は前方クリップ面が0.1、後方クリップ面が10、画角がπ/4です
+func newTestWorld(width, height int32, objects ...LocatedObject) World {
+	return World{
+		LocatedObjects: objects,
+		Viewport:       Viewport{Width: width, Height: height},
+		Clipping:       Clipping{NearDistance: 0.1, FarDistance: 10.0, FieldOfView: math.Pi / 4},
+	}
+}
+
+// newTestPlane はカメラの正面のZ=zの位置に、一辺がsizeの正方形の平面を置きます
+func newTestPlane(z, size float64, c color.RGBA) LocatedObject {
+	return LocatedObject{
+		Location: Vector3D{0, 0, z},
+		Scale:    Vector3D{1, 1, 1},
+		Object:   NewPlaneObject(size, size, c),
+	}
+}
+
 // func TestWorld_Transform_オブジェクトが原点に配置されている(t *testing.T) {
 // 	world := World{
 // 		Camera: Camera{
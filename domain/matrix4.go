@@ -0,0 +1,224 @@
+package domain
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Matrix4 は4x4の変換行列を表します（[行][列]）
+// mat.Denseと違いヒープを確保しないため、変換の合成に使用します
+type Matrix4 [4][4]float64
+
+// NewIdentityMatrix4 は単位行列を返します
+func NewIdentityMatrix4() Matrix4 {
+	return Matrix4{
+		{1, 0, 0, 0},
+		{0, 1, 0, 0},
+		{0, 0, 1, 0},
+		{0, 0, 0, 1},
+	}
+}
+
+// NewTranslateMatrix4 は平行移動行列を返します
+func NewTranslateMatrix4(x, y, z float64) Matrix4 {
+	return Matrix4{
+		{1, 0, 0, x},
+		{0, 1, 0, y},
+		{0, 0, 1, z},
+		{0, 0, 0, 1},
+	}
+}
+
+// NewScaleMatrix4 は拡大・縮小行列を返します
+func NewScaleMatrix4(scaleX, scaleY, scaleZ float64) Matrix4 {
+	return Matrix4{
+		{scaleX, 0, 0, 0},
+		{0, scaleY, 0, 0},
+		{0, 0, scaleZ, 0},
+		{0, 0, 0, 1},
+	}
+}
+
+// NewPerspectiveMatrix4 は透視投影行列を返します（左手座標系）
+// fieldOfViewは垂直方向の視野角、aspectは幅/高さです
+// クリップ空間は -w ≤ x,y ≤ w, 0 ≤ z ≤ w で、wにはカメラ座標系のzが入ります
+func NewPerspectiveMatrix4(fieldOfView, aspect, near, far float64) Matrix4 {
+	tan := math.Tan(fieldOfView / 2.0)
+	return Matrix4{
+		{1 / (aspect * tan), 0, 0, 0},
+		{0, 1 / tan, 0, 0},
+		{0, 0, far / (far - near), -(far * near) / (far - near)},
+		{0, 0, 1, 0},
+	}
+}
+
+// NewOrthographicMatrix4 は平行投影行列を返します（左手座標系）
+// width, heightはカメラ座標系で画面に映る範囲の幅と高さです
+// クリップ空間は -1 ≤ x,y ≤ 1, 0 ≤ z ≤ 1 で、wは常に1になります
+func NewOrthographicMatrix4(width, height, near, far float64) Matrix4 {
+	return Matrix4{
+		{2 / width, 0, 0, 0},
+		{0, 2 / height, 0, 0},
+		{0, 0, 1 / (far - near), -near / (far - near)},
+		{0, 0, 0, 1},
+	}
+}
+
+// NewRotateMatrix4 は3つの軸（X、Y、Z）での回転行列を返します
+// TransformRotateと同じく Z軸 -> Y軸 -> X軸 の順で回転します
+func NewRotateMatrix4(x, y, z float64) Matrix4 {
+	mx := Matrix4{
+		{1, 0, 0, 0},
+		{0, math.Cos(x), -math.Sin(x), 0},
+		{0, math.Sin(x), math.Cos(x), 0},
+		{0, 0, 0, 1},
+	}
+	my := Matrix4{
+		{math.Cos(y), 0, math.Sin(y), 0},
+		{0, 1, 0, 0},
+		{-math.Sin(y), 0, math.Cos(y), 0},
+		{0, 0, 0, 1},
+	}
+	mz := Matrix4{
+		{math.Cos(z), -math.Sin(z), 0, 0},
+		{math.Sin(z), math.Cos(z), 0, 0},
+		{0, 0, 1, 0},
+		{0, 0, 0, 1},
+	}
+	return mx.Mul(my).Mul(mz)
+}
+
+// FromTRS は平行移動・回転・拡大縮小から変換行列を作成します
+// 拡大・縮小 → 回転（Z→Y→X軸の順） → 平行移動 の順に適用されます
+func FromTRS(location, rotation, scale Vector3D) Matrix4 {
+	return FromTRSMatrix(location, NewRotateMatrix4(rotation.X(), rotation.Y(), rotation.Z()), scale)
+}
+
+// FromTRSMatrix は平行移動・回転行列・拡大縮小から変換行列を作成します
+// 拡大・縮小 → 回転 → 平行移動 の順に適用されます
+func FromTRSMatrix(location Vector3D, rotation Matrix4, scale Vector3D) Matrix4 {
+	return NewTranslateMatrix4(location.X(), location.Y(), location.Z()).
+		Mul(rotation).
+		Mul(NewScaleMatrix4(scale.X(), scale.Y(), scale.Z()))
+}
+
+// Mul は行列の積 m × n を返します
+// 頂点に適用すると n を適用した後に m を適用したことになります
+func (m Matrix4) Mul(n Matrix4) Matrix4 {
+	var result Matrix4
+	for row := 0; row < 4; row++ {
+		for col := 0; col < 4; col++ {
+			result[row][col] = m[row][0]*n[0][col] + m[row][1]*n[1][col] + m[row][2]*n[2][col] + m[row][3]*n[3][col]
+		}
+	}
+	return result
+}
+
+// Transpose は転置行列を返します
+func (m Matrix4) Transpose() Matrix4 {
+	var result Matrix4
+	for row := 0; row < 4; row++ {
+		for col := 0; col < 4; col++ {
+			result[row][col] = m[col][row]
+		}
+	}
+	return result
+}
+
+// Inverse は逆行列を返します
+// 逆行列が存在しない場合はfalseを返します
+func (m Matrix4) Inverse() (bool, Matrix4) {
+	// 掃き出し法（部分ピボット選択）で逆行列を求める
+	work := m
+	result := NewIdentityMatrix4()
+	for col := 0; col < 4; col++ {
+		pivot := col
+		for row := col + 1; row < 4; row++ {
+			if math.Abs(work[row][col]) > math.Abs(work[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(work[pivot][col]) < 1e-12 {
+			return false, Matrix4{}
+		}
+		work[col], work[pivot] = work[pivot], work[col]
+		result[col], result[pivot] = result[pivot], result[col]
+
+		scale := 1 / work[col][col]
+		for i := 0; i < 4; i++ {
+			work[col][i] *= scale
+			result[col][i] *= scale
+		}
+
+		for row := 0; row < 4; row++ {
+			if row == col {
+				continue
+			}
+			factor := work[row][col]
+			for i := 0; i < 4; i++ {
+				work[row][i] -= factor * work[col][i]
+				result[row][i] -= factor * result[col][i]
+			}
+		}
+	}
+	return true, result
+}
+
+// MulPoint は点（同次座標のw=1）に変換を適用します
+func (m Matrix4) MulPoint(v Vector3D) Vector3D {
+	return Vector3D{
+		m[0][0]*v[0] + m[0][1]*v[1] + m[0][2]*v[2] + m[0][3],
+		m[1][0]*v[0] + m[1][1]*v[1] + m[1][2]*v[2] + m[1][3],
+		m[2][0]*v[0] + m[2][1]*v[1] + m[2][2]*v[2] + m[2][3],
+	}
+}
+
+// MulVector4 は同次座標のベクトルに変換を適用します
+func (m Matrix4) MulVector4(v Vector4D) Vector4D {
+	var result Vector4D
+	for row := 0; row < 4; row++ {
+		result[row] = m[row][0]*v[0] + m[row][1]*v[1] + m[row][2]*v[2] + m[row][3]*v[3]
+	}
+	return result
+}
+
+// Dense はmat.Denseに変換します
+func (m Matrix4) Dense() *mat.Dense {
+	return mat.NewDense(4, 4, []float64{
+		m[0][0], m[0][1], m[0][2], m[0][3],
+		m[1][0], m[1][1], m[1][2], m[1][3],
+		m[2][0], m[2][1], m[2][2], m[2][3],
+		m[3][0], m[3][1], m[3][2], m[3][3],
+	})
+}
+
+// TransformMatrix4 は変換行列を全頂点に1回だけ適用します
+// 複数の変換を合成した行列を渡すことで、変換ごとに行列の積を計算する必要がなくなります
+func (v *VartexMatrix) TransformMatrix4(m Matrix4) {
+	raw := v.RawMatrix()
+	colCnt := raw.Cols
+	data := make([]float64, 4*colCnt)
+	for i := 0; i < colCnt; i++ {
+		x := raw.Data[i]
+		y := raw.Data[raw.Stride+i]
+		z := raw.Data[2*raw.Stride+i]
+		w := raw.Data[3*raw.Stride+i]
+		for row := 0; row < 4; row++ {
+			data[row*colCnt+i] = m[row][0]*x + m[row][1]*y + m[row][2]*z + m[row][3]*w
+		}
+	}
+	v.Dense = mat.NewDense(4, colCnt, data)
+}
+
+// ModelMatrix はオブジェクトのワールド座標変換行列を返します
+func (l LocatedObject) ModelMatrix() Matrix4 {
+	return FromTRSMatrix(l.Location, l.RotationMatrix(), l.Scale)
+}
+
+// ViewMatrix はワールド座標系からカメラ座標系への変換行列を返します
+// カメラの位置の逆方向に平行移動した後、カメラの向きの逆方向に回転します
+func (c Camera) ViewMatrix() Matrix4 {
+	return NewRotateMatrix4(-c.Direction.X(), -c.Direction.Y(), -c.Direction.Z()).
+		Mul(NewTranslateMatrix4(-c.Location.X(), -c.Location.Y(), -c.Location.Z()))
+}
@@ -0,0 +1,185 @@
+package domain
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func assertMatrix4InDelta(t *testing.T, expected, actual Matrix4, delta float64) {
+	t.Helper()
+	for row := 0; row < 4; row++ {
+		for col := 0; col < 4; col++ {
+			assert.InDelta(t, expected[row][col], actual[row][col], delta, "row=%d col=%d", row, col)
+		}
+	}
+}
+
+func TestMatrix4_Mul(t *testing.T) {
+	m := NewTranslateMatrix4(1, 2, 3)
+
+	assert.Equal(t, m, m.Mul(NewIdentityMatrix4()))
+	assert.Equal(t, m, NewIdentityMatrix4().Mul(m))
+
+	// 平行移動の合成は移動量の和になる
+	assertMatrix4InDelta(t, NewTranslateMatrix4(5, 7, 9), m.Mul(NewTranslateMatrix4(4, 5, 6)), 1e-12)
+}
+
+func TestMatrix4_Transpose(t *testing.T) {
+	m := Matrix4{
+		{1, 2, 3, 4},
+		{5, 6, 7, 8},
+		{9, 10, 11, 12},
+		{13, 14, 15, 16},
+	}
+
+	result := m.Transpose()
+
+	assert.Equal(t, 5.0, result[0][1])
+	assert.Equal(t, 2.0, result[1][0])
+	assert.Equal(t, 16.0, result[3][3])
+	assert.Equal(t, m, result.Transpose())
+}
+
+func TestMatrix4_Inverse(t *testing.T) {
+	m := FromTRS(Vector3D{1, -2, 3}, Vector3D{0.3, -0.7, 1.1}, Vector3D{2, 0.5, 3})
+
+	ok, inverse := m.Inverse()
+
+	assert.True(t, ok)
+	assertMatrix4InDelta(t, NewIdentityMatrix4(), m.Mul(inverse), 1e-9)
+	assertMatrix4InDelta(t, NewIdentityMatrix4(), inverse.Mul(m), 1e-9)
+}
+
+func TestMatrix4_Inverse_逆行列が存在しない場合(t *testing.T) {
+	ok, _ := NewScaleMatrix4(1, 0, 1).Inverse()
+
+	assert.False(t, ok)
+}
+
+func TestFromTRS_逐次変換と同じ結果になること(t *testing.T) {
+	location := Vector3D{1, -2, 3}
+	rotation := Vector3D{0.3, -0.7, 1.1}
+	scale := Vector3D{2, 0.5, 3}
+	vertices := []Vector3D{{1, 2, 3}, {-4, 5, -6}}
+
+	expected := NewVertexMatrix(vertices)
+	expected.TransformScale(scale.X(), scale.Y(), scale.Z())
+	expected.TransformRotate(rotation.X(), rotation.Y(), rotation.Z())
+	expected.TransformTranslate(location.X(), location.Y(), location.Z())
+
+	actual := NewVertexMatrix(vertices)
+	actual.TransformMatrix4(FromTRS(location, rotation, scale))
+
+	for i := range vertices {
+		for axis := 0; axis < 3; axis++ {
+			assert.InDelta(t, expected.GetVertex(i)[axis], actual.GetVertex(i)[axis], 1e-9)
+		}
+	}
+}
+
+func TestMatrix4_MulPoint(t *testing.T) {
+	m := NewTranslateMatrix4(1, 2, 3).Mul(NewScaleMatrix4(2, 2, 2))
+
+	result := m.MulPoint(Vector3D{1, 1, 1})
+
+	assert.Equal(t, Vector3D{3, 4, 5}, result)
+}
+
+func TestMatrix4_Dense(t *testing.T) {
+	m := NewTranslateMatrix4(1, 2, 3)
+
+	dense := m.Dense()
+
+	assert.Equal(t, 1.0, dense.At(0, 3))
+	assert.Equal(t, 2.0, dense.At(1, 3))
+	assert.Equal(t, 3.0, dense.At(2, 3))
+	assert.Equal(t, 1.0, dense.At(3, 3))
+}
+
+func TestCamera_ViewMatrix_逐次変換と同じ結果になること(t *testing.T) {
+	camera := Camera{
+		Location:  Vector3D{0.5, -1, 2},
+		Direction: Vector3D{0.2, -0.4, 0.6},
+	}
+	vertices := []Vector3D{{1, 2, 3}, {-4, 5, -6}}
+
+	expected := NewVertexMatrix(vertices)
+	expected.TransformTranslate(-camera.Location.X(), -camera.Location.Y(), -camera.Location.Z())
+	expected.TransformRotate(-camera.Direction.X(), -camera.Direction.Y(), -camera.Direction.Z())
+
+	actual := NewVertexMatrix(vertices)
+	actual.TransformMatrix4(camera.ViewMatrix())
+
+	for i := range vertices {
+		for axis := 0; axis < 3; axis++ {
+			assert.InDelta(t, expected.GetVertex(i)[axis], actual.GetVertex(i)[axis], 1e-9)
+		}
+	}
+}
+
+func newBenchmarkWorld() World {
+	return World{
+		Camera: Camera{
+			Location:  Vector3D{0, 0, 0},
+			Direction: Vector3D{0.1, 0.2, 0},
+		},
+		LocatedObjects: []LocatedObject{
+			{
+				Location: Vector3D{0, 0, 2},
+				Scale:    Vector3D{1.0, 1.0, 1.0},
+				Rotation: Vector3D{0.3, 0.4, 0.0},
+				Object:   NewTetrahedronObject(0.15),
+			},
+			{
+				Location: Vector3D{0.0, 0.0, 2.5},
+				Scale:    Vector3D{1.0, 1.0, 1.0},
+				Rotation: Vector3D{0.0, 0.0, 0.0},
+				Object:   NewPlaneObject(0.3, 0.3, color.RGBA{50, 50, 50, 255}),
+			},
+		},
+		Viewport: Viewport{
+			Width:  80,
+			Height: 60,
+		},
+		Clipping: Clipping{
+			NearDistance: 0.1,
+			FarDistance:  10.0,
+			FieldOfView:  math.Pi / 4,
+		},
+	}
+}
+
+// BenchmarkWorld_TransformToCameraSpace_逐次変換 は変換ごとに行列の積を計算する従来の方法です
+func BenchmarkWorld_TransformToCameraSpace_逐次変換(b *testing.B) {
+	world := newBenchmarkWorld()
+	locatedObj := world.LocatedObjects[0]
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		obj := locatedObj.Object
+		obj.VertexMatrix.TransformScale(locatedObj.Scale.X(), locatedObj.Scale.Y(), locatedObj.Scale.Z())
+		obj.VertexMatrix.TransformRotate(locatedObj.Rotation.X(), locatedObj.Rotation.Y(), locatedObj.Rotation.Z())
+		obj.VertexMatrix.TransformTranslate(locatedObj.Location.X(), locatedObj.Location.Y(), locatedObj.Location.Z())
+		obj.VertexMatrix.TransformTranslate(-world.Camera.Location.X(), -world.Camera.Location.Y(), -world.Camera.Location.Z())
+		obj.VertexMatrix.TransformRotate(-world.Camera.Direction.X(), -world.Camera.Direction.Y(), -world.Camera.Direction.Z())
+	}
+}
+
+func BenchmarkWorld_TransformToCameraSpace(b *testing.B) {
+	world := newBenchmarkWorld()
+	locatedObj := world.LocatedObjects[0]
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		world.TransformToCameraSpace(locatedObj)
+	}
+}
+
+func BenchmarkWorld_Transform(b *testing.B) {
+	world := newBenchmarkWorld()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		world.Transform()
+	}
+}
@@ -0,0 +1,350 @@
+package domain
+
+import (
+	"math"
+	"math/rand"
+)
+
+// NoiseKind はグラディエントノイズの種類です
+type NoiseKind int
+
+const (
+	// NoisePerlin パーリンノイズ（Improved Perlin Noise, 2002）
+	NoisePerlin NoiseKind = iota
+	// NoiseSimplex シンプレックスノイズ。パーリンノイズより軸方向の模様が目立たない
+	NoiseSimplex
+)
+
+// Noise は乱数の種から作る順列表を使って、手続き的なノイズを計算します
+// 同じ種からは常に同じ模様になります。ゼロ値は種0のノイズとして使えます
+type Noise struct {
+	permutation []int
+}
+
+// defaultNoise ゼロ値のNoiseが使う順列表
+var defaultNoise = NewNoise(0)
+
+// NewNoise は乱数の種seedからノイズを作ります
+func NewNoise(seed int64) Noise {
+	perm := rand.New(rand.NewSource(seed)).Perm(256)
+	// 添字の折り返しを省くため2周分並べる
+	return Noise{permutation: append(perm, perm...)}
+}
+
+func (n Noise) table() []int {
+	if n.permutation == nil {
+		return defaultNoise.permutation
+	}
+	return n.permutation
+}
+
+// hash は格子点の座標から0から255の値を返します
+func (n Noise) hash(x, y, z int) int {
+	p := n.table()
+	return p[p[p[x&255]+y&255]+z&255]
+}
+
+// fade はパーリンノイズの補間曲線 6t^5 - 15t^4 + 10t^3 です
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+// perlinGradient は格子点のハッシュ値で選んだ勾配ベクトルと(x, y, z)の内積を返します
+// 立方体の中心から12本の辺の中点へのベクトル（と重複する4本）から選びます
+func perlinGradient(hash int, x, y, z float64) float64 {
+	h := hash & 15
+	u := x
+	if h >= 8 {
+		u = y
+	}
+	v := z
+	if h < 4 {
+		v = y
+	} else if h == 12 || h == 14 {
+		v = x
+	}
+	if h&1 != 0 {
+		u = -u
+	}
+	if h&2 != 0 {
+		v = -v
+	}
+	return u + v
+}
+
+// Perlin3D は3次元のパーリンノイズを返します（おおよそ-1から1）
+// 整数の格子点では0になります
+func (n Noise) Perlin3D(x, y, z float64) float64 {
+	fx, fy, fz := math.Floor(x), math.Floor(y), math.Floor(z)
+	ix, iy, iz := int(fx), int(fy), int(fz)
+	x, y, z = x-fx, y-fy, z-fz
+	u, v, w := fade(x), fade(y), fade(z)
+
+	corner := func(dx, dy, dz int) float64 {
+		return perlinGradient(n.hash(ix+dx, iy+dy, iz+dz), x-float64(dx), y-float64(dy), z-float64(dz))
+	}
+	return LerpFloat(
+		LerpFloat(
+			LerpFloat(corner(0, 0, 0), corner(1, 0, 0), u),
+			LerpFloat(corner(0, 1, 0), corner(1, 1, 0), u),
+			v),
+		LerpFloat(
+			LerpFloat(corner(0, 0, 1), corner(1, 0, 1), u),
+			LerpFloat(corner(0, 1, 1), corner(1, 1, 1), u),
+			v),
+		w)
+}
+
+// Perlin2D は2次元のパーリンノイズを返します（おおよそ-1から1）
+func (n Noise) Perlin2D(x, y float64) float64 {
+	fx, fy := math.Floor(x), math.Floor(y)
+	ix, iy := int(fx), int(fy)
+	x, y = x-fx, y-fy
+	u, v := fade(x), fade(y)
+
+	corner := func(dx, dy int) float64 {
+		// 8方向の勾配から選ぶ
+		switch n.hash(ix+dx, iy+dy, 0) & 7 {
+		case 0:
+			return x - float64(dx) + y - float64(dy)
+		case 1:
+			return -(x - float64(dx)) + y - float64(dy)
+		case 2:
+			return x - float64(dx) - (y - float64(dy))
+		case 3:
+			return -(x - float64(dx)) - (y - float64(dy))
+		case 4:
+			return math.Sqrt2 * (x - float64(dx))
+		case 5:
+			return -math.Sqrt2 * (x - float64(dx))
+		case 6:
+			return math.Sqrt2 * (y - float64(dy))
+		default:
+			return -math.Sqrt2 * (y - float64(dy))
+		}
+	}
+	return LerpFloat(
+		LerpFloat(corner(0, 0), corner(1, 0), u),
+		LerpFloat(corner(0, 1), corner(1, 1), u),
+		v)
+}
+
+// simplexGradients3 は3次元シンプレックスノイズの勾配ベクトルです
+var simplexGradients3 = [12]Vector3D{
+	{1, 1, 0}, {-1, 1, 0}, {1, -1, 0}, {-1, -1, 0},
+	{1, 0, 1}, {-1, 0, 1}, {1, 0, -1}, {-1, 0, -1},
+	{0, 1, 1}, {0, -1, 1}, {0, 1, -1}, {0, -1, -1},
+}
+
+// Simplex2D は2次元のシンプレックスノイズを返します（おおよそ-1から1）
+// （S. Gustavson "Simplex noise demystified", 2005）
+func (n Noise) Simplex2D(x, y float64) float64 {
+	f2 := 0.5 * (math.Sqrt(3) - 1)
+	g2 := (3 - math.Sqrt(3)) / 6
+
+	// 正三角形の格子に歪めて、点を含む三角形を求める
+	s := (x + y) * f2
+	i, j := math.Floor(x+s), math.Floor(y+s)
+	t := (i + j) * g2
+	x0, y0 := x-(i-t), y-(j-t)
+
+	i1, j1 := 0, 1
+	if x0 > y0 {
+		i1, j1 = 1, 0
+	}
+	x1, y1 := x0-float64(i1)+g2, y0-float64(j1)+g2
+	x2, y2 := x0-1+2*g2, y0-1+2*g2
+
+	ii, jj := int(i), int(j)
+	contribution := func(hash int, x, y float64) float64 {
+		t := 0.5 - x*x - y*y
+		if t < 0 {
+			return 0
+		}
+		g := simplexGradients3[hash%12]
+		t *= t
+		return t * t * (g[0]*x + g[1]*y)
+	}
+	return 70 * (contribution(n.hash(ii, jj, 0), x0, y0) +
+		contribution(n.hash(ii+i1, jj+j1, 0), x1, y1) +
+		contribution(n.hash(ii+1, jj+1, 0), x2, y2))
+}
+
+// Simplex3D は3次元のシンプレックスノイズを返します（おおよそ-1から1）
+func (n Noise) Simplex3D(x, y, z float64) float64 {
+	const f3, g3 = 1.0 / 3, 1.0 / 6
+
+	// 四面体の格子に歪めて、点を含む四面体を求める
+	s := (x + y + z) * f3
+	i, j, k := math.Floor(x+s), math.Floor(y+s), math.Floor(z+s)
+	t := (i + j + k) * g3
+	x0, y0, z0 := x-(i-t), y-(j-t), z-(k-t)
+
+	var i1, j1, k1, i2, j2, k2 int
+	switch {
+	case x0 >= y0 && y0 >= z0:
+		i1, j1, k1, i2, j2, k2 = 1, 0, 0, 1, 1, 0
+	case x0 >= y0 && x0 >= z0:
+		i1, j1, k1, i2, j2, k2 = 1, 0, 0, 1, 0, 1
+	case x0 >= y0:
+		i1, j1, k1, i2, j2, k2 = 0, 0, 1, 1, 0, 1
+	case y0 < z0:
+		i1, j1, k1, i2, j2, k2 = 0, 0, 1, 0, 1, 1
+	case x0 < z0:
+		i1, j1, k1, i2, j2, k2 = 0, 1, 0, 0, 1, 1
+	default:
+		i1, j1, k1, i2, j2, k2 = 0, 1, 0, 1, 1, 0
+	}
+	offsets := [4]Vector3D{
+		{x0, y0, z0},
+		{x0 - float64(i1) + g3, y0 - float64(j1) + g3, z0 - float64(k1) + g3},
+		{x0 - float64(i2) + 2*g3, y0 - float64(j2) + 2*g3, z0 - float64(k2) + 2*g3},
+		{x0 - 1 + 3*g3, y0 - 1 + 3*g3, z0 - 1 + 3*g3},
+	}
+	ii, jj, kk := int(i), int(j), int(k)
+	hashes := [4]int{
+		n.hash(ii, jj, kk),
+		n.hash(ii+i1, jj+j1, kk+k1),
+		n.hash(ii+i2, jj+j2, kk+k2),
+		n.hash(ii+1, jj+1, kk+1),
+	}
+
+	total := 0.0
+	for c, offset := range offsets {
+		t := 0.6 - offset.Dot(offset)
+		if t < 0 {
+			continue
+		}
+		t *= t
+		total += t * t * simplexGradients3[hashes[c]%12].Dot(offset)
+	}
+	return 32 * total
+}
+
+// Worley3D は3次元のセルラーノイズ（Worleyノイズ）を返します
+// 単位立方体の格子ごとに1つずつ置いた特徴点のうち、最も近い点までの距離（F1）です（0以上、おおよそ1以下）
+func (n Noise) Worley3D(x, y, z float64) float64 {
+	fx, fy, fz := math.Floor(x), math.Floor(y), math.Floor(z)
+	ix, iy, iz := int(fx), int(fy), int(fz)
+
+	nearest := math.Inf(1)
+	for dz := -1; dz <= 1; dz++ {
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				feature := n.featurePoint(ix+dx, iy+dy, iz+dz)
+				d := Vector3D{fx + float64(dx) + feature[0] - x, fy + float64(dy) + feature[1] - y, fz + float64(dz) + feature[2] - z}
+				nearest = math.Min(nearest, d.Dot(d))
+			}
+		}
+	}
+	return math.Sqrt(nearest)
+}
+
+// Worley2D は2次元のセルラーノイズ（Worleyノイズ）を返します
+func (n Noise) Worley2D(x, y float64) float64 {
+	fx, fy := math.Floor(x), math.Floor(y)
+	ix, iy := int(fx), int(fy)
+
+	nearest := math.Inf(1)
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			feature := n.featurePoint(ix+dx, iy+dy, 0)
+			d := Vector2D{fx + float64(dx) + feature[0] - x, fy + float64(dy) + feature[1] - y}
+			nearest = math.Min(nearest, d[0]*d[0]+d[1]*d[1])
+		}
+	}
+	return math.Sqrt(nearest)
+}
+
+// featurePoint は格子(x, y, z)の中の特徴点の位置（各要素0から1）を返します
+func (n Noise) featurePoint(x, y, z int) Vector3D {
+	p := n.table()
+	h := n.hash(x, y, z)
+	return Vector3D{
+		float64(p[h]) / 256,
+		float64(p[h+1]) / 256,
+		float64(p[h+2]) / 256,
+	}
+}
+
+// Sample2D は種類kindの2次元のノイズを返します
+func (n Noise) Sample2D(kind NoiseKind, x, y float64) float64 {
+	if kind == NoiseSimplex {
+		return n.Simplex2D(x, y)
+	}
+	return n.Perlin2D(x, y)
+}
+
+// Sample3D は種類kindの3次元のノイズを返します
+func (n Noise) Sample3D(kind NoiseKind, p Vector3D) float64 {
+	if kind == NoiseSimplex {
+		return n.Simplex3D(p[0], p[1], p[2])
+	}
+	return n.Perlin3D(p[0], p[1], p[2])
+}
+
+// Fractal は周波数を変えたノイズを重ね合わせる（フラクタルノイズの）設定を表します
+// ゼロ値の項目は既定値（Octaves: 4, Lacunarity: 2, Gain: 0.5）になります
+type Fractal struct {
+	// Octaves 重ね合わせる回数
+	Octaves int
+	// Lacunarity 1回ごとに周波数に掛ける倍率
+	Lacunarity float64
+	// Gain 1回ごとに振幅に掛ける倍率
+	Gain float64
+}
+
+// sum はsampleに渡す周波数を変えながら重ね合わせ、振幅の合計で割った値を返します
+func (f Fractal) sum(sample func(frequency float64) float64) float64 {
+	octaves := f.Octaves
+	if octaves <= 0 {
+		octaves = 4
+	}
+	lacunarity := nonZero(f.Lacunarity, 2)
+	gain := nonZero(f.Gain, 0.5)
+
+	total, amplitude, amplitudes, frequency := 0.0, 1.0, 0.0, 1.0
+	for i := 0; i < octaves; i++ {
+		total += amplitude * sample(frequency)
+		amplitudes += amplitude
+		amplitude *= gain
+		frequency *= lacunarity
+	}
+	return total / amplitudes
+}
+
+// FBm2D は2次元のノイズを重ね合わせたフラクタルブラウン運動（fBm）を返します（おおよそ-1から1）
+func (n Noise) FBm2D(kind NoiseKind, x, y float64, f Fractal) float64 {
+	return f.sum(func(frequency float64) float64 {
+		return n.Sample2D(kind, x*frequency, y*frequency)
+	})
+}
+
+// FBm3D は3次元のノイズを重ね合わせたフラクタルブラウン運動（fBm）を返します（おおよそ-1から1）
+func (n Noise) FBm3D(kind NoiseKind, p Vector3D, f Fractal) float64 {
+	return f.sum(func(frequency float64) float64 {
+		return n.Sample3D(kind, p.MulScalar(frequency))
+	})
+}
+
+// Turbulence2D はノイズの絶対値を重ね合わせた乱流を返します（0からおおよそ1）
+func (n Noise) Turbulence2D(kind NoiseKind, x, y float64, f Fractal) float64 {
+	return f.sum(func(frequency float64) float64 {
+		return math.Abs(n.Sample2D(kind, x*frequency, y*frequency))
+	})
+}
+
+// Turbulence3D はノイズの絶対値を重ね合わせた乱流を返します（0からおおよそ1）
+func (n Noise) Turbulence3D(kind NoiseKind, p Vector3D, f Fractal) float64 {
+	return f.sum(func(frequency float64) float64 {
+		return math.Abs(n.Sample3D(kind, p.MulScalar(frequency)))
+	})
+}
+
+// nonZero はvが0の場合にdefaultValueを返します
+func nonZero(v, defaultValue float64) float64 {
+	if v == 0 {
+		return defaultValue
+	}
+	return v
+}
@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// noiseSamplePoints はノイズの値域を調べるための点です
+func noiseSamplePoints() []Vector3D {
+	points := make([]Vector3D, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		f := float64(i)
+		points = append(points, Vector3D{f * 0.137, f * 0.291, f * 0.073})
+	}
+	return points
+}
+
+func TestNoise_同じ種なら同じ値(t *testing.T) {
+	a, b, c := NewNoise(42), NewNoise(42), NewNoise(7)
+	p := Vector3D{1.3, 2.7, 0.4}
+
+	assert.Equal(t, a.Perlin3D(p[0], p[1], p[2]), b.Perlin3D(p[0], p[1], p[2]))
+	assert.NotEqual(t, a.Perlin3D(p[0], p[1], p[2]), c.Perlin3D(p[0], p[1], p[2]))
+	// ゼロ値は種0のノイズ
+	assert.Equal(t, NewNoise(0).Simplex3D(p[0], p[1], p[2]), Noise{}.Simplex3D(p[0], p[1], p[2]))
+}
+
+func TestNoise_Perlin(t *testing.T) {
+	n := NewNoise(1)
+
+	// 格子点では0
+	assert.Equal(t, 0.0, n.Perlin3D(3, -2, 5))
+	assert.Equal(t, 0.0, n.Perlin2D(-4, 7))
+
+	for _, p := range noiseSamplePoints() {
+		assert.LessOrEqual(t, math.Abs(n.Perlin3D(p[0], p[1], p[2])), 1.0)
+		assert.LessOrEqual(t, math.Abs(n.Perlin2D(p[0], p[1])), 1.0)
+	}
+
+	// 連続している
+	assert.InDelta(t, n.Perlin3D(0.5, 0.5, 0.5), n.Perlin3D(0.5001, 0.5, 0.5), 1e-3)
+	assert.InDelta(t, n.Perlin2D(0.5, 0.5), n.Perlin2D(0.5, 0.5001), 1e-3)
+}
+
+func TestNoise_Simplex(t *testing.T) {
+	n := NewNoise(1)
+
+	min2, max2, min3, max3 := 1.0, -1.0, 1.0, -1.0
+	for _, p := range noiseSamplePoints() {
+		v2 := n.Simplex2D(p[0], p[1])
+		v3 := n.Simplex3D(p[0], p[1], p[2])
+		min2, max2 = math.Min(min2, v2), math.Max(max2, v2)
+		min3, max3 = math.Min(min3, v3), math.Max(max3, v3)
+	}
+	assert.GreaterOrEqual(t, min2, -1.0)
+	assert.LessOrEqual(t, max2, 1.0)
+	assert.GreaterOrEqual(t, min3, -1.0)
+	assert.LessOrEqual(t, max3, 1.0)
+	// 正負どちらの値も取る
+	assert.Less(t, min2, -0.3)
+	assert.Greater(t, max2, 0.3)
+	assert.Less(t, min3, -0.3)
+	assert.Greater(t, max3, 0.3)
+
+	assert.InDelta(t, n.Simplex3D(0.3, 0.2, 0.1), n.Simplex3D(0.3001, 0.2, 0.1), 1e-3)
+}
+
+func TestNoise_Worley(t *testing.T) {
+	n := NewNoise(3)
+
+	// 特徴点の上では0
+	feature := n.featurePoint(2, -1, 4)
+	assert.InDelta(t, 0.0, n.Worley3D(2+feature[0], -1+feature[1], 4+feature[2]), 1e-12)
+	feature = n.featurePoint(-3, 5, 0)
+	assert.InDelta(t, 0.0, n.Worley2D(-3+feature[0], 5+feature[1]), 1e-12)
+
+	for _, p := range noiseSamplePoints() {
+		v := n.Worley3D(p[0], p[1], p[2])
+		assert.GreaterOrEqual(t, v, 0.0)
+		assert.LessOrEqual(t, v, math.Sqrt(3))
+	}
+}
+
+func TestNoise_FBmとTurbulence(t *testing.T) {
+	n := NewNoise(5)
+	f := Fractal{Octaves: 6}
+
+	for _, p := range noiseSamplePoints() {
+		assert.LessOrEqual(t, math.Abs(n.FBm3D(NoisePerlin, p, f)), 1.0)
+		assert.LessOrEqual(t, math.Abs(n.FBm2D(NoiseSimplex, p[0], p[1], f)), 1.0)
+		turbulence := n.Turbulence3D(NoiseSimplex, p, f)
+		assert.GreaterOrEqual(t, turbulence, 0.0)
+		assert.LessOrEqual(t, turbulence, 1.0)
+	}
+
+	// 1回だけ重ねる場合は元のノイズと同じ
+	p := Vector3D{0.3, 1.7, 2.2}
+	assert.Equal(t, n.Perlin3D(p[0], p[1], p[2]), n.FBm3D(NoisePerlin, p, Fractal{Octaves: 1}))
+	assert.Equal(t, math.Abs(n.Simplex2D(p[0], p[1])), n.Turbulence2D(NoiseSimplex, p[0], p[1], Fractal{Octaves: 1}))
+}
@@ -0,0 +1,157 @@
+package domain
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// OBJColor はOBJファイルから読み込んだ三角形の色です（OBJの面は色を持たないため）
+var OBJColor = color.RGBA{200, 200, 200, 255}
+
+// LoadOBJ はWavefront OBJファイルからオブジェクトを読み込みます（DecodeOBJ）
+func LoadOBJ(path string) (Object, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Object{}, err
+	}
+	defer file.Close()
+
+	return DecodeOBJ(file)
+}
+
+// DecodeOBJ はWavefront OBJ形式の頂点（v）と面（f）を読み込みます
+// 面の頂点は位置の添字番号だけを使い（テクスチャ座標と法線は読み込まない）、負の添字番号は直前の頂点からの相対位置です
+// 4つ以上の頂点を持つ面は耳刈り法（TriangulatePolygon）で三角形に分割するので、凹んだ面も扱えます
+// OBJは右手座標系で反時計回りの面が表面なので、Z座標の符号を反転して左手座標系の時計回りの面（CalcNormalFromPoints）にします
+// 添字番号は範囲を検査しないので、壊れたファイルは Validate で検査できます
+func DecodeOBJ(r io.Reader) (Object, error) {
+	vertices := make([]Vector3D, 0, 50)
+	triangles := make([][3]int, 0, 50)
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "v":
+			if len(fields) < 4 {
+				return Object{}, fmt.Errorf("obj: line %d: vertex needs 3 coordinates", line)
+			}
+			vertex := Vector3D{}
+			for i := range vertex {
+				value, err := strconv.ParseFloat(fields[i+1], 64)
+				if err != nil {
+					return Object{}, fmt.Errorf("obj: line %d: %w", line, err)
+				}
+				vertex[i] = value
+			}
+			vertices = append(vertices, flipOBJHandedness(vertex))
+		case "f":
+			if len(fields) < 4 {
+				return Object{}, fmt.Errorf("obj: line %d: face needs 3 vertices", line)
+			}
+			face := make([]int, 0, len(fields)-1)
+			for _, field := range fields[1:] {
+				// "v/vt/vn" の形式では最初の添字番号だけを使う
+				index, err := strconv.Atoi(strings.SplitN(field, "/", 2)[0])
+				if err != nil {
+					return Object{}, fmt.Errorf("obj: line %d: %w", line, err)
+				}
+				if index < 0 {
+					face = append(face, len(vertices)+index)
+				} else {
+					face = append(face, index-1)
+				}
+			}
+			triangles = append(triangles, triangulateOBJFace(vertices, face)...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Object{}, err
+	}
+	if len(vertices) == 0 {
+		return Object{}, fmt.Errorf("obj: no vertices")
+	}
+
+	triangleColors := make([]color.RGBA, 0, len(triangles))
+	edges := make([][2]int, 0, len(triangles)*3)
+	for _, triangle := range triangles {
+		triangleColors = append(triangleColors, OBJColor)
+		edges = append(edges, [2]int{triangle[0], triangle[1]}, [2]int{triangle[1], triangle[2]}, [2]int{triangle[2], triangle[0]})
+	}
+	return Object{
+		VertexMatrix:   NewVertexMatrix(vertices),
+		Edges:          CleanEdges(edges),
+		Triangles:      triangles,
+		TriangleColors: triangleColors,
+	}, nil
+}
+
+// triangulateOBJFace は面の頂点の添字番号の列を三角形に分割します
+// 範囲外の添字番号を含む面は頂点の位置がわからないので、最初の頂点から扇状に分割します
+func triangulateOBJFace(vertices []Vector3D, face []int) [][3]int {
+	positions := make([]Vector3D, 0, len(face))
+	for _, index := range face {
+		if index < 0 || index >= len(vertices) {
+			triangles := make([][3]int, 0, len(face)-2)
+			for i := 1; i < len(face)-1; i++ {
+				triangles = append(triangles, [3]int{face[0], face[i], face[i+1]})
+			}
+			return triangles
+		}
+		positions = append(positions, vertices[index])
+	}
+
+	pieces := TriangulatePolygon(positions)
+	triangles := make([][3]int, 0, len(pieces))
+	for _, piece := range pieces {
+		triangles = append(triangles, [3]int{face[piece[0]], face[piece[1]], face[piece[2]]})
+	}
+	return triangles
+}
+
+// SaveOBJ はオブジェクトをWavefront OBJファイルとして保存します（EncodeOBJ）
+func (o Object) SaveOBJ(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return o.EncodeOBJ(file)
+}
+
+// EncodeOBJ はオブジェクトの頂点と三角形をWavefront OBJ形式で書き出します
+// DecodeOBJ と逆にZ座標の符号を反転して右手座標系に戻し、頂点と三角形の順番はそのまま書き出します。頂点属性と三角形の色は書き出しません
+func (o Object) EncodeOBJ(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if o.VertexMatrix.Dense != nil {
+		o.VertexMatrix.EachVertex(func(i int, vertex Vertex) bool {
+			v := flipOBJHandedness(vertex)
+			fmt.Fprintf(bw, "v %s %s %s\n", formatOBJFloat(v[0]), formatOBJFloat(v[1]), formatOBJFloat(v[2]))
+			return true
+		})
+	}
+	for _, triangle := range o.Triangles {
+		fmt.Fprintf(bw, "f %d %d %d\n", triangle[0]+1, triangle[1]+1, triangle[2]+1)
+	}
+	return bw.Flush()
+}
+
+// flipOBJHandedness はZ座標の符号を反転して、右手座標系と左手座標系を入れ替えます
+// 鏡映で面の回る向きも逆になるので、三角形の頂点の順番は変えません。0は-0にしません
+func flipOBJHandedness(v Vector3D) Vector3D {
+	return Vector3D{v[0], v[1], 0 - v[2]}
+}
+
+// formatOBJFloat は座標を読み込み直しても値が変わらない最短の表記にします
+func formatOBJFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
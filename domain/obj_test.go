@@ -0,0 +1,138 @@
+package domain
+
+import (
+	"bytes"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeOBJ(t *testing.T) {
+	source := `# 四角形と三角形
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+vt 0 0
+vn 0 0 1
+f 1/1/1 2/1/1 3/1/1 4/1/1
+
+v 0 0 1
+f -4 -3 -1
+`
+
+	o, err := DecodeOBJ(strings.NewReader(source))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5, o.VertexMatrix.Len())
+	assert.Equal(t, Vector3D{1, 1, 0}, o.VertexMatrix.GetVertex(2))
+	// Z座標は符号を反転して左手座標系にする
+	assert.Equal(t, Vector3D{0, 0, -1}, o.VertexMatrix.GetVertex(4))
+	// 凸な四角形は最初の頂点から扇状に分割する
+	assert.Equal(t, [][3]int{{0, 1, 2}, {0, 2, 3}, {1, 2, 4}}, o.Triangles)
+	assert.Equal(t, []color.RGBA{OBJColor, OBJColor, OBJColor}, o.TriangleColors)
+	assert.Len(t, o.Edges, len(triangleEdgeSet(o.Triangles)))
+}
+
+func TestDecodeOBJ_凹んだ面(t *testing.T) {
+	// L字形の面。扇状に分割すると最初の頂点(1, 2)から(2, 1)への対角線が面の外側を通る
+	source := `v 1 2 0
+v 0 2 0
+v 0 0 0
+v 2 0 0
+v 2 1 0
+v 1 1 0
+f 1 2 3 4 5 6
+`
+
+	o, err := DecodeOBJ(strings.NewReader(source))
+
+	assert.NoError(t, err)
+	assert.Len(t, o.Triangles, 4)
+	area := 0.0
+	for _, triangle := range o.Triangles {
+		a, b, c := o.VertexMatrix.GetVertex(triangle[0]), o.VertexMatrix.GetVertex(triangle[1]), o.VertexMatrix.GetVertex(triangle[2])
+		cross := b.Sub(a).Cross(c.Sub(a))
+		// 三角形は面と同じ向き（+Z側から見て反時計回り）で、重ならない
+		assert.Greater(t, cross.Z(), 0.0, "%v", triangle)
+		area += cross.Distance() / 2
+	}
+	assert.InDelta(t, 3.0, area, 1e-9)
+	assert.Equal(t, 0, o.Validate().Count(MeshIssueDegenerateTriangle))
+}
+
+func TestDecodeOBJ_反時計回りの立方体(t *testing.T) {
+	// 右手座標系で外側から見て反時計回りに並べた、一般的なOBJの立方体
+	source := `v -1 -1 1
+v 1 -1 1
+v 1 1 1
+v -1 1 1
+v -1 -1 -1
+v 1 -1 -1
+v 1 1 -1
+v -1 1 -1
+f 1 2 3 4
+f 6 5 8 7
+f 5 1 4 8
+f 2 6 7 3
+f 4 3 7 8
+f 5 6 2 1
+`
+
+	o, err := DecodeOBJ(strings.NewReader(source))
+
+	assert.NoError(t, err)
+	assertCleanSolid(t, o)
+	// 表面が外側を向くので、向きを揃えても裏返さない
+	assert.InDelta(t, 8.0, meshVolume(o), 1e-9)
+	_, flipped := o.UnifyWinding()
+	assert.Equal(t, 0, flipped)
+	// 元の+Z側の面（1 2 3 4）は-Z側になり、表面が-Zを向く
+	normal := CalcNormalFromPoints(o.VertexMatrix.GetVertex(0), o.VertexMatrix.GetVertex(1), o.VertexMatrix.GetVertex(2))
+	assert.Less(t, normal.Z(), 0.0)
+}
+
+func TestDecodeOBJ_エラー(t *testing.T) {
+	for name, source := range map[string]string{
+		"座標が足りない":  "v 0 0\n",
+		"数値ではない座標": "v 0 a 0\n",
+		"頂点が足りない面": "v 0 0 0\nf 1 1\n",
+		"数値ではない添字": "v 0 0 0\nf 1 x 1\n",
+		"頂点がない":    "# empty\n",
+	} {
+		_, err := DecodeOBJ(strings.NewReader(source))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestObject_EncodeOBJ(t *testing.T) {
+	o := NewIcosphereObject(0.3, 1)
+
+	var buf bytes.Buffer
+	assert.NoError(t, o.EncodeOBJ(&buf))
+	decoded, err := DecodeOBJ(&buf)
+
+	// 頂点の位置と三角形はそのまま戻る
+	assert.NoError(t, err)
+	assert.Equal(t, o.Triangles, decoded.Triangles)
+	assert.Equal(t, o.VertexMatrix.Len(), decoded.VertexMatrix.Len())
+	o.VertexMatrix.EachVertex(func(i int, v Vertex) bool {
+		assert.Equal(t, v, decoded.VertexMatrix.GetVertex(i))
+		return true
+	})
+}
+
+func TestObject_EncodeOBJ_右手座標系(t *testing.T) {
+	o := Object{
+		VertexMatrix: NewVertexMatrix([]Vector3D{{0, 0, 0.5}, {1, 0, 0}, {0, 1, -2}}),
+		Triangles:    [][3]int{{0, 1, 2}},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, o.EncodeOBJ(&buf))
+
+	// Z座標の符号を反転し、三角形の頂点の順番は変えない
+	assert.Equal(t, "v 0 0 -0.5\nv 1 0 0\nv 0 1 2\nf 1 2 3\n", buf.String())
+}
@@ -0,0 +1,144 @@
+package domain
+
+import (
+	"math"
+)
+
+// PanoramaProjection はパノラマカメラの投影方式を表します
+type PanoramaProjection int
+
+const (
+	// Equirectangular 正距円筒図法（緯度経度、幅:高さ=2:1）
+	Equirectangular PanoramaProjection = iota
+	// Fisheye 等距離射影の魚眼
+	Fisheye
+	// Cubemap 6面のキューブマップ（+X, -X, +Y, -Y, +Z, -Z の順に横一列に並べる）
+	Cubemap
+)
+
+// PanoramaCamera はパノラマ画像を出力するカメラモデルです
+// 視錐台を前提としたViewVolumeによるクリッピングは行わず、画素ごとにレイを生成します
+type PanoramaCamera struct {
+	Projection PanoramaProjection
+	// FieldOfView 魚眼の視野角(単位：ラジアン)。Fisheyeの場合のみ使用する
+	FieldOfView float64
+}
+
+// cubemapFace はキューブマップの1面の向きを表します
+type cubemapFace struct {
+	Forward Vector3D
+	Right   Vector3D
+	Up      Vector3D
+}
+
+// cubemapFaces はキューブマップの各面の向きを返します
+// 左手座標系なので Right × Up = Forward となるように定義しています
+func cubemapFaces() [6]cubemapFace {
+	return [6]cubemapFace{
+		{Forward: Vector3D{1, 0, 0}, Right: Vector3D{0, 0, -1}, Up: Vector3D{0, 1, 0}},  // +X
+		{Forward: Vector3D{-1, 0, 0}, Right: Vector3D{0, 0, 1}, Up: Vector3D{0, 1, 0}},  // -X
+		{Forward: Vector3D{0, 1, 0}, Right: Vector3D{1, 0, 0}, Up: Vector3D{0, 0, -1}},  // +Y
+		{Forward: Vector3D{0, -1, 0}, Right: Vector3D{1, 0, 0}, Up: Vector3D{0, 0, 1}},  // -Y
+		{Forward: Vector3D{0, 0, 1}, Right: Vector3D{1, 0, 0}, Up: Vector3D{0, 1, 0}},   // +Z
+		{Forward: Vector3D{0, 0, -1}, Right: Vector3D{-1, 0, 0}, Up: Vector3D{0, 1, 0}}, // -Z
+	}
+}
+
+// RayDirection は画素に対応するカメラ座標系でのレイの方向（正規化済み）を返します
+// 画素に対応するレイが存在しない場合（魚眼の円の外側など）はfalseを返します
+func (p PanoramaCamera) RayDirection(xPixel, yPixel, width, height int32) (bool, Vector3D) {
+	// 画素の中心を0〜1に正規化する
+	u := (float64(xPixel) + 0.5) / float64(width)
+	v := (float64(yPixel) + 0.5) / float64(height)
+
+	switch p.Projection {
+	case Equirectangular:
+		longitude := (u - 0.5) * 2 * math.Pi
+		latitude := (0.5 - v) * math.Pi
+		return true, Vector3D{
+			math.Cos(latitude) * math.Sin(longitude),
+			math.Sin(latitude),
+			math.Cos(latitude) * math.Cos(longitude),
+		}
+	case Fisheye:
+		// 画面の短辺に内接する円を投影範囲とする
+		radius := math.Min(float64(width), float64(height)) / 2
+		nx := (float64(xPixel) + 0.5 - float64(width)/2) / radius
+		ny := (float64(height)/2 - float64(yPixel) - 0.5) / radius
+		rho := math.Sqrt(nx*nx + ny*ny)
+		if rho > 1 {
+			return false, Vector3D{}
+		}
+		// 等距離射影：中心からの距離が光軸からの角度に比例する
+		theta := rho * p.FieldOfView / 2
+		phi := math.Atan2(ny, nx)
+		return true, Vector3D{
+			math.Sin(theta) * math.Cos(phi),
+			math.Sin(theta) * math.Sin(phi),
+			math.Cos(theta),
+		}
+	case Cubemap:
+		faces := cubemapFaces()
+		faceIndex := int(u * float64(len(faces)))
+		if faceIndex >= len(faces) {
+			faceIndex = len(faces) - 1
+		}
+		face := faces[faceIndex]
+		s := u*float64(len(faces)) - float64(faceIndex)
+		direction := face.Forward.
+			Add(face.Right.MulScalar(2*s - 1)).
+			Add(face.Up.MulScalar(1 - 2*v))
+		return true, direction.Normalize()
+	}
+	return false, Vector3D{}
+}
+
+// TransformPanorama はパノラマカメラでワールドをレンダリングします
+// 出力画像のサイズはViewportの幅と高さをそのまま使用します
+// Equirectangularは2:1、Cubemapは6:1（1面の辺の長さ＝高さ）を想定しています
+func (w World) TransformPanorama(p PanoramaCamera) FrameBuffer {
+	calculatedWorld := NewCalculatedWorld(w)
+	for _, obj := range w.CameraSpaceObjects() {
+		obj, _ = obj.CullFaces()
+		calculatedWorld.AddObject(obj)
+	}
+
+	return calculatedWorld.RayTracePanorama(p)
+}
+
+// RayTracePanorama はパノラマカメラのレイでレイトレースします
+// 深度にはカメラからの距離を使用し、NearDistanceとFarDistanceの範囲外の交点は破棄します
+func (w CalculatedWorld) RayTracePanorama(p PanoramaCamera) FrameBuffer {
+	width := w.Origin.Viewport.Width
+	height := w.Origin.Viewport.Height
+
+	frameBuffer := make(FrameBuffer, width*height)
+	for xPixel := int32(0); xPixel < width; xPixel++ {
+		for yPixel := int32(0); yPixel < height; yPixel++ {
+			ok, rayDirection := p.RayDirection(xPixel, yPixel, width, height)
+			if !ok {
+				continue
+			}
+
+			for _, lObj := range w.Objects {
+				for triangleIndex, triangle := range lObj.Triangles {
+					hit, intersection := IntersectRayTriangle(rayDirection, lObj.VertexMatrix, triangle)
+					if !hit {
+						continue
+					}
+					depth := intersection.Distance()
+					if depth < w.Origin.Clipping.NearDistance || depth > w.Origin.Clipping.FarDistance {
+						continue
+					}
+					key := FrameBufferKey{X: xPixel, Y: yPixel}
+					if v, ok := frameBuffer[key]; !ok || depth < v.Depth {
+						// 交点の色を取得（テクスチャがない場合は三角形の色）
+						frameBuffer[key] = FrameBufferValue{Color: lObj.SurfaceColor(triangleIndex, intersection), Depth: depth}
+					}
+				}
+			}
+		}
+	}
+
+	return frameBuffer
+}
@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPanoramaCamera_RayDirection_Equirectangular(t *testing.T) {
+	p := PanoramaCamera{Projection: Equirectangular}
+
+	// 画像の中央は正面（+Z）を向く
+	ok, center := p.RayDirection(100, 50, 200, 100)
+	assert.True(t, ok)
+	assert.InDelta(t, 0.0, center.X(), 0.05)
+	assert.InDelta(t, 0.0, center.Y(), 0.05)
+	assert.InDelta(t, 1.0, center.Z(), 0.05)
+
+	// 画像の左端は真後ろ（-Z）を向く
+	ok, left := p.RayDirection(0, 50, 200, 100)
+	assert.True(t, ok)
+	assert.InDelta(t, -1.0, left.Z(), 0.05)
+
+	// 幅の3/4の位置は右（+X）を向く
+	ok, right := p.RayDirection(150, 50, 200, 100)
+	assert.True(t, ok)
+	assert.InDelta(t, 1.0, right.X(), 0.05)
+
+	// 画像の上端は真上（+Y）を向く
+	ok, top := p.RayDirection(100, 0, 200, 100)
+	assert.True(t, ok)
+	assert.InDelta(t, 1.0, top.Y(), 0.05)
+}
+
+func TestPanoramaCamera_RayDirection_Fisheye(t *testing.T) {
+	p := PanoramaCamera{Projection: Fisheye, FieldOfView: math.Pi}
+
+	// 中心は正面（+Z）を向く
+	ok, center := p.RayDirection(50, 50, 101, 101)
+	assert.True(t, ok)
+	assert.InDelta(t, 0.0, center.X(), 1e-6)
+	assert.InDelta(t, 0.0, center.Y(), 1e-6)
+	assert.InDelta(t, 1.0, center.Z(), 1e-6)
+
+	// 視野角180度の円の右端は真横（+X）を向く
+	ok, right := p.RayDirection(100, 50, 101, 101)
+	assert.True(t, ok)
+	assert.InDelta(t, 1.0, right.X(), 0.05)
+	assert.InDelta(t, 0.0, right.Z(), 0.05)
+
+	// 円の外側はレイが存在しない
+	ok, _ = p.RayDirection(0, 0, 101, 101)
+	assert.False(t, ok)
+}
+
+func TestPanoramaCamera_RayDirection_Cubemap(t *testing.T) {
+	p := PanoramaCamera{Projection: Cubemap}
+
+	expected := []Vector3D{
+		{1, 0, 0},
+		{-1, 0, 0},
+		{0, 1, 0},
+		{0, -1, 0},
+		{0, 0, 1},
+		{0, 0, -1},
+	}
+	for i, e := range expected {
+		// 各面の中心は面の正面方向を向く
+		ok, direction := p.RayDirection(int32(i*11+5), 5, 66, 11)
+		assert.True(t, ok)
+		assert.InDelta(t, e.X(), direction.X(), 1e-6)
+		assert.InDelta(t, e.Y(), direction.Y(), 1e-6)
+		assert.InDelta(t, e.Z(), direction.Z(), 1e-6)
+	}
+
+	// +Z面の右上の画素は右上方向を向く
+	ok, direction := p.RayDirection(4*11+10, 0, 66, 11)
+	assert.True(t, ok)
+	assert.Greater(t, direction.X(), 0.0)
+	assert.Greater(t, direction.Y(), 0.0)
+	assert.Greater(t, direction.Z(), 0.0)
+}
+
+func TestWorld_TransformPanorama_カメラの後方が描画されること(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	world := World{
+		LocatedObjects: []LocatedObject{
+			{
+				// カメラの後方に配置し、カメラの方を向ける
+				Location: Vector3D{0, 0, -2},
+				Scale:    Vector3D{1, 1, 1},
+				Rotation: Vector3D{0, math.Pi, 0},
+				Object:   NewPlaneObject(1, 1, red),
+			},
+		},
+		Viewport: Viewport{
+			Width:  40,
+			Height: 20,
+		},
+		Clipping: Clipping{
+			NearDistance: 0.1,
+			FarDistance:  10.0,
+		},
+	}
+
+	frameBuffer := world.TransformPanorama(PanoramaCamera{Projection: Equirectangular})
+
+	// 画像の左端（真後ろ）に描画される
+	assert.Equal(t, red, frameBuffer[FrameBufferKey{X: 0, Y: 10}].Color)
+	assert.InDelta(t, 2.0, frameBuffer[FrameBufferKey{X: 0, Y: 10}].Depth, 0.05)
+	// 画像の中央（正面）には何もない
+	assert.NotContains(t, frameBuffer, FrameBufferKey{X: 20, Y: 10})
+}
@@ -0,0 +1,402 @@
+package domain
+
+import (
+	"image/color"
+	"math"
+)
+
+const (
+	// AttributeNormal 頂点の法線（要素数3）の属性名
+	AttributeNormal = "normal"
+	// AttributeUV 頂点のテクスチャ座標（要素数2）の属性名
+	AttributeUV = "uv"
+)
+
+// 以下のプリミティブは全て原点を中心とし、Y軸を上とします
+// 三角形は外側から見て表面になる（IntersectRayTriangleで交差する）頂点の順番で作り、
+// 頂点属性として法線（AttributeNormal）とテクスチャ座標（AttributeUV）を持ちます
+// colorsは面（三角形分割する前の多角形。格子の1マスや箱の1面など）ごとの色で、面の数より少ない場合は繰り返して使います
+// 色を指定しない場合はデフォルト色になります
+
+// NewBoxObject は直方体のオブジェクトを作ります
+// 面の順番は前（-Z）、後（+Z）、左（-X）、右（+X）、下（-Y）、上（+Y）です
+func NewBoxObject(width, height, depth float64, colors ...color.RGBA) Object {
+	b := newPrimitiveBuilder()
+	half := Vector3D{width / 2, height / 2, depth / 2}
+	sides := []struct {
+		normal, uAxis, vAxis Vector3D
+	}{
+		{Vector3D{0, 0, -1}, Vector3D{1, 0, 0}, Vector3D{0, -1, 0}},
+		{Vector3D{0, 0, 1}, Vector3D{-1, 0, 0}, Vector3D{0, -1, 0}},
+		{Vector3D{-1, 0, 0}, Vector3D{0, 0, -1}, Vector3D{0, -1, 0}},
+		{Vector3D{1, 0, 0}, Vector3D{0, 0, 1}, Vector3D{0, -1, 0}},
+		{Vector3D{0, -1, 0}, Vector3D{1, 0, 0}, Vector3D{0, 0, 1}},
+		{Vector3D{0, 1, 0}, Vector3D{1, 0, 0}, Vector3D{0, 0, -1}},
+	}
+	for _, side := range sides {
+		b.addGrid(1, 1, func(u, v float64) (Vector3D, Vector3D) {
+			p := side.normal.Add(side.uAxis.MulScalar(2 * (u - 0.5))).Add(side.vAxis.MulScalar(2 * (v - 0.5)))
+			return Vector3D{p[0] * half[0], p[1] * half[1], p[2] * half[2]}, side.normal
+		})
+	}
+	return b.toObject(colors)
+}
+
+// NewCubeObject は立方体のオブジェクトを作ります
+func NewCubeObject(size float64, colors ...color.RGBA) Object {
+	return NewBoxObject(size, size, size, colors...)
+}
+
+// NewGridPlaneObject はXY平面上で格子状に分割した平面のオブジェクトを作ります
+// NewPlaneObjectと同じく-Z方向を表面とします
+func NewGridPlaneObject(width, height float64, columns, rows int, colors ...color.RGBA) Object {
+	b := newPrimitiveBuilder()
+	b.addGrid(atLeast(columns, 1), atLeast(rows, 1), func(u, v float64) (Vector3D, Vector3D) {
+		return Vector3D{(u - 0.5) * width, (0.5 - v) * height, 0}, Vector3D{0, 0, -1}
+	})
+	return b.toObject(colors)
+}
+
+// NewDiskObject はXY平面上の円盤のオブジェクトを作ります
+// NewPlaneObjectと同じく-Z方向を表面とします。segmentsは円周の分割数（3以上）です
+func NewDiskObject(radius float64, segments int, colors ...color.RGBA) Object {
+	b := newPrimitiveBuilder()
+	b.addDisk(atLeast(segments, 3), func(x, y float64) (Vector3D, Vector3D) {
+		return Vector3D{x * radius, y * radius, 0}, Vector3D{0, 0, -1}
+	})
+	return b.toObject(colors)
+}
+
+// NewUVSphereObject は経線（segments、3以上）と緯線（rings、2以上）で分割した球のオブジェクトを作ります
+func NewUVSphereObject(radius float64, segments, rings int, colors ...color.RGBA) Object {
+	b := newPrimitiveBuilder()
+	b.addGrid(atLeast(segments, 3), atLeast(rings, 2), func(u, v float64) (Vector3D, Vector3D) {
+		normal := sphericalDirection(u, v)
+		return normal.MulScalar(radius), normal
+	})
+	return b.toObject(colors)
+}
+
+// NewIcosphereObject は正二十面体をlevel回細分化した球のオブジェクトを作ります
+// 三角形の数は 20 * 4^level です。面は細分化した後の三角形です
+func NewIcosphereObject(radius float64, level int, colors ...color.RGBA) Object {
+	t := (1 + math.Sqrt(5)) / 2
+	directions := []Vector3D{
+		{-1, t, 0}, {1, t, 0}, {-1, -t, 0}, {1, -t, 0},
+		{0, -1, t}, {0, 1, t}, {0, -1, -t}, {0, 1, -t},
+		{t, 0, -1}, {t, 0, 1}, {-t, 0, -1}, {-t, 0, 1},
+	}
+	for i := range directions {
+		directions[i] = directions[i].Normalize()
+	}
+	triangles := [][3]int{
+		{0, 11, 5}, {0, 5, 1}, {0, 1, 7}, {0, 7, 10}, {0, 10, 11},
+		{1, 5, 9}, {5, 11, 4}, {11, 10, 2}, {10, 7, 6}, {7, 1, 8},
+		{3, 9, 4}, {3, 4, 2}, {3, 2, 6}, {3, 6, 8}, {3, 8, 9},
+		{4, 9, 5}, {2, 4, 11}, {6, 2, 10}, {8, 6, 7}, {9, 8, 1},
+	}
+
+	// 辺の中点を球面上に移して4つの三角形に分ける（辺を共有する三角形は同じ中点を使う）
+	for i := 0; i < level; i++ {
+		midpoints := make(map[[2]int]int, len(triangles)*3/2)
+		midpoint := func(a, b int) int {
+			key := [2]int{a, b}
+			if a > b {
+				key = [2]int{b, a}
+			}
+			if index, ok := midpoints[key]; ok {
+				return index
+			}
+			directions = append(directions, directions[a].Add(directions[b]).Normalize())
+			midpoints[key] = len(directions) - 1
+			return len(directions) - 1
+		}
+
+		subdivided := make([][3]int, 0, len(triangles)*4)
+		for _, triangle := range triangles {
+			ab := midpoint(triangle[0], triangle[1])
+			bc := midpoint(triangle[1], triangle[2])
+			ca := midpoint(triangle[2], triangle[0])
+			subdivided = append(subdivided,
+				[3]int{triangle[0], ab, ca},
+				[3]int{triangle[1], bc, ab},
+				[3]int{triangle[2], ca, bc},
+				[3]int{ab, bc, ca},
+			)
+		}
+		triangles = subdivided
+	}
+
+	b := newPrimitiveBuilder()
+	// 継ぎ目でずらした頂点は別の頂点として追加する
+	vertexIndexes := make(map[[2]int]int, len(directions))
+	vertex := func(index int, uv [2]float64) int {
+		key := [2]int{index, int(uv[0])}
+		if vertexIndex, ok := vertexIndexes[key]; ok {
+			return vertexIndex
+		}
+		vertexIndex := b.addVertex(directions[index].MulScalar(radius), directions[index], uv[0], uv[1])
+		vertexIndexes[key] = vertexIndex
+		return vertexIndex
+	}
+	for _, triangle := range triangles {
+		var uvs [3][2]float64
+		for k, index := range triangle {
+			uvs[k] = sphericalUV(directions[index])
+		}
+		// 経度の継ぎ目をまたぐ三角形は、テクスチャ座標が1周しないようにuを1ずらす
+		for k := range uvs {
+			if math.Max(uvs[0][0], math.Max(uvs[1][0], uvs[2][0]))-uvs[k][0] > 0.5 {
+				uvs[k][0]++
+			}
+		}
+
+		var indexes [3]int
+		for k, index := range triangle {
+			indexes[k] = vertex(index, uvs[k])
+		}
+		b.addPolygon(indexes[:])
+	}
+	return b.toObject(colors)
+}
+
+// NewCylinderObject はY軸に沿った円柱のオブジェクトを作ります
+// segmentsは円周の分割数（3以上）です。面の順番は側面の各マス、上面、下面です
+func NewCylinderObject(radius, height float64, segments int, colors ...color.RGBA) Object {
+	segments = atLeast(segments, 3)
+	b := newPrimitiveBuilder()
+	b.addGrid(segments, 1, func(u, v float64) (Vector3D, Vector3D) {
+		normal := ringDirection(u)
+		return Vector3D{normal[0] * radius, (0.5 - v) * height, normal[2] * radius}, normal
+	})
+	b.addDisk(segments, func(x, z float64) (Vector3D, Vector3D) {
+		return Vector3D{x * radius, height / 2, z * radius}, Vector3D{0, 1, 0}
+	})
+	b.addDisk(segments, func(x, z float64) (Vector3D, Vector3D) {
+		return Vector3D{x * radius, -height / 2, z * radius}, Vector3D{0, -1, 0}
+	})
+	return b.toObject(colors)
+}
+
+// NewConeObject は頂点を上（+Y）に向けた円錐のオブジェクトを作ります
+// segmentsは円周の分割数（3以上）です。面の順番は側面の各三角形、底面です
+func NewConeObject(radius, height float64, segments int, colors ...color.RGBA) Object {
+	segments = atLeast(segments, 3)
+	b := newPrimitiveBuilder()
+	b.addGrid(segments, 1, func(u, v float64) (Vector3D, Vector3D) {
+		direction := ringDirection(u)
+		normal := Vector3D{direction[0] * height, radius, direction[2] * height}.Normalize()
+		return Vector3D{direction[0] * radius * v, (0.5 - v) * height, direction[2] * radius * v}, normal
+	})
+	b.addDisk(segments, func(x, z float64) (Vector3D, Vector3D) {
+		return Vector3D{x * radius, -height / 2, z * radius}, Vector3D{0, -1, 0}
+	})
+	return b.toObject(colors)
+}
+
+// NewCapsuleObject はY軸に沿った円柱の両端を半球で塞いだカプセルのオブジェクトを作ります
+// heightは円柱部分の長さ（全体の高さは height + 2 * radius）です
+// segmentsは円周の分割数（3以上）、ringsは半球の緯線の分割数（1以上）です
+func NewCapsuleObject(radius, height float64, segments, rings int, colors ...color.RGBA) Object {
+	segments = atLeast(segments, 3)
+	rings = atLeast(rings, 1)
+	b := newPrimitiveBuilder()
+	// 上の半球
+	b.addGrid(segments, rings, func(u, v float64) (Vector3D, Vector3D) {
+		normal := sphericalDirection(u, v/2)
+		return normal.MulScalar(radius).Add(Vector3D{0, height / 2, 0}), normal
+	})
+	// 円柱部分
+	b.addGrid(segments, 1, func(u, v float64) (Vector3D, Vector3D) {
+		normal := ringDirection(u)
+		return Vector3D{normal[0] * radius, (0.5 - v) * height, normal[2] * radius}, normal
+	})
+	// 下の半球
+	b.addGrid(segments, rings, func(u, v float64) (Vector3D, Vector3D) {
+		normal := sphericalDirection(u, 0.5+v/2)
+		return normal.MulScalar(radius).Sub(Vector3D{0, height / 2, 0}), normal
+	})
+	return b.toObject(colors)
+}
+
+// NewTorusObject はY軸を中心に管を1周させたトーラスのオブジェクトを作ります
+// majorRadiusは中心から管の中心までの距離、minorRadiusは管の半径です
+// majorSegmentsは1周の分割数（3以上）、minorSegmentsは管の断面の分割数（3以上）です
+func NewTorusObject(majorRadius, minorRadius float64, majorSegments, minorSegments int, colors ...color.RGBA) Object {
+	b := newPrimitiveBuilder()
+	b.addGrid(atLeast(majorSegments, 3), atLeast(minorSegments, 3), func(u, v float64) (Vector3D, Vector3D) {
+		ring := ringDirection(u)
+		theta := 2 * math.Pi * v
+		normal := Vector3D{math.Cos(theta) * ring[0], math.Sin(theta), math.Cos(theta) * ring[2]}
+		return ring.MulScalar(majorRadius).Add(normal.MulScalar(minorRadius)), normal
+	})
+	return b.toObject(colors)
+}
+
+// primitiveBuilder はプリミティブのオブジェクトを組み立てます
+type primitiveBuilder struct {
+	vertices  []Vector3D
+	normals   []float64
+	uvs       []float64
+	triangles [][3]int
+	// faces 三角形ごとの面の番号
+	faces []int
+	// faceCount 追加した面の数
+	faceCount int
+}
+
+func newPrimitiveBuilder() *primitiveBuilder {
+	return &primitiveBuilder{}
+}
+
+func (b *primitiveBuilder) addVertex(position, normal Vector3D, u, v float64) int {
+	b.vertices = append(b.vertices, position)
+	b.normals = append(b.normals, normal[0], normal[1], normal[2])
+	b.uvs = append(b.uvs, u, v)
+	return len(b.vertices) - 1
+}
+
+// addPolygon は凸多角形を1つの面として追加します
+// 三角形の頂点の順番は、頂点の法線の向きが表面になるように揃えます
+// 極などで潰れた三角形は追加しません
+func (b *primitiveBuilder) addPolygon(indexes []int) {
+	triangles := make([][3]int, 0, len(indexes)-2)
+	for i := 1; i < len(indexes)-1; i++ {
+		triangles = append(triangles, [3]int{indexes[0], indexes[i], indexes[i+1]})
+	}
+	b.addFace(triangles)
+}
+
+// addFace は三角形の集まりを1つの面として追加します
+// 頂点の順番の揃え方と潰れた三角形の扱いはaddPolygonと同じです
+func (b *primitiveBuilder) addFace(triangles [][3]int) {
+	added := false
+	for _, triangle := range triangles {
+		p := [3]Vector3D{b.vertices[triangle[0]], b.vertices[triangle[1]], b.vertices[triangle[2]]}
+		if IsDegenerateTriangle(p) {
+			continue
+		}
+
+		expected := Vector3D{}
+		for _, index := range triangle {
+			expected = expected.Add(Vector3D{b.normals[index*3], b.normals[index*3+1], b.normals[index*3+2]})
+		}
+		// 表面の法線（IsFrontFacingと同じ向き）
+		normal := p[2].Sub(p[0]).Cross(p[1].Sub(p[0]))
+		if normal.Dot(expected) < 0 {
+			triangle[1], triangle[2] = triangle[2], triangle[1]
+		}
+
+		b.triangles = append(b.triangles, triangle)
+		b.faces = append(b.faces, b.faceCount)
+		added = true
+	}
+	if added {
+		b.faceCount++
+	}
+}
+
+// addGrid はパラメータ(u, v)（それぞれ0から1）で表される曲面を、columns×rowsの格子に分割して追加します
+// fは位置と法線を返します。テクスチャ座標は(u, v)です
+func (b *primitiveBuilder) addGrid(columns, rows int, f func(u, v float64) (Vector3D, Vector3D)) {
+	b.addIndexedGrid(columns, rows, func(column, row int) (Vector3D, Vector3D, [2]float64) {
+		u := float64(column) / float64(columns)
+		v := float64(row) / float64(rows)
+		position, normal := f(u, v)
+		return position, normal, [2]float64{u, v}
+	})
+}
+
+// addIndexedGrid は(columns+1)×(rows+1)個の格子点を持つ曲面を追加します
+// fは格子点の番号から位置と法線とテクスチャ座標を返します。格子の1マスが1つの面になります
+func (b *primitiveBuilder) addIndexedGrid(columns, rows int, f func(column, row int) (Vector3D, Vector3D, [2]float64)) {
+	first := len(b.vertices)
+	for row := 0; row <= rows; row++ {
+		for column := 0; column <= columns; column++ {
+			position, normal, uv := f(column, row)
+			b.addVertex(position, normal, uv[0], uv[1])
+		}
+	}
+
+	index := func(column, row int) int {
+		return first + row*(columns+1) + column
+	}
+	for row := 0; row < rows; row++ {
+		for column := 0; column < columns; column++ {
+			b.addPolygon([]int{
+				index(column, row),
+				index(column+1, row),
+				index(column+1, row+1),
+				index(column, row+1),
+			})
+		}
+	}
+}
+
+// addDisk は単位円を1つの面として追加します
+// fは単位円上の点(x, y)から位置と法線を返します。テクスチャ座標は円に内接する正方形に合わせます
+func (b *primitiveBuilder) addDisk(segments int, f func(x, y float64) (Vector3D, Vector3D)) {
+	indexes := make([]int, 0, segments)
+	for i := 0; i < segments; i++ {
+		angle := 2 * math.Pi * float64(i) / float64(segments)
+		x, y := math.Cos(angle), math.Sin(angle)
+		position, normal := f(x, y)
+		indexes = append(indexes, b.addVertex(position, normal, 0.5+x/2, 0.5-y/2))
+	}
+	b.addPolygon(indexes)
+}
+
+func (b *primitiveBuilder) toObject(colors []color.RGBA) Object {
+	triangleColors := make([]color.RGBA, 0, len(b.triangles))
+	edges := make([][2]int, 0, len(b.triangles)*3)
+	for i, triangle := range b.triangles {
+		if len(colors) > 0 {
+			triangleColors = append(triangleColors, colors[b.faces[i]%len(colors)])
+		} else {
+			triangleColors = append(triangleColors, color.RGBA{0, 0, 0, 255}) // デフォルト色
+		}
+		edges = append(edges, [2]int{triangle[0], triangle[1]}, [2]int{triangle[1], triangle[2]}, [2]int{triangle[2], triangle[0]})
+	}
+
+	return Object{
+		VertexMatrix:   NewVertexMatrix(b.vertices),
+		Edges:          CleanEdges(edges),
+		Triangles:      b.triangles,
+		TriangleColors: triangleColors,
+		Attributes: []VertexAttribute{
+			{Name: AttributeNormal, Size: 3, Values: b.normals},
+			{Name: AttributeUV, Size: 2, Values: b.uvs},
+		},
+	}
+}
+
+// sphericalDirection は経度u（0から1で1周）と余緯度v（0が上の極、1が下の極）の単位ベクトルを返します
+func sphericalDirection(u, v float64) Vector3D {
+	theta := math.Pi * v
+	ring := ringDirection(u)
+	return Vector3D{math.Sin(theta) * ring[0], math.Cos(theta), math.Sin(theta) * ring[2]}
+}
+
+// sphericalUV は単位ベクトルを経度と余緯度のテクスチャ座標（sphericalDirectionの逆）に変換します
+func sphericalUV(direction Vector3D) [2]float64 {
+	u := math.Atan2(direction[2], direction[0]) / (2 * math.Pi)
+	if u < 0 {
+		u++
+	}
+	v := math.Acos(math.Max(-1, math.Min(1, direction[1]))) / math.Pi
+	return [2]float64{u, v}
+}
+
+// ringDirection はXZ平面上で角度u（0から1で1周）の単位ベクトルを返します
+func ringDirection(u float64) Vector3D {
+	angle := 2 * math.Pi * u
+	return Vector3D{math.Cos(angle), 0, math.Sin(angle)}
+}
+
+// atLeast はvalueがminimumより小さい場合はminimumを返します
+func atLeast(value, minimum int) int {
+	if value < minimum {
+		return minimum
+	}
+	return value
+}
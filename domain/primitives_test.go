@@ -0,0 +1,209 @@
+package domain
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// weldedTriangles は同じ位置の頂点を1つにまとめた三角形を返します（テクスチャ座標の継ぎ目を無視するため）
+func weldedTriangles(o Object) [][3]int {
+	grid := NewVertexGrid(o.MergeTolerance())
+	vertexMap := make([]int, o.VertexMatrix.Len())
+	o.VertexMatrix.EachVertex(func(i int, v Vertex) bool {
+		vertexMap[i] = grid.AddVertex(v)
+		return true
+	})
+	triangles := make([][3]int, 0, len(o.Triangles))
+	for _, triangle := range o.Triangles {
+		triangles = append(triangles, [3]int{vertexMap[triangle[0]], vertexMap[triangle[1]], vertexMap[triangle[2]]})
+	}
+	return triangles
+}
+
+// assertWeldedClosedMesh は同じ位置の頂点をまとめると閉じていて、向きが揃っていることを検証します
+func assertWeldedClosedMesh(t *testing.T, o Object) {
+	welded := o
+	welded.Triangles = weldedTriangles(o)
+	assertClosedMesh(t, welded)
+}
+
+// assertFacesMatchNormals は全ての三角形の表面が頂点の法線の向きを向いていることを検証します
+func assertFacesMatchNormals(t *testing.T, o Object) {
+	ok, normals := o.Attribute(AttributeNormal)
+	assert.True(t, ok)
+	for _, triangle := range o.Triangles {
+		a := o.VertexMatrix.GetVertex(triangle[0])
+		b := o.VertexMatrix.GetVertex(triangle[1])
+		c := o.VertexMatrix.GetVertex(triangle[2])
+		expected := Vector3D{}
+		for _, index := range triangle {
+			n := Vector3D{normals.Values[index*3], normals.Values[index*3+1], normals.Values[index*3+2]}
+			assert.InDelta(t, 1.0, n.Distance(), 1e-9)
+			expected = expected.Add(n)
+		}
+		assert.Greater(t, CalcNormalFromPoints(a, b, c).Dot(expected), 0.0, "%v", triangle)
+	}
+}
+
+// assertConvexOutward は全ての三角形の表面が中心（原点）から外側を向いていることを検証します
+func assertConvexOutward(t *testing.T, o Object) {
+	for _, triangle := range o.Triangles {
+		a := o.VertexMatrix.GetVertex(triangle[0])
+		b := o.VertexMatrix.GetVertex(triangle[1])
+		c := o.VertexMatrix.GetVertex(triangle[2])
+		centroid := a.Add(b).Add(c).MulScalar(1.0 / 3)
+		assert.Greater(t, CalcNormalFromPoints(a, b, c).Dot(centroid), 0.0, "%v", triangle)
+	}
+}
+
+// assertUVInRange はテクスチャ座標が0から1の範囲にあることを検証します
+func assertUVInRange(t *testing.T, o Object, max float64) {
+	ok, uvs := o.Attribute(AttributeUV)
+	assert.True(t, ok)
+	assert.Len(t, uvs.Values, o.VertexMatrix.Len()*2)
+	for _, value := range uvs.Values {
+		assert.GreaterOrEqual(t, value, 0.0)
+		assert.LessOrEqual(t, value, max)
+	}
+}
+
+func TestNewPrimitiveObjects_閉じた立体(t *testing.T) {
+	primitives := map[string]Object{
+		"box":       NewBoxObject(1, 2, 3),
+		"cube":      NewCubeObject(1),
+		"uvSphere":  NewUVSphereObject(1, 12, 6),
+		"icosphere": NewIcosphereObject(1, 2),
+		"cylinder":  NewCylinderObject(1, 2, 10),
+		"cone":      NewConeObject(1, 2, 10),
+		"capsule":   NewCapsuleObject(0.5, 1, 10, 4),
+	}
+
+	for name, o := range primitives {
+		t.Run(name, func(t *testing.T) {
+			assertWeldedClosedMesh(t, o)
+			assertFacesMatchNormals(t, o)
+			assertConvexOutward(t, o)
+			if name == "icosphere" {
+				// 継ぎ目をまたぐ三角形はuを1ずらす
+				assertUVInRange(t, o, 2)
+			} else {
+				assertUVInRange(t, o, 1)
+			}
+			assert.Len(t, o.TriangleColors, len(o.Triangles))
+		})
+	}
+}
+
+func TestNewTorusObject(t *testing.T) {
+	o := NewTorusObject(1, 0.25, 16, 8)
+
+	assert.Len(t, o.Triangles, 16*8*2)
+	assertWeldedClosedMesh(t, o)
+	assertFacesMatchNormals(t, o)
+	assertUVInRange(t, o, 1)
+
+	_, box := o.BoundingBox()
+	assert.InDelta(t, 1.25, box.Max.X(), 1e-9)
+	assert.InDelta(t, 0.25, box.Max.Y(), 1e-9)
+}
+
+func TestNewPrimitiveObjects_平面(t *testing.T) {
+	for name, o := range map[string]Object{
+		"gridPlane": NewGridPlaneObject(2, 1, 4, 2),
+		"disk":      NewDiskObject(1, 16),
+	} {
+		t.Run(name, func(t *testing.T) {
+			assertFacesMatchNormals(t, o)
+			assertUVInRange(t, o, 1)
+			// NewPlaneObjectと同じく-Z方向を表面とする
+			for _, triangle := range o.Triangles {
+				normal := CalcNormalFromPoints(o.VertexMatrix.GetVertex(triangle[0]), o.VertexMatrix.GetVertex(triangle[1]), o.VertexMatrix.GetVertex(triangle[2]))
+				assert.InDelta(t, -1.0, normal.Z(), 1e-9)
+			}
+		})
+	}
+
+	assert.Len(t, NewGridPlaneObject(2, 1, 4, 2).Triangles, 16)
+	assert.Len(t, NewDiskObject(1, 16).Triangles, 14)
+}
+
+func TestNewUVSphereObject_極の三角形(t *testing.T) {
+	o := NewUVSphereObject(2, 8, 4)
+
+	// 極に接するマスは三角形1つになる
+	assert.Len(t, o.Triangles, 8*4*2-2*8)
+	o.VertexMatrix.EachVertex(func(_ int, v Vertex) bool {
+		assert.InDelta(t, 2.0, v.Distance(), 1e-9)
+		return true
+	})
+}
+
+func TestNewIcosphereObject(t *testing.T) {
+	assert.Len(t, NewIcosphereObject(1, 0).Triangles, 20)
+	o := NewIcosphereObject(1, 1)
+	assert.Len(t, o.Triangles, 80)
+
+	// 継ぎ目をまたぐ三角形はuを1ずらすため、uは2未満になる
+	assertUVInRange(t, o, 2)
+	ok, uvs := o.Attribute(AttributeUV)
+	assert.True(t, ok)
+	for _, triangle := range o.Triangles {
+		us := []float64{uvs.Values[triangle[0]*2], uvs.Values[triangle[1]*2], uvs.Values[triangle[2]*2]}
+		assert.LessOrEqual(t, math.Max(us[0], math.Max(us[1], us[2]))-math.Min(us[0], math.Min(us[1], us[2])), 0.5)
+	}
+}
+
+func TestNewBoxObject_面ごとの色(t *testing.T) {
+	colors := []color.RGBA{
+		{255, 0, 0, 255},
+		{0, 255, 0, 255},
+		{0, 0, 255, 255},
+		{255, 255, 0, 255},
+		{0, 255, 255, 255},
+		{255, 0, 255, 255},
+	}
+
+	o := NewBoxObject(1, 1, 1, colors...)
+
+	assert.Equal(t, 24, o.VertexMatrix.Len())
+	assert.Len(t, o.Triangles, 12)
+	for i, c := range o.TriangleColors {
+		// 1つの面は2つの三角形
+		assert.Equal(t, colors[i/2], c)
+	}
+
+	// 面の数より少ない色は繰り返して使う
+	o = NewBoxObject(1, 1, 1, colors[0], colors[1])
+	assert.Equal(t, colors[0], o.TriangleColors[8])
+	assert.Equal(t, colors[1], o.TriangleColors[10])
+
+	// 色を指定しない場合はデフォルト色
+	assert.Equal(t, color.RGBA{0, 0, 0, 255}, NewBoxObject(1, 1, 1).TriangleColors[0])
+}
+
+func TestWorld_Transform_プリミティブ(t *testing.T) {
+	front := color.RGBA{255, 0, 0, 255}
+	other := color.RGBA{0, 0, 255, 255}
+	world := World{
+		LocatedObjects: []LocatedObject{
+			{
+				Location: Vector3D{0, 0, 3},
+				Scale:    Vector3D{1, 1, 1},
+				Object:   NewCubeObject(1, front, other, other, other, other, other),
+			},
+		},
+		Viewport: Viewport{Width: 16, Height: 12},
+		Clipping: Clipping{NearDistance: 0.1, FarDistance: 10.0, FieldOfView: math.Pi / 4},
+	}
+
+	frameBuffer := world.Transform()
+
+	// 前の面（-Z）だけが見える
+	assert.Equal(t, front, frameBuffer[FrameBufferKey{X: 8, Y: 6}].Color)
+	for _, value := range frameBuffer {
+		assert.Equal(t, front, value.Color)
+	}
+}
@@ -0,0 +1,219 @@
+package domain
+
+import (
+	"image/color"
+)
+
+// MeshRepair はメッシュの修復で行う処理です
+type MeshRepair struct {
+	// Weld 位置と頂点属性が同じ頂点を1つにまとめる（WeldVertices）
+	Weld bool
+	// RemoveDegenerate 面積が0の三角形を取り除く（RemoveDegenerateTriangles）
+	RemoveDegenerate bool
+	// UnifyWinding 辺を共有する三角形の向きを揃える（UnifyWinding）
+	UnifyWinding bool
+	// MaxHoleEdges 塞ぐ穴の境界の辺の数の上限（FillHoles）。0の場合は穴を塞がない
+	MaxHoleEdges int
+}
+
+// MeshRepairStats は修復で変えた数です
+type MeshRepairStats struct {
+	// RemovedTriangles 取り除いた三角形の数（範囲外の添字番号を含む三角形も含む）
+	RemovedTriangles int
+	// WeldedVertices まとめて減った頂点の数
+	WeldedVertices int
+	// FlippedTriangles 裏返した三角形の数
+	FlippedTriangles int
+	// FilledHoles 塞いだ穴の数
+	FilledHoles int
+}
+
+// Repair はメッシュを修復します
+// 範囲外の添字番号を含む三角形を取り除いてから、頂点をまとめる・面積が0の三角形を取り除く・向きを揃える・穴を塞ぐの順に行います
+func (o Object) Repair(r MeshRepair) (Object, MeshRepairStats) {
+	stats := MeshRepairStats{}
+	o, stats.RemovedTriangles = o.removeInvalidTriangles()
+
+	if r.Weld {
+		triangleCount := len(o.Triangles)
+		o, stats.WeldedVertices = o.WeldVertices()
+		stats.RemovedTriangles += triangleCount - len(o.Triangles)
+	}
+	if r.RemoveDegenerate {
+		var removed int
+		o, removed = o.RemoveDegenerateTriangles()
+		stats.RemovedTriangles += removed
+	}
+	if r.UnifyWinding {
+		o, stats.FlippedTriangles = o.UnifyWinding()
+	}
+	if r.MaxHoleEdges > 0 {
+		o, stats.FilledHoles = o.FillHoles(r.MaxHoleEdges)
+	}
+	return o, stats
+}
+
+// removeInvalidTriangles は範囲外の添字番号を含む三角形を色と共に取り除きます。範囲外の添字番号を含む辺も取り除きます
+// 取り除いた三角形の数も返します
+func (o Object) removeInvalidTriangles() (Object, int) {
+	vertexCount := 0
+	if o.VertexMatrix.Dense != nil {
+		vertexCount = o.VertexMatrix.Len()
+	}
+	inRange := func(indexes ...int) bool {
+		for _, index := range indexes {
+			if index < 0 || index >= vertexCount {
+				return false
+			}
+		}
+		return true
+	}
+
+	triangles := make([][3]int, 0, len(o.Triangles))
+	triangleColors := make([]color.RGBA, 0, len(o.Triangles))
+	for i, triangle := range o.Triangles {
+		if inRange(triangle[:]...) {
+			triangles = append(triangles, triangle)
+			triangleColors = append(triangleColors, o.TriangleColor(i))
+		}
+	}
+	edges := make([][2]int, 0, len(o.Edges))
+	for _, edge := range o.Edges {
+		if inRange(edge[:]...) {
+			edges = append(edges, edge)
+		}
+	}
+
+	removed := len(o.Triangles) - len(triangles)
+	if removed == 0 && len(edges) == len(o.Edges) {
+		return o, 0
+	}
+	o.Triangles = triangles
+	o.TriangleColors = triangleColors
+	o.Edges = edges
+	return o, removed
+}
+
+// WeldVertices は位置と頂点属性が同じ頂点を VertexGrid で1つにまとめます（MargeVertices）
+// まとめた結果、同じ頂点を含むようになった三角形と重複する三角形は色と共に取り除きます
+// 減った頂点の数も返します
+func (o Object) WeldVertices() (Object, int) {
+	if o.VertexMatrix.Dense == nil || o.VertexMatrix.Len() == 0 {
+		return o, 0
+	}
+	welded := ViewVolume{}.MargeVertices(o)
+	welded.CullMode = o.CullMode
+	welded.Texture = o.Texture
+	return welded, o.VertexMatrix.Len() - welded.VertexMatrix.Len()
+}
+
+// UnifyWinding は辺を共有する三角形の向きを揃えます（HalfEdgeMesh.OrientFaces）
+// 閉じた塊は表面が外側を向く方、開いた塊は裏返す三角形が少ない方に揃えます。頂点と三角形の順番は変えません
+// 範囲外の添字番号を含む三角形はそのまま残します（Repair で取り除けます）
+// 裏返した三角形の数も返します
+func (o Object) UnifyWinding() (Object, int) {
+	m, sources := newHalfEdgeMesh(o)
+	_, flipped := m.orientation()
+
+	triangles := make([][3]int, len(o.Triangles))
+	copy(triangles, o.Triangles)
+	count := 0
+	for f, flip := range flipped {
+		if flip {
+			triangle := &triangles[sources[f]]
+			triangle[1], triangle[2] = triangle[2], triangle[1]
+			count++
+		}
+	}
+
+	o.Triangles = triangles
+	return o, count
+}
+
+// FillHoles は境界の辺がmaxEdges本以下の穴を、穴の縁の頂点を結ぶ三角形で塞ぎます
+// 穴の縁を TriangulatePolygon で分割し、周りの三角形と向きを揃えます。三角形の色は穴の縁の最初の三角形の色です
+// 同じ位置を2回通る穴と、分割できない（面積が0の）穴は塞ぎません。範囲外の添字番号を含む三角形は穴の検出に使わず、そのまま残します
+// 塞ぐ三角形の面積が縁の塊の面積以上になる輪は、穴ではなく開いたメッシュ（平面など）の外周なので塞ぎません
+// 塞いだ穴の数も返します
+func (o Object) FillHoles(maxEdges int) (Object, int) {
+	m, _ := newHalfEdgeMesh(o)
+	// 三角形ごとの繋がった塊の面積
+	componentAreas := make([]float64, m.FaceCount())
+	for _, component := range m.ConnectedComponents() {
+		area := 0.0
+		for _, f := range component {
+			area += m.faceArea(m.Triangle(f))
+		}
+		for _, f := range component {
+			componentAreas[f] = area
+		}
+	}
+
+	triangles := make([][3]int, len(o.Triangles), len(o.Triangles)+maxEdges)
+	copy(triangles, o.Triangles)
+	triangleColors := make([]color.RGBA, 0, len(o.Triangles)+maxEdges)
+	for i := range o.Triangles {
+		triangleColors = append(triangleColors, o.TriangleColor(i))
+	}
+	edges := make([][2]int, len(o.Edges))
+	copy(edges, o.Edges)
+
+	filled := 0
+	for _, loop := range m.boundaryLoopHalfEdges() {
+		if len(loop) < 3 || len(loop) > maxEdges {
+			continue
+		}
+		// 輪が閉じていない（辿れなかった）場合
+		if m.welded[m.Destination(loop[len(loop)-1])] != m.weldedOrigin(loop[0]) {
+			continue
+		}
+
+		// 穴の縁を逆向きに辿ると、塞ぐ三角形が周りの三角形と辺を逆向きに辿る
+		indexes := make([]int, 0, len(loop))
+		positions := make([]Vector3D, 0, len(loop))
+		seen := make(map[int]bool, len(loop))
+		for i := len(loop) - 1; i >= 0; i-- {
+			v := m.HalfEdges[loop[i]].Origin
+			if seen[m.welded[v]] {
+				break
+			}
+			seen[m.welded[v]] = true
+			indexes = append(indexes, v)
+			positions = append(positions, m.Vertices[v])
+		}
+		if len(indexes) != len(loop) {
+			continue
+		}
+
+		pieces := TriangulatePolygon(positions)
+		if len(pieces) != len(loop)-2 {
+			continue
+		}
+		// 外周を塞ぐと、元の三角形と裏表が重なった殻になる
+		fill := make([][3]int, 0, len(pieces))
+		fillArea := 0.0
+		for _, piece := range pieces {
+			triangle := [3]int{indexes[piece[0]], indexes[piece[1]], indexes[piece[2]]}
+			fill = append(fill, triangle)
+			fillArea += m.faceArea(triangle)
+		}
+		if fillArea >= componentAreas[m.HalfEdges[loop[0]].Face]*(1-MergeTolerance) {
+			continue
+		}
+		c := m.TriangleColors[m.HalfEdges[loop[0]].Face]
+		for _, triangle := range fill {
+			triangles = append(triangles, triangle)
+			triangleColors = append(triangleColors, c)
+			edges = append(edges, [2]int{triangle[0], triangle[1]}, [2]int{triangle[1], triangle[2]}, [2]int{triangle[2], triangle[0]})
+		}
+		filled++
+	}
+
+	if filled == 0 {
+		return o, 0
+	}
+	o.Triangles = triangles
+	o.TriangleColors = triangleColors
+	o.Edges = CleanEdges(edges)
+	return o, filled
+}
@@ -0,0 +1,171 @@
+package domain
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// removeTriangles は指定した三角形を色と共に取り除いたオブジェクトを返します
+func removeTriangles(o Object, faces ...int) Object {
+	triangles := make([][3]int, 0, len(o.Triangles))
+	triangleColors := make([]color.RGBA, 0, len(o.Triangles))
+	for i, triangle := range o.Triangles {
+		removed := false
+		for _, f := range faces {
+			removed = removed || f == i
+		}
+		if !removed {
+			triangles = append(triangles, triangle)
+			triangleColors = append(triangleColors, o.TriangleColor(i))
+		}
+	}
+	o.Triangles = triangles
+	o.TriangleColors = triangleColors
+	return o
+}
+
+func TestObject_WeldVertices(t *testing.T) {
+	// 頂点属性が異なる面ごとの頂点はまとめない
+	o := NewCubeObject(1)
+	welded, count := o.WeldVertices()
+	assert.Equal(t, 0, count)
+	assert.Equal(t, o.VertexMatrix.Len(), welded.VertexMatrix.Len())
+
+	o.Attributes = nil
+	welded, count = o.WeldVertices()
+	assert.Equal(t, 16, count)
+	assert.Equal(t, 8, welded.VertexMatrix.Len())
+	assert.Equal(t, o.TriangleColors, welded.TriangleColors)
+	assertClosedMesh(t, welded)
+}
+
+func TestObject_UnifyWinding(t *testing.T) {
+	o := NewIcosphereObject(1, 1, color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 255, 255})
+
+	unified, count := flipTriangles(o, 0, 7, 30).UnifyWinding()
+
+	assert.Equal(t, 3, count)
+	assert.Equal(t, o.Triangles, unified.Triangles)
+	assert.Equal(t, o.TriangleColors, unified.TriangleColors)
+	assertFacesMatchNormals(t, unified)
+
+	// 全て裏返っている閉じた立体は外側に向け直す
+	all := make([]int, len(o.Triangles))
+	for i := range all {
+		all[i] = i
+	}
+	unified, count = flipTriangles(o, all...).UnifyWinding()
+	assert.Equal(t, len(o.Triangles), count)
+	assert.Equal(t, o.Triangles, unified.Triangles)
+}
+
+func TestObject_UnifyWinding_範囲外の添字番号(t *testing.T) {
+	o := NewIcosphereObject(1, 1)
+	invalid := flipTriangles(o, 0)
+	invalid.Triangles = append(invalid.Triangles, [3]int{0, 1, 100}, [3]int{-1, 1, 2})
+
+	// 範囲外の添字番号を含む三角形は向きを調べずにそのまま残す
+	unified, count := invalid.UnifyWinding()
+
+	assert.Equal(t, 1, count)
+	assert.Equal(t, append(o.Triangles, [3]int{0, 1, 100}, [3]int{-1, 1, 2}), unified.Triangles)
+}
+
+func TestObject_FillHoles(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	o := NewIcosphereObject(1, 1, red)
+	// 元の20面体の頂点の周りの5つの三角形を取り除く
+	holed := removeTriangles(o, NewHalfEdgeMesh(o).VertexFaces(0)...)
+	assert.Equal(t, 1, holed.Validate().Count(MeshIssueHole))
+
+	// 上限より辺が多い穴は塞がない
+	notFilled, count := holed.FillHoles(4)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, holed, notFilled)
+
+	filled, count := holed.FillHoles(8)
+	assert.Equal(t, 1, count)
+	assert.Len(t, filled.Triangles, len(o.Triangles)-5+3)
+	assert.Len(t, filled.TriangleColors, len(filled.Triangles))
+	assert.Equal(t, red, filled.TriangleColors[len(filled.TriangleColors)-1])
+	assertWeldedClosedMesh(t, filled)
+	assertConvexOutward(t, filled)
+	assert.True(t, filled.Validate().IsValid())
+	// 塞いだ三角形の辺もEdgesに加える
+	edges := make(map[[2]int]bool)
+	for _, edge := range filled.Edges {
+		edges[weldedEdgeKey(edge[0], edge[1])] = true
+	}
+	for edge := range triangleEdgeSet(filled.Triangles[len(holed.Triangles):]) {
+		assert.True(t, edges[edge])
+	}
+}
+
+func TestObject_FillHoles_開いたメッシュの外周(t *testing.T) {
+	// 2つの三角形の四角形は外周を塞ぐと裏表が重なった殻になる
+	quad := NewPlaneObject(1, 1, color.RGBA{255, 0, 0, 255})
+	filled, count := quad.FillHoles(8)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, quad, filled)
+
+	// 格子状の平面の内側の穴だけを塞ぐ（外周の16本の辺も上限以下）
+	grid := NewGridPlaneObject(1, 1, 4, 4)
+	center := -1
+	grid.VertexMatrix.EachVertex(func(i int, v Vertex) bool {
+		if v.Sub(Vector3D{}).Distance() < 1e-9 {
+			center = i
+		}
+		return center < 0
+	})
+	holed := removeTriangles(grid, NewHalfEdgeMesh(grid).VertexFaces(center)...)
+	assert.Equal(t, 2, holed.Validate().Count(MeshIssueHole))
+
+	filled, count = holed.FillHoles(16)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, 1, filled.Validate().Count(MeshIssueHole))
+	assertFacesMatchNormals(t, filled)
+}
+
+func TestObject_FillHoles_範囲外の添字番号(t *testing.T) {
+	o := NewIcosphereObject(1, 1)
+	holed := removeTriangles(o, NewHalfEdgeMesh(o).VertexFaces(0)...)
+	holed.Triangles = append(holed.Triangles, [3]int{0, 1, 100})
+
+	// 範囲外の添字番号を含む三角形は穴の検出に使わずにそのまま残す
+	filled, count := holed.FillHoles(8)
+
+	assert.Equal(t, 1, count)
+	assert.Len(t, filled.Triangles, len(holed.Triangles)+3)
+	assert.Equal(t, [3]int{0, 1, 100}, filled.Triangles[len(holed.Triangles)-1])
+	assert.Len(t, filled.TriangleColors, len(filled.Triangles))
+}
+
+func TestObject_Repair(t *testing.T) {
+	// 面ごとに頂点が分かれ、裏返った三角形・穴・面積が0の三角形・範囲外の添字番号を含む箱
+	o := NewCubeObject(1)
+	o.Attributes = nil
+	o = removeTriangles(flipTriangles(o, 5, 8), 0, 1)
+	o.Triangles = append(o.Triangles, [3]int{2, 2, 3}, [3]int{0, 1, 100})
+	assert.False(t, o.Validate().IsValid())
+
+	repaired, stats := o.Repair(MeshRepair{Weld: true, RemoveDegenerate: true, UnifyWinding: true, MaxHoleEdges: 4})
+
+	assert.Equal(t, MeshRepairStats{RemovedTriangles: 2, WeldedVertices: 16, FlippedTriangles: 2, FilledHoles: 1}, stats)
+	assert.True(t, repaired.Validate().IsValid(), "%v", repaired.Validate().Issues)
+	assert.Len(t, repaired.Triangles, 12)
+	assertClosedMesh(t, repaired)
+	assertConvexOutward(t, repaired)
+
+	// 頂点のないオブジェクトはそのまま
+	repaired, stats = Object{}.Repair(MeshRepair{Weld: true, RemoveDegenerate: true, UnifyWinding: true, MaxHoleEdges: 4})
+	assert.Equal(t, MeshRepairStats{}, stats)
+	assert.Empty(t, repaired.Triangles)
+	assert.Nil(t, repaired.VertexMatrix.Dense)
+
+	// 何も指定しない場合は範囲外の添字番号を含む三角形だけを取り除く
+	repaired, stats = o.Repair(MeshRepair{})
+	assert.Equal(t, MeshRepairStats{RemovedTriangles: 1}, stats)
+	assert.Len(t, repaired.Triangles, len(o.Triangles)-1)
+}
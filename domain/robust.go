@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"image/color"
+)
+
+const (
+	// MergeTolerance 頂点をまとめる距離の、境界ボックスの対角線の長さに対する比率
+	MergeTolerance = 1e-6
+	// minMergeTolerance 頂点をまとめる距離の下限（大きさが0のオブジェクトでグリッドの添字が溢れないようにする）
+	minMergeTolerance = 1e-12
+)
+
+// MergeTolerance は頂点をまとめる距離（オブジェクトの大きさに比例する許容誤差）を返します
+func (o Object) MergeTolerance() float64 {
+	ok, box := o.BoundingBox()
+	if !ok {
+		return minMergeTolerance
+	}
+	tolerance := box.Max.Sub(box.Min).Distance() * MergeTolerance
+	if !(tolerance > minMergeTolerance) {
+		// 大きさが0のオブジェクトや、座標にNaN・無限大を含むオブジェクト
+		return minMergeTolerance
+	}
+	return tolerance
+}
+
+// RemoveDegenerateTriangles は退化した三角形（IsDegenerateTriangle）と同じ頂点を含む三角形を色と共に取り除きます
+// 取り除いた三角形の数も返します
+func (o Object) RemoveDegenerateTriangles() (Object, int) {
+	triangles := make([][3]int, 0, len(o.Triangles))
+	triangleColors := make([]color.RGBA, 0, len(o.Triangles))
+
+	for i, triangle := range o.Triangles {
+		if triangle[0] == triangle[1] || triangle[1] == triangle[2] || triangle[2] == triangle[0] {
+			continue
+		}
+		if IsDegenerateTriangle([3]Vector3D{
+			o.VertexMatrix.GetVertex(triangle[0]),
+			o.VertexMatrix.GetVertex(triangle[1]),
+			o.VertexMatrix.GetVertex(triangle[2]),
+		}) {
+			continue
+		}
+
+		triangles = append(triangles, triangle)
+		if i < len(o.TriangleColors) {
+			triangleColors = append(triangleColors, o.TriangleColors[i])
+		} else {
+			triangleColors = append(triangleColors, color.RGBA{0, 0, 0, 255}) // デフォルト色
+		}
+	}
+
+	removed := len(o.Triangles) - len(triangles)
+	o.Triangles = triangles
+	o.TriangleColors = triangleColors
+	return o, removed
+}
@@ -0,0 +1,224 @@
+package domain
+
+import (
+	"image/color"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVector3D_Normalize_零ベクトル(t *testing.T) {
+	assert.Equal(t, Vector3D{}, Vector3D{}.Normalize())
+	normalized := NormalizeVecDense(Vector3D{}.Vec())
+	assert.Equal(t, []float64{0, 0, 0}, normalized.RawVector().Data)
+}
+
+func TestClipParameter(t *testing.T) {
+	assert.InDelta(t, 0.25, ClipParameter(1, -3), 1e-9)
+	// 平面と平行な線分は始点を返す
+	assert.Equal(t, 0.0, ClipParameter(1, 1))
+	assert.Equal(t, 0.0, ClipParameter(0, 0))
+	// 誤差で範囲外になった比率は0から1に収める
+	assert.Equal(t, 1.0, ClipParameter(2, 1))
+	assert.Equal(t, 0.0, ClipParameter(math.NaN(), 1))
+}
+
+func TestClassifyEdgeByPlaneWithEpsilon(t *testing.T) {
+	normal := Vector3D{0, 0, 2}
+	point := Vector3D{0, 0, 1}
+
+	// 平面上の点は内側として扱う
+	assert.True(t, ClassifyEdgeByPlaneWithEpsilon(Vector3D{5, 5, 1}, normal, point, ClippingEpsilon))
+	assert.True(t, ClassifyEdgeByPlaneWithEpsilon(Vector3D{5, 5, 1 + 1e-12}, normal, point, ClippingEpsilon))
+	assert.False(t, ClassifyEdgeByPlaneWithEpsilon(Vector3D{5, 5, 1 + 1e-6}, normal, point, ClippingEpsilon))
+	// ClassifyEdgeByPlaneでは平面上の点は外側
+	assert.False(t, ClassifyEdgeByPlane(Vector3D{5, 5, 1}, normal, point))
+}
+
+func TestIsDegenerateTriangle(t *testing.T) {
+	assert.False(t, IsDegenerateTriangle([3]Vector3D{{0, 0, 1}, {1, 0, 1}, {0, 1, 1}}))
+	// 小さくても面積を持つ三角形は退化していない
+	assert.False(t, IsDegenerateTriangle([3]Vector3D{{0, 0, 1}, {1e-8, 0, 1}, {0, 1e-8, 1}}))
+	// 一直線上の頂点
+	assert.True(t, IsDegenerateTriangle([3]Vector3D{{0, 0, 1}, {1, 1, 1}, {2, 2, 1}}))
+	// 同じ位置の頂点
+	assert.True(t, IsDegenerateTriangle([3]Vector3D{{0, 0, 1}, {0, 0, 1}, {0, 0, 1}}))
+	// NaN・無限大を含む
+	assert.True(t, IsDegenerateTriangle([3]Vector3D{{0, 0, 1}, {math.NaN(), 0, 1}, {0, 1, 1}}))
+	assert.True(t, IsDegenerateTriangle([3]Vector3D{{0, 0, 1}, {math.Inf(1), 0, 1}, {0, 1, 1}}))
+}
+
+func TestIntersectRayTriangle_面積0の三角形(t *testing.T) {
+	vertexMatrix := NewVertexMatrix([]Vector3D{{0, 0, 1}, {0, 0, 1}, {0, 0, 1}})
+
+	hit, _ := IntersectRayTriangle(Vector3D{0, 0, 1}, vertexMatrix, [3]int{0, 1, 2})
+
+	assert.False(t, hit)
+}
+
+func TestObject_RemoveDegenerateTriangles(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+	obj := Object{
+		VertexMatrix: NewVertexMatrix([]Vector3D{{0, 0, 1}, {1, 0, 1}, {0, 1, 1}, {2, 0, 1}}),
+		Triangles:    [][3]int{{0, 1, 3}, {0, 2, 1}, {1, 1, 2}},
+		TriangleColors: []color.RGBA{
+			red,
+			blue,
+			red,
+		},
+	}
+
+	result, removed := obj.RemoveDegenerateTriangles()
+
+	assert.Equal(t, 2, removed)
+	assert.Equal(t, [][3]int{{0, 2, 1}}, result.Triangles)
+	assert.Equal(t, []color.RGBA{blue}, result.TriangleColors)
+}
+
+func TestViewVolume_MargeVertices_小さなオブジェクト(t *testing.T) {
+	viewVolume := ViewVolume{}
+	// 1辺が1mmの三角形（固定の許容誤差1e-2では全ての頂点がまとめられてしまう大きさ）
+	obj := Object{
+		VertexMatrix: NewVertexMatrix([]Vector3D{{0, 0, 1}, {0.001, 0, 1}, {0, 0.001, 1}}),
+		Triangles:    [][3]int{{0, 2, 1}},
+	}
+
+	result := viewVolume.MargeVertices(obj)
+
+	assert.Equal(t, 3, result.VertexMatrix.Len())
+	assert.Len(t, result.Triangles, 1)
+}
+
+func TestViewVolume_MargeVertices_三角形と色の対応がずれないこと(t *testing.T) {
+	viewVolume := ViewVolume{}
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+	obj := Object{
+		VertexMatrix: NewVertexMatrix([]Vector3D{{0, 0, 1}, {0, 0, 1}, {1, 0, 1}, {0, 1, 1}}),
+		Triangles: [][3]int{
+			{0, 1, 2}, // 頂点をまとめると潰れる三角形
+			{0, 3, 2},
+		},
+		TriangleColors: []color.RGBA{red, blue},
+	}
+
+	result := viewVolume.MargeVertices(obj)
+
+	assert.Len(t, result.Triangles, 1)
+	assert.Equal(t, []color.RGBA{blue}, result.TriangleColors)
+}
+
+func TestViewVolume_ClipObject_クリップ面上の頂点(t *testing.T) {
+	world := World{
+		Viewport: Viewport{Width: 16, Height: 12},
+		Clipping: Clipping{NearDistance: 0.1, FarDistance: 10.0, FieldOfView: math.Pi / 4},
+	}
+	// 1つの頂点がちょうど前方クリップ面上にあり、残りは内側にある三角形
+	obj := Object{
+		VertexMatrix: NewVertexMatrix([]Vector3D{{0, 0, 0.1}, {0.01, 0, 1}, {0, 0.01, 1}}),
+		Triangles:    [][3]int{{0, 2, 1}},
+	}
+
+	result := world.ViewVolume().ClipObject(obj)
+
+	// 頂点は増えず、潰れた三角形も作られない
+	assert.Equal(t, 3, result.VertexMatrix.Len())
+	assert.Len(t, result.Triangles, 1)
+}
+
+// newTestTriangle は1つの三角形（両面を描画する）だけを持つオブジェクトを置きます
+func newTestTriangle(triangle [3]Vector3D) LocatedObject {
+	return LocatedObject{
+		Scale: Vector3D{1, 1, 1},
+		Object: Object{
+			VertexMatrix:   NewVertexMatrix(triangle[:]),
+			Triangles:      [][3]int{{0, 1, 2}},
+			TriangleColors: []color.RGBA{{255, 0, 0, 255}},
+			CullMode:       CullNone,
+		},
+	}
+}
+
+// assertNoNaN はどちらのパイプラインでもフレームバッファにNaN・無限大が含まれないことを検証します
+func assertNoNaN(t *testing.T, world World) {
+	for _, clipSpace := range []bool{false, true} {
+		world.Clipping.ClipSpace = clipSpace
+		for key, value := range world.Transform() {
+			if math.IsNaN(value.Depth) || math.IsInf(value.Depth, 0) {
+				t.Fatalf("ClipSpace=%v %v: 深度が%vになった %v", clipSpace, key, value.Depth, world.LocatedObjects)
+			}
+			if key.X < 0 || key.X >= world.Viewport.Width || key.Y < 0 || key.Y >= world.Viewport.Height {
+				t.Fatalf("ClipSpace=%v: 画面外の画素 %v", clipSpace, key)
+			}
+		}
+	}
+}
+
+// randomDegenerateTriangle はクリッピングで問題になりやすい三角形をランダムに作ります
+func randomDegenerateTriangle(r *rand.Rand) [3]Vector3D {
+	scales := []float64{1e-9, 1e-4, 1, 1e3, 1e8}
+	scale := scales[r.Intn(len(scales))]
+	randomPoint := func() Vector3D {
+		return Vector3D{
+			(r.Float64()*2 - 1) * scale,
+			(r.Float64()*2 - 1) * scale,
+			(r.Float64()*2-1)*scale + r.Float64()*3,
+		}
+	}
+
+	triangle := [3]Vector3D{randomPoint(), randomPoint(), randomPoint()}
+	switch r.Intn(7) {
+	case 0:
+		// 同じ位置の頂点
+		triangle[1] = triangle[0]
+	case 1:
+		// 一直線上の頂点
+		triangle[2] = triangle[0].Add(triangle[1].Sub(triangle[0]).MulScalar(r.Float64() * 2))
+	case 2:
+		// 前方クリップ面上の頂点
+		for i := range triangle {
+			triangle[i][2] = 0.1
+		}
+	case 3:
+		// カメラの位置（原点）を含む
+		triangle[r.Intn(3)] = Vector3D{}
+	case 4:
+		// カメラから真横に見える三角形
+		for i := range triangle {
+			triangle[i][0] = 0
+		}
+	case 5:
+		// 側面のクリップ面上の頂点
+		for i := range triangle {
+			triangle[i][0] = triangle[i][2] * math.Tan(math.Pi/8) * 8 / 6
+		}
+	}
+	return triangle
+}
+
+func TestWorld_Transform_退化した三角形でNaNが含まれないこと(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		assertNoNaN(t, newTestWorld(8, 6, newTestTriangle(randomDegenerateTriangle(r))))
+	}
+}
+
+func TestWorld_Transform_NaNを含む頂点(t *testing.T) {
+	assertNoNaN(t, newTestWorld(8, 6, newTestTriangle([3]Vector3D{{0, 0, 1}, {math.NaN(), 0, 1}, {0, 1, 1}})))
+	assertNoNaN(t, newTestWorld(8, 6, newTestTriangle([3]Vector3D{{0, 0, 1}, {math.Inf(1), 0, 1}, {0, 1, 1}})))
+}
+
+func FuzzWorld_Transform(f *testing.F) {
+	f.Add(0.0, 0.0, 1.0, 1.0, 0.0, 1.0, 0.0, 1.0, 1.0)
+	f.Add(0.0, 0.0, 0.1, 0.0, 0.0, 0.1, 0.0, 0.0, 0.1)
+	f.Add(-1.0, 0.0, -1.0, 1.0, 0.0, 5.0, 0.0, 1.0, 0.1)
+	f.Add(0.0, 0.0, 0.0, 1e9, 1e9, 1e9, -1e9, 1e9, 20.0)
+	f.Add(0.0, -1.0, 0.1, 0.0, 1.0, 0.1, 0.0, 0.0, 10.0)
+
+	f.Fuzz(func(t *testing.T, x0, y0, z0, x1, y1, z1, x2, y2, z2 float64) {
+		assertNoNaN(t, newTestWorld(8, 6, newTestTriangle([3]Vector3D{{x0, y0, z0}, {x1, y1, z1}, {x2, y2, z2}})))
+	})
+}
@@ -0,0 +1,250 @@
+package domain
+
+import "math"
+
+// RotationOrder はオイラー角（Tait–Bryan角）の回転を適用する順番を表します
+type RotationOrder int
+
+const (
+	// RotationOrderZYX Z軸 -> Y軸 -> X軸の順で回転（既定値。TransformRotateと同じ）
+	RotationOrderZYX RotationOrder = iota
+	// RotationOrderXYZ X軸 -> Y軸 -> Z軸の順で回転
+	RotationOrderXYZ
+	// RotationOrderXZY X軸 -> Z軸 -> Y軸の順で回転
+	RotationOrderXZY
+	// RotationOrderYXZ Y軸 -> X軸 -> Z軸の順で回転
+	RotationOrderYXZ
+	// RotationOrderYZX Y軸 -> Z軸 -> X軸の順で回転
+	RotationOrderYZX
+	// RotationOrderZXY Z軸 -> X軸 -> Y軸の順で回転
+	RotationOrderZXY
+)
+
+// RotationOrders は全ての回転順を返します
+func RotationOrders() []RotationOrder {
+	return []RotationOrder{RotationOrderZYX, RotationOrderXYZ, RotationOrderXZY, RotationOrderYXZ, RotationOrderYZX, RotationOrderZXY}
+}
+
+// Axes は回転を適用する軸の添字（0:X, 1:Y, 2:Z）を適用する順に返します
+func (o RotationOrder) Axes() [3]int {
+	switch o {
+	case RotationOrderXYZ:
+		return [3]int{0, 1, 2}
+	case RotationOrderXZY:
+		return [3]int{0, 2, 1}
+	case RotationOrderYXZ:
+		return [3]int{1, 0, 2}
+	case RotationOrderYZX:
+		return [3]int{1, 2, 0}
+	case RotationOrderZXY:
+		return [3]int{2, 0, 1}
+	}
+	return [3]int{2, 1, 0}
+}
+
+// NewAxisRotateMatrix4 は1つの軸（0:X, 1:Y, 2:Z）周りの回転行列を返します
+func NewAxisRotateMatrix4(axis int, angle float64) Matrix4 {
+	switch axis {
+	case 0:
+		return NewRotateMatrix4(angle, 0, 0)
+	case 1:
+		return NewRotateMatrix4(0, angle, 0)
+	}
+	return NewRotateMatrix4(0, 0, angle)
+}
+
+// EulerToMatrix4 はオイラー角を回転行列に変換します
+// rotationはX軸、Y軸、Z軸それぞれの回転角度（ラジアン）で、orderの順に適用します
+func EulerToMatrix4(rotation Vector3D, order RotationOrder) Matrix4 {
+	axes := order.Axes()
+	m := NewIdentityMatrix4()
+	for _, axis := range axes {
+		// 後から適用する回転を左から掛ける
+		m = NewAxisRotateMatrix4(axis, rotation[axis]).Mul(m)
+	}
+	return m
+}
+
+// Matrix4ToEuler は回転行列をorderの順に適用するオイラー角に変換します
+// ジンバルロック（2番目の軸の回転が±90度）の場合は、3番目の軸の回転を0とします
+func Matrix4ToEuler(m Matrix4, order RotationOrder) Vector3D {
+	axes := order.Axes()
+	i, j, k := axes[0], axes[1], axes[2]
+
+	// 軸の並びが X→Y→Z の巡回置換なら1、そうでなければ-1
+	sign := 1.0
+	if (j-i+3)%3 != 1 {
+		sign = -1.0
+	}
+
+	var result Vector3D
+	sinJ := math.Max(-1, math.Min(1, -sign*m[k][i]))
+	result[j] = math.Asin(sinJ)
+	if math.Abs(sinJ) < 1-1e-9 {
+		result[i] = math.Atan2(sign*m[k][j], m[k][k])
+		result[k] = math.Atan2(sign*m[j][i], m[i][i])
+	} else {
+		// ジンバルロック：2番目の軸は1番目の軸の回転の影響を受けないため、その行から1番目の回転を求める
+		result[i] = math.Atan2(-sign*m[j][k], m[j][j])
+		result[k] = 0
+	}
+	return result
+}
+
+// ConvertEulerOrder はオイラー角を別の回転順で同じ回転を表すオイラー角に変換します
+func ConvertEulerOrder(rotation Vector3D, from, to RotationOrder) Vector3D {
+	return Matrix4ToEuler(EulerToMatrix4(rotation, from), to)
+}
+
+// AxisAngle は回転軸と回転角度による回転を表します
+type AxisAngle struct {
+	// Axis 回転軸（正規化されていなくてもよい）
+	Axis Vector3D
+	// Angle 回転角度（ラジアン）
+	Angle float64
+}
+
+// Quaternion は回転を表す単位四元数です
+type Quaternion struct {
+	W, X, Y, Z float64
+}
+
+// NewIdentityQuaternion は回転しないことを表す四元数を返します
+func NewIdentityQuaternion() Quaternion {
+	return Quaternion{W: 1}
+}
+
+// Quaternion は軸と角度による回転を四元数に変換します
+func (a AxisAngle) Quaternion() Quaternion {
+	axis := a.Axis.Normalize()
+	s := math.Sin(a.Angle / 2)
+	return Quaternion{
+		W: math.Cos(a.Angle / 2),
+		X: axis.X() * s,
+		Y: axis.Y() * s,
+		Z: axis.Z() * s,
+	}
+}
+
+// Matrix4 は軸と角度による回転を回転行列に変換します
+func (a AxisAngle) Matrix4() Matrix4 {
+	return a.Quaternion().Matrix4()
+}
+
+// Mul は四元数の積 q × r を返します
+// 回転としては r を適用した後に q を適用したことになります
+func (q Quaternion) Mul(r Quaternion) Quaternion {
+	return Quaternion{
+		W: q.W*r.W - q.X*r.X - q.Y*r.Y - q.Z*r.Z,
+		X: q.W*r.X + q.X*r.W + q.Y*r.Z - q.Z*r.Y,
+		Y: q.W*r.Y - q.X*r.Z + q.Y*r.W + q.Z*r.X,
+		Z: q.W*r.Z + q.X*r.Y - q.Y*r.X + q.Z*r.W,
+	}
+}
+
+// Conjugate は共役四元数（逆回転）を返します
+func (q Quaternion) Conjugate() Quaternion {
+	return Quaternion{W: q.W, X: -q.X, Y: -q.Y, Z: -q.Z}
+}
+
+// Dot は四元数の内積を返します
+func (q Quaternion) Dot(r Quaternion) float64 {
+	return q.W*r.W + q.X*r.X + q.Y*r.Y + q.Z*r.Z
+}
+
+// Normalize は正規化した四元数を返します
+func (q Quaternion) Normalize() Quaternion {
+	norm := math.Sqrt(q.Dot(q))
+	return Quaternion{W: q.W / norm, X: q.X / norm, Y: q.Y / norm, Z: q.Z / norm}
+}
+
+// Rotate はベクトルを回転します
+func (q Quaternion) Rotate(v Vector3D) Vector3D {
+	p := q.Mul(Quaternion{X: v.X(), Y: v.Y(), Z: v.Z()}).Mul(q.Conjugate())
+	return Vector3D{p.X, p.Y, p.Z}
+}
+
+// AxisAngle は四元数を回転軸と回転角度に変換します
+// 回転しない場合は軸をX軸とします
+func (q Quaternion) AxisAngle() AxisAngle {
+	q = q.Normalize()
+	if q.W < 0 {
+		// 回転角度を0〜πの範囲にする
+		q = Quaternion{W: -q.W, X: -q.X, Y: -q.Y, Z: -q.Z}
+	}
+	s := math.Sqrt(q.X*q.X + q.Y*q.Y + q.Z*q.Z)
+	if s < 1e-12 {
+		return AxisAngle{Axis: Vector3D{1, 0, 0}, Angle: 0}
+	}
+	return AxisAngle{
+		Axis:  Vector3D{q.X / s, q.Y / s, q.Z / s},
+		Angle: 2 * math.Atan2(s, q.W),
+	}
+}
+
+// Matrix4 は四元数を回転行列に変換します
+func (q Quaternion) Matrix4() Matrix4 {
+	q = q.Normalize()
+	w, x, y, z := q.W, q.X, q.Y, q.Z
+	return Matrix4{
+		{1 - 2*(y*y+z*z), 2 * (x*y - z*w), 2 * (x*z + y*w), 0},
+		{2 * (x*y + z*w), 1 - 2*(x*x+z*z), 2 * (y*z - x*w), 0},
+		{2 * (x*z - y*w), 2 * (y*z + x*w), 1 - 2*(x*x+y*y), 0},
+		{0, 0, 0, 1},
+	}
+}
+
+// Euler は四元数をorderの順に適用するオイラー角に変換します
+func (q Quaternion) Euler(order RotationOrder) Vector3D {
+	return Matrix4ToEuler(q.Matrix4(), order)
+}
+
+// QuaternionFromMatrix4 は回転行列を四元数に変換します
+func QuaternionFromMatrix4(m Matrix4) Quaternion {
+	trace := m[0][0] + m[1][1] + m[2][2]
+	var q Quaternion
+	if trace > 0 {
+		s := math.Sqrt(trace+1) * 2
+		q = Quaternion{W: s / 4, X: (m[2][1] - m[1][2]) / s, Y: (m[0][2] - m[2][0]) / s, Z: (m[1][0] - m[0][1]) / s}
+	} else if m[0][0] > m[1][1] && m[0][0] > m[2][2] {
+		s := math.Sqrt(1+m[0][0]-m[1][1]-m[2][2]) * 2
+		q = Quaternion{W: (m[2][1] - m[1][2]) / s, X: s / 4, Y: (m[0][1] + m[1][0]) / s, Z: (m[0][2] + m[2][0]) / s}
+	} else if m[1][1] > m[2][2] {
+		s := math.Sqrt(1+m[1][1]-m[0][0]-m[2][2]) * 2
+		q = Quaternion{W: (m[0][2] - m[2][0]) / s, X: (m[0][1] + m[1][0]) / s, Y: s / 4, Z: (m[1][2] + m[2][1]) / s}
+	} else {
+		s := math.Sqrt(1+m[2][2]-m[0][0]-m[1][1]) * 2
+		q = Quaternion{W: (m[1][0] - m[0][1]) / s, X: (m[0][2] + m[2][0]) / s, Y: (m[1][2] + m[2][1]) / s, Z: s / 4}
+	}
+	return q.Normalize()
+}
+
+// QuaternionFromEuler はオイラー角をorderの順に適用する回転を四元数に変換します
+func QuaternionFromEuler(rotation Vector3D, order RotationOrder) Quaternion {
+	q := NewIdentityQuaternion()
+	for _, axis := range order.Axes() {
+		var axisVector Vector3D
+		axisVector[axis] = 1
+		// 後から適用する回転を左から掛ける
+		q = AxisAngle{Axis: axisVector, Angle: rotation[axis]}.Quaternion().Mul(q)
+	}
+	return q
+}
+
+// RotationMatrix はオブジェクトの回転行列を返します
+// Quaternionが指定されている場合はそちらを優先し、そうでなければRotationをRotationOrderの順に適用します
+func (l LocatedObject) RotationMatrix() Matrix4 {
+	if l.Quaternion != nil {
+		return l.Quaternion.Matrix4()
+	}
+	return EulerToMatrix4(l.Rotation, l.RotationOrder)
+}
+
+// RotationMatrix はノードの回転行列を返します
+// Quaternionが指定されている場合はそちらを優先し、そうでなければRotationをRotationOrderの順に適用します
+func (n SceneNode) RotationMatrix() Matrix4 {
+	if n.Quaternion != nil {
+		return n.Quaternion.Matrix4()
+	}
+	return EulerToMatrix4(n.Rotation, n.RotationOrder)
+}
@@ -0,0 +1,195 @@
+package domain
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// assertSameRotation は2つの回転行列が同じ回転を表すことを検証します
+func assertSameRotation(t *testing.T, expected, actual Matrix4) {
+	t.Helper()
+	assertMatrix4InDelta(t, expected, actual, 1e-9)
+}
+
+// assertSameQuaternion は2つの四元数が同じ回転を表すことを検証します（qと-qは同じ回転）
+func assertSameQuaternion(t *testing.T, expected, actual Quaternion) {
+	t.Helper()
+	assert.InDelta(t, 1.0, math.Abs(expected.Dot(actual)), 1e-9)
+}
+
+func TestEulerToMatrix4_既定の回転順はNewRotateMatrix4と同じになること(t *testing.T) {
+	rotation := Vector3D{0.3, -0.7, 1.1}
+
+	result := EulerToMatrix4(rotation, RotationOrderZYX)
+
+	assertSameRotation(t, NewRotateMatrix4(rotation.X(), rotation.Y(), rotation.Z()), result)
+}
+
+func TestEulerToMatrix4_回転順(t *testing.T) {
+	// X軸周りに90度回転した後にY軸周りに90度回転すると、(0,1,0)は(0,0,1)を経て(1,0,0)になる
+	m := EulerToMatrix4(Vector3D{math.Pi / 2, math.Pi / 2, 0}, RotationOrderXYZ)
+	result := m.MulPoint(Vector3D{0, 1, 0})
+	assert.InDelta(t, 1.0, result.X(), 1e-9)
+	assert.InDelta(t, 0.0, result.Y(), 1e-9)
+	assert.InDelta(t, 0.0, result.Z(), 1e-9)
+
+	// Y軸周りに先に回転すると、(0,1,0)はY軸回転では変わらず、X軸回転で(0,0,1)になる
+	m = EulerToMatrix4(Vector3D{math.Pi / 2, math.Pi / 2, 0}, RotationOrderYXZ)
+	result = m.MulPoint(Vector3D{0, 1, 0})
+	assert.InDelta(t, 0.0, result.X(), 1e-9)
+	assert.InDelta(t, 0.0, result.Y(), 1e-9)
+	assert.InDelta(t, 1.0, result.Z(), 1e-9)
+}
+
+func TestMatrix4ToEuler_往復変換(t *testing.T) {
+	rotation := Vector3D{0.3, -0.7, 1.1}
+	for _, order := range RotationOrders() {
+		m := EulerToMatrix4(rotation, order)
+
+		result := Matrix4ToEuler(m, order)
+
+		assert.InDelta(t, rotation.X(), result.X(), 1e-9, "order=%d", order)
+		assert.InDelta(t, rotation.Y(), result.Y(), 1e-9, "order=%d", order)
+		assert.InDelta(t, rotation.Z(), result.Z(), 1e-9, "order=%d", order)
+	}
+}
+
+func TestMatrix4ToEuler_ジンバルロック(t *testing.T) {
+	for _, order := range RotationOrders() {
+		// 2番目に適用する軸の回転を90度にする
+		var rotation Vector3D
+		axes := order.Axes()
+		rotation[axes[0]] = 0.4
+		rotation[axes[1]] = math.Pi / 2
+		m := EulerToMatrix4(rotation, order)
+
+		result := Matrix4ToEuler(m, order)
+
+		// 角度の組み合わせは一意でないため、同じ回転になることを検証する
+		assertSameRotation(t, m, EulerToMatrix4(result, order))
+	}
+}
+
+func TestConvertEulerOrder(t *testing.T) {
+	rotation := Vector3D{0.3, -0.7, 1.1}
+	for _, from := range RotationOrders() {
+		for _, to := range RotationOrders() {
+			result := ConvertEulerOrder(rotation, from, to)
+
+			assertSameRotation(t, EulerToMatrix4(rotation, from), EulerToMatrix4(result, to))
+		}
+	}
+}
+
+func TestAxisAngle_Quaternion(t *testing.T) {
+	// Z軸周りに90度回転すると(1,0,0)は(0,1,0)になる
+	q := AxisAngle{Axis: Vector3D{0, 0, 2}, Angle: math.Pi / 2}.Quaternion()
+
+	result := q.Rotate(Vector3D{1, 0, 0})
+
+	assert.InDelta(t, 0.0, result.X(), 1e-9)
+	assert.InDelta(t, 1.0, result.Y(), 1e-9)
+	assert.InDelta(t, 0.0, result.Z(), 1e-9)
+}
+
+func TestAxisAngle_Matrix4(t *testing.T) {
+	m := AxisAngle{Axis: Vector3D{1, 0, 0}, Angle: 0.5}.Matrix4()
+
+	assertSameRotation(t, NewRotateMatrix4(0.5, 0, 0), m)
+}
+
+func TestQuaternion_AxisAngle_往復変換(t *testing.T) {
+	axisAngle := AxisAngle{Axis: Vector3D{1, -2, 3}.Normalize(), Angle: 2.5}
+
+	result := axisAngle.Quaternion().AxisAngle()
+
+	assert.InDelta(t, axisAngle.Angle, result.Angle, 1e-9)
+	for axis := 0; axis < 3; axis++ {
+		assert.InDelta(t, axisAngle.Axis[axis], result.Axis[axis], 1e-9)
+	}
+}
+
+func TestQuaternion_AxisAngle_回転しない場合(t *testing.T) {
+	result := NewIdentityQuaternion().AxisAngle()
+
+	assert.Equal(t, 0.0, result.Angle)
+	assert.Equal(t, Vector3D{1, 0, 0}, result.Axis)
+}
+
+func TestQuaternion_Matrix4_往復変換(t *testing.T) {
+	// 対角成分の大小で分岐するため、いくつかの回転で検証する
+	for _, axisAngle := range []AxisAngle{
+		{Axis: Vector3D{1, -2, 3}, Angle: 0.5},
+		{Axis: Vector3D{1, 0, 0}, Angle: 3.0},
+		{Axis: Vector3D{0, 1, 0}, Angle: 3.0},
+		{Axis: Vector3D{0, 0, 1}, Angle: 3.0},
+	} {
+		q := axisAngle.Quaternion()
+
+		result := QuaternionFromMatrix4(q.Matrix4())
+
+		assertSameQuaternion(t, q, result)
+	}
+}
+
+func TestQuaternion_Mul(t *testing.T) {
+	qx := AxisAngle{Axis: Vector3D{1, 0, 0}, Angle: 0.3}.Quaternion()
+	qy := AxisAngle{Axis: Vector3D{0, 1, 0}, Angle: 0.7}.Quaternion()
+
+	// 四元数の積と回転行列の積は同じ順番で合成される
+	assertSameRotation(t, qy.Matrix4().Mul(qx.Matrix4()), qy.Mul(qx).Matrix4())
+}
+
+func TestQuaternionFromEuler_往復変換(t *testing.T) {
+	rotation := Vector3D{0.3, -0.7, 1.1}
+	for _, order := range RotationOrders() {
+		q := QuaternionFromEuler(rotation, order)
+
+		assertSameRotation(t, EulerToMatrix4(rotation, order), q.Matrix4())
+
+		result := q.Euler(order)
+		assert.InDelta(t, rotation.X(), result.X(), 1e-9, "order=%d", order)
+		assert.InDelta(t, rotation.Y(), result.Y(), 1e-9, "order=%d", order)
+		assert.InDelta(t, rotation.Z(), result.Z(), 1e-9, "order=%d", order)
+	}
+}
+
+func TestLocatedObject_RotationMatrix(t *testing.T) {
+	rotation := Vector3D{0.3, -0.7, 1.1}
+
+	// 既定ではZ→Y→X軸の順
+	assertSameRotation(t, NewRotateMatrix4(0.3, -0.7, 1.1), LocatedObject{Rotation: rotation}.RotationMatrix())
+
+	// 回転順を指定した場合
+	assertSameRotation(t,
+		EulerToMatrix4(rotation, RotationOrderXYZ),
+		LocatedObject{Rotation: rotation, RotationOrder: RotationOrderXYZ}.RotationMatrix())
+
+	// 四元数を指定した場合はRotationより優先する
+	q := AxisAngle{Axis: Vector3D{0, 1, 0}, Angle: 0.5}.Quaternion()
+	assertSameRotation(t,
+		NewRotateMatrix4(0, 0.5, 0),
+		LocatedObject{Rotation: rotation, Quaternion: &q}.RotationMatrix())
+}
+
+func TestWorld_TransformToCameraSpace_回転順(t *testing.T) {
+	world := World{}
+	locatedObj := LocatedObject{
+		Scale:         Vector3D{1, 1, 1},
+		Rotation:      Vector3D{math.Pi / 2, math.Pi / 2, 0},
+		RotationOrder: RotationOrderXYZ,
+		Object: Object{
+			VertexMatrix:   NewVertexMatrix([]Vector3D{{0, 1, 0}}),
+			TriangleColors: []color.RGBA{},
+		},
+	}
+
+	result := world.TransformToCameraSpace(locatedObj)
+
+	assert.InDelta(t, 1.0, result.VertexMatrix.GetVertex(0).X(), 1e-9)
+	assert.InDelta(t, 0.0, result.VertexMatrix.GetVertex(0).Y(), 1e-9)
+	assert.InDelta(t, 0.0, result.VertexMatrix.GetVertex(0).Z(), 1e-9)
+}
@@ -0,0 +1,147 @@
+package domain
+
+// SceneNode はシーングラフのノードを表します
+// Location, Scale, Rotation は親ノードを基準としたローカルな変換です
+type SceneNode struct {
+	Name     string
+	Location Vector3D
+	Scale    Vector3D
+	Rotation Vector3D
+	// RotationOrder Rotationを適用する順番（既定値はZ→Y→X軸の順）
+	RotationOrder RotationOrder
+	// Quaternion 四元数による回転。指定した場合はRotationより優先する
+	Quaternion *Quaternion
+	// Object ノードに配置するオブジェクト。nilの場合は変換のみを持つノードになる
+	Object   *Object
+	Children []SceneNode
+	// Animation 時間によって変化するLocation/Rotation/Scale。World.Atで評価する
+	Animation *ObjectAnimation
+}
+
+// LocalMatrix はノードのローカルな変換行列を返します
+// LocatedObjectと同じく 拡大・縮小 → 回転 → 平行移動 の順に適用されます
+func (n SceneNode) LocalMatrix() Matrix4 {
+	return FromTRSMatrix(n.Location, n.RotationMatrix(), n.Scale)
+}
+
+// Walk はノードとその子孫を深さ優先で辿り、ワールド座標系への変換行列とともにfを呼び出します
+// parentMatrixは親ノードのワールド座標系への変換行列です
+func (n SceneNode) Walk(parentMatrix Matrix4, f func(node SceneNode, worldMatrix Matrix4)) {
+	worldMatrix := parentMatrix.Mul(n.LocalMatrix())
+
+	f(n, worldMatrix)
+
+	for _, child := range n.Children {
+		child.Walk(worldMatrix, f)
+	}
+}
+
+// WalkScene はシーングラフの全ノードを深さ優先で辿ります
+func (w World) WalkScene(f func(node SceneNode, worldMatrix Matrix4)) {
+	for _, node := range w.Scene {
+		node.Walk(NewIdentityMatrix4(), f)
+	}
+}
+
+// FindByName は名前が一致するノードを自身と子孫から探します
+// 見つからない場合はnilを返します
+func (n *SceneNode) FindByName(name string) *SceneNode {
+	if n.Name == name {
+		return n
+	}
+	for i := range n.Children {
+		if found := n.Children[i].FindByName(name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// FindNode は名前が一致するノードをシーングラフから探します
+// 返されたノードを変更するとシーングラフに反映されます
+// 見つからない場合はnilを返します
+func (w *World) FindNode(name string) *SceneNode {
+	for i := range w.Scene {
+		if found := w.Scene[i].FindByName(name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// NodeWorldMatrix は名前が一致するノードのワールド座標系への変換行列を返します
+// 見つからない場合はfalseを返します
+func (w World) NodeWorldMatrix(name string) (bool, Matrix4) {
+	found := false
+	result := Matrix4{}
+	w.WalkScene(func(node SceneNode, worldMatrix Matrix4) {
+		if !found && node.Name == name {
+			found = true
+			result = worldMatrix
+		}
+	})
+	return found, result
+}
+
+// WorldBounds はノードとその子孫のオブジェクト全体を囲む、ワールド座標系の軸平行境界ボックスを返します
+// parentMatrixは親ノードのワールド座標系への変換行列です
+// オブジェクトを1つも持たない場合はfalseを返します
+func (n SceneNode) WorldBounds(parentMatrix Matrix4) (bool, Vector3D, Vector3D) {
+	found := false
+	bounds := BoundingBox{}
+
+	n.Walk(parentMatrix, func(node SceneNode, worldMatrix Matrix4) {
+		if node.Object == nil {
+			return
+		}
+		obj := *node.Object
+		if ok, _ := obj.BoundingBox(); !ok {
+			return
+		}
+		obj.VertexMatrix.TransformMatrix4(worldMatrix)
+		_, box := obj.BoundingBox()
+		if found {
+			bounds = bounds.Union(box)
+		} else {
+			found, bounds = true, box
+		}
+	})
+
+	if !found {
+		return false, Vector3D{}, Vector3D{}
+	}
+	return true, bounds.Min, bounds.Max
+}
+
+// NodeWorldBounds は名前が一致するノードとその子孫の、ワールド座標系の軸平行境界ボックスを返します
+// ノードが見つからない場合やオブジェクトを1つも持たない場合はfalseを返します
+func (w World) NodeWorldBounds(name string) (bool, Vector3D, Vector3D) {
+	var walk func(node SceneNode, parentMatrix Matrix4) (bool, bool, Vector3D, Vector3D)
+	walk = func(node SceneNode, parentMatrix Matrix4) (bool, bool, Vector3D, Vector3D) {
+		if node.Name == name {
+			ok, min, max := node.WorldBounds(parentMatrix)
+			return true, ok, min, max
+		}
+		worldMatrix := parentMatrix.Mul(node.LocalMatrix())
+		for _, child := range node.Children {
+			if matched, ok, min, max := walk(child, worldMatrix); matched {
+				return true, ok, min, max
+			}
+		}
+		return false, false, Vector3D{}, Vector3D{}
+	}
+
+	for _, node := range w.Scene {
+		if matched, ok, min, max := walk(node, NewIdentityMatrix4()); matched {
+			return ok, min, max
+		}
+	}
+	return false, Vector3D{}, Vector3D{}
+}
+
+// SceneBounds はシーングラフ全体の、ワールド座標系の軸平行境界ボックスを返します
+// オブジェクトを1つも持たない場合はfalseを返します
+func (w World) SceneBounds() (bool, Vector3D, Vector3D) {
+	root := SceneNode{Scale: Vector3D{1, 1, 1}, Children: w.Scene}
+	return root.WorldBounds(NewIdentityMatrix4())
+}
@@ -0,0 +1,169 @@
+package domain
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newCarScene() []SceneNode {
+	wheel := NewPlaneObject(0.2, 0.2, color.RGBA{0, 0, 0, 255})
+	body := NewPlaneObject(1.0, 0.5, color.RGBA{255, 0, 0, 255})
+	return []SceneNode{
+		{
+			Name:     "car",
+			Location: Vector3D{10, 0, 0},
+			Scale:    Vector3D{1, 1, 1},
+			Rotation: Vector3D{0, 0, math.Pi / 2},
+			Object:   &body,
+			Children: []SceneNode{
+				{
+					Name:     "wheel",
+					Location: Vector3D{1, 0, 0},
+					Scale:    Vector3D{2, 2, 2},
+					Object:   &wheel,
+				},
+			},
+		},
+	}
+}
+
+func TestSceneNode_LocalMatrix(t *testing.T) {
+	node := SceneNode{
+		Location: Vector3D{1, 2, 3},
+		Scale:    Vector3D{2, 2, 2},
+		Rotation: Vector3D{0, 0, math.Pi / 2},
+	}
+
+	m := node.LocalMatrix()
+
+	vm := NewVertexMatrix([]Vector3D{{1, 0, 0}})
+	vm.TransformMatrix4(m)
+
+	// 拡大 → Z軸回転 → 平行移動の順に適用される
+	assert.InDelta(t, 1.0, vm.GetVertex(0).X(), 1e-9)
+	assert.InDelta(t, 4.0, vm.GetVertex(0).Y(), 1e-9)
+	assert.InDelta(t, 3.0, vm.GetVertex(0).Z(), 1e-9)
+}
+
+func TestSceneNode_Walk_子ノードは親ノードの変換を引き継ぐこと(t *testing.T) {
+	scene := newCarScene()
+
+	worldMatrices := map[string]Matrix4{}
+	scene[0].Walk(NewIdentityMatrix4(), func(node SceneNode, worldMatrix Matrix4) {
+		worldMatrices[node.Name] = worldMatrix
+	})
+
+	assert.Len(t, worldMatrices, 2)
+
+	// 車輪の原点は、車の原点から車のローカル座標で(1,0,0)の位置
+	// 車はZ軸周りに90度回転しているので、ワールド座標では(10,1,0)になる
+	wheelMatrix := worldMatrices["wheel"]
+	vm := NewVertexMatrix([]Vector3D{{0, 0, 0}, {0.1, 0, 0}})
+	vm.TransformMatrix4(wheelMatrix)
+	assert.InDelta(t, 10.0, vm.GetVertex(0).X(), 1e-9)
+	assert.InDelta(t, 1.0, vm.GetVertex(0).Y(), 1e-9)
+	assert.InDelta(t, 0.0, vm.GetVertex(0).Z(), 1e-9)
+	// 車輪の拡大率も適用される
+	assert.InDelta(t, 10.0, vm.GetVertex(1).X(), 1e-9)
+	assert.InDelta(t, 1.2, vm.GetVertex(1).Y(), 1e-9)
+}
+
+func TestWorld_FindNode(t *testing.T) {
+	world := World{Scene: newCarScene()}
+
+	wheel := world.FindNode("wheel")
+	assert.NotNil(t, wheel)
+	assert.Equal(t, "wheel", wheel.Name)
+
+	// 返されたノードの変更はシーングラフに反映される
+	wheel.Location = Vector3D{2, 0, 0}
+	assert.Equal(t, Vector3D{2, 0, 0}, world.Scene[0].Children[0].Location)
+
+	assert.Nil(t, world.FindNode("missing"))
+}
+
+func TestWorld_NodeWorldMatrix(t *testing.T) {
+	world := World{Scene: newCarScene()}
+
+	ok, m := world.NodeWorldMatrix("wheel")
+	assert.True(t, ok)
+	vm := NewVertexMatrix([]Vector3D{{0, 0, 0}})
+	vm.TransformMatrix4(m)
+	assert.InDelta(t, 10.0, vm.GetVertex(0).X(), 1e-9)
+	assert.InDelta(t, 1.0, vm.GetVertex(0).Y(), 1e-9)
+
+	ok, _ = world.NodeWorldMatrix("missing")
+	assert.False(t, ok)
+}
+
+func TestWorld_NodeWorldBounds(t *testing.T) {
+	world := World{Scene: newCarScene()}
+
+	// 車輪：0.2x0.2の板を2倍に拡大し、車と一緒に90度回転したもの
+	ok, min, max := world.NodeWorldBounds("wheel")
+	assert.True(t, ok)
+	assert.InDelta(t, 9.8, min.X(), 1e-9)
+	assert.InDelta(t, 0.8, min.Y(), 1e-9)
+	assert.InDelta(t, 10.2, max.X(), 1e-9)
+	assert.InDelta(t, 1.2, max.Y(), 1e-9)
+
+	// 車：1.0x0.5の板を90度回転したものと車輪を合わせた範囲
+	ok, min, max = world.NodeWorldBounds("car")
+	assert.True(t, ok)
+	assert.InDelta(t, 9.75, min.X(), 1e-9)
+	assert.InDelta(t, -0.5, min.Y(), 1e-9)
+	assert.InDelta(t, 10.25, max.X(), 1e-9)
+	assert.InDelta(t, 1.2, max.Y(), 1e-9)
+
+	ok, _, _ = world.NodeWorldBounds("missing")
+	assert.False(t, ok)
+}
+
+func TestWorld_SceneBounds(t *testing.T) {
+	world := World{Scene: newCarScene()}
+
+	ok, min, max := world.SceneBounds()
+	assert.True(t, ok)
+	assert.InDelta(t, 9.75, min.X(), 1e-9)
+	assert.InDelta(t, 1.2, max.Y(), 1e-9)
+
+	ok, _, _ = World{}.SceneBounds()
+	assert.False(t, ok)
+}
+
+func TestWorld_Transform_シーングラフのオブジェクトが描画されること(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	plane := NewPlaneObject(0.3, 0.3, red)
+	world := World{
+		Scene: []SceneNode{
+			{
+				Name:     "parent",
+				Location: Vector3D{0, 0, 2},
+				Scale:    Vector3D{1, 1, 1},
+				Children: []SceneNode{
+					{
+						Name:   "child",
+						Scale:  Vector3D{1, 1, 1},
+						Object: &plane,
+					},
+				},
+			},
+		},
+		Viewport: Viewport{
+			Width:  20,
+			Height: 20,
+		},
+		Clipping: Clipping{
+			NearDistance: 0.1,
+			FarDistance:  10.0,
+			FieldOfView:  math.Pi / 4,
+		},
+	}
+
+	frameBuffer := world.Transform()
+
+	assert.Equal(t, red, frameBuffer[FrameBufferKey{X: 10, Y: 10}].Color)
+}
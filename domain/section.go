@@ -0,0 +1,351 @@
+package domain
+
+import (
+	"image/color"
+	"math"
+)
+
+// ClipPlane はワールド座標系の任意の切断面を表します（断面図に使います）
+// 法線（Normal）の向きの側を取り除き、反対側を残します
+type ClipPlane struct {
+	// Point 切断面上の任意の点
+	Point Vector3D
+	// Normal 切断面の法線。取り除く側を向ける
+	Normal Vector3D
+	// CapColor 断面を塗りつぶす色
+	// nilの場合は塗りつぶさず、切り口から内側が見える
+	CapColor *color.RGBA
+}
+
+// Distance は点から切断面までの符号付き距離を返します
+// 取り除く側が正、残す側が負になります
+func (p ClipPlane) Distance(v Vector3D) float64 {
+	return PlaneSignedDistance(v, p.Normal, p.Point)
+}
+
+// SectionObject はオブジェクトを全ての切断面（ClipPlanes）で切断し、ワールド座標系のオブジェクトとして返します
+// modelMatrixはオブジェクトのローカル座標系からワールド座標系への変換行列です
+// 頂点を持たないオブジェクトはそのまま返します
+func (w World) SectionObject(o Object, modelMatrix Matrix4) Object {
+	if ok, _ := o.BoundingBox(); !ok {
+		return o
+	}
+	o.VertexMatrix.TransformMatrix4(modelMatrix)
+	for _, plane := range w.ClipPlanes {
+		o = plane.SectionObject(o)
+	}
+	return o
+}
+
+// SectionObject はオブジェクトを切断面で切断します
+// 切断面をまたぐ三角形はClassifyEdgeByPlaneWithEpsilonとIntersectPlaneParameterで切断し、頂点属性も補間します
+// CapColorを指定した場合は、閉じた立体の切り口を塞ぐ三角形を追加します
+// 全ての頂点が取り除く側にある場合は頂点を持たない空のオブジェクトを返します（頂点を持たないオブジェクトはそのまま返します）
+func (p ClipPlane) SectionObject(o Object) Object {
+	if ok, _ := o.BoundingBox(); !ok {
+		return o
+	}
+
+	inside := make([]bool, o.VertexMatrix.Len())
+	insideCount := 0
+	o.VertexMatrix.EachVertex(func(i int, v Vertex) bool {
+		// 切断面上の頂点は残す側として扱う
+		inside[i] = ClassifyEdgeByPlaneWithEpsilon(v, p.Normal, p.Point, ClippingEpsilon)
+		if inside[i] {
+			insideCount++
+		}
+		return true
+	})
+
+	if insideCount == len(inside) {
+		return o
+	}
+	if insideCount == 0 {
+		return Object{CullMode: o.CullMode, Texture: o.Texture}
+	}
+
+	builder := newSectionBuilder(o, p, inside)
+	for i, triangle := range o.Triangles {
+		builder.addTriangle(i, triangle)
+	}
+	if p.CapColor != nil {
+		builder.addCaps(*p.CapColor)
+	}
+	return builder.toObject()
+}
+
+// sectionBuilder は切断したオブジェクトを組み立てます
+type sectionBuilder struct {
+	source Object
+	plane  ClipPlane
+	inside []bool
+
+	vertices        []Vector3D
+	attributeValues [][]float64
+	triangles       [][3]int
+	triangleColors  []color.RGBA
+
+	// vertexMap 元の頂点の添字番号から新しい頂点の添字番号への対応
+	vertexMap map[int]int
+	// intersectionMap 切断した辺（元の頂点の添字番号の組）から交点の頂点の添字番号への対応
+	// 辺を共有する三角形で同じ交点を使うため、切り口の輪郭が繋がる
+	intersectionMap map[[2]int]int
+	// segments 切り口の輪郭を構成する線分（新しい頂点の添字番号の組）
+	segments [][2]int
+}
+
+func newSectionBuilder(o Object, p ClipPlane, inside []bool) *sectionBuilder {
+	return &sectionBuilder{
+		source:          o,
+		plane:           p,
+		inside:          inside,
+		vertices:        make([]Vector3D, 0, len(inside)),
+		attributeValues: make([][]float64, 0, len(inside)),
+		triangles:       make([][3]int, 0, len(o.Triangles)),
+		triangleColors:  make([]color.RGBA, 0, len(o.Triangles)),
+		vertexMap:       make(map[int]int, len(inside)),
+		intersectionMap: make(map[[2]int]int),
+	}
+}
+
+func (b *sectionBuilder) appendVertex(v ClipVertex) int {
+	b.vertices = append(b.vertices, v.Vector3D())
+	b.attributeValues = append(b.attributeValues, v.Attributes)
+	return len(b.vertices) - 1
+}
+
+func (b *sectionBuilder) clipVertex(i int) ClipVertex {
+	v := b.source.VertexMatrix.GetVertex(i)
+	return ClipVertex{Position: Vector4D{v[0], v[1], v[2], 1}, Attributes: b.source.VertexAttributeValues(i)}
+}
+
+// vertex は残す側にある元の頂点を追加し、新しい添字番号を返します
+func (b *sectionBuilder) vertex(i int) int {
+	if index, ok := b.vertexMap[i]; ok {
+		return index
+	}
+	index := b.appendVertex(b.clipVertex(i))
+	b.vertexMap[i] = index
+	return index
+}
+
+// intersection は辺と切断面の交点を追加し、新しい添字番号を返します
+func (b *sectionBuilder) intersection(from, to int) int {
+	// 辺の向きによらず同じ交点になるように、添字番号の小さい頂点から計算する
+	key := [2]int{from, to}
+	if from > to {
+		key = [2]int{to, from}
+	}
+	if index, ok := b.intersectionMap[key]; ok {
+		return index
+	}
+
+	fromVertex := b.clipVertex(key[0])
+	toVertex := b.clipVertex(key[1])
+	// 切断面上の頂点はそのまま使う（長さ0の輪郭の線分を作らないため）
+	for _, i := range key {
+		if b.inside[i] && math.Abs(b.plane.Distance(b.clipVertex(i).Vector3D())) <= ClippingEpsilon {
+			index := b.vertex(i)
+			b.intersectionMap[key] = index
+			return index
+		}
+	}
+	t := IntersectPlaneParameter(b.plane.Normal, b.plane.Point, fromVertex.Vector3D(), toVertex.Vector3D())
+	index := b.appendVertex(fromVertex.Lerp(toVertex, t))
+	b.intersectionMap[key] = index
+	return index
+}
+
+// addTriangle はi番目の三角形をSutherland-Hodgmanアルゴリズムで切断して追加します
+func (b *sectionBuilder) addTriangle(i int, triangle [3]int) {
+	polygon := make([]int, 0, 4)
+	cut := make([]int, 0, 2)
+	for k := 0; k < 3; k++ {
+		from := triangle[k]
+		to := triangle[(k+1)%3]
+
+		if b.inside[from] && b.inside[to] {
+			// 内から内
+			polygon = append(polygon, b.vertex(to))
+		} else if b.inside[from] && !b.inside[to] {
+			// 内から外
+			x := b.intersection(from, to)
+			polygon = append(polygon, x)
+			cut = append(cut, x)
+		} else if !b.inside[from] && b.inside[to] {
+			// 外から内
+			// 先に交点を追加する。その後、内側の頂点を追加する。（頂点の順番を維持するため）
+			x := b.intersection(from, to)
+			polygon = append(polygon, x, b.vertex(to))
+			cut = append(cut, x)
+		}
+	}
+
+	// 元の三角形の色を取得
+	var originalColor color.RGBA
+	if i < len(b.source.TriangleColors) {
+		originalColor = b.source.TriangleColors[i]
+	} else {
+		originalColor = color.RGBA{0, 0, 0, 255} // デフォルト色
+	}
+
+	// 凸多角形なので扇状に三角形分割する（切断面上の頂点を共有して潰れた三角形は除く）
+	for j := 1; j < len(polygon)-1; j++ {
+		triangle := [3]int{polygon[0], polygon[j], polygon[j+1]}
+		if triangle[0] == triangle[1] || triangle[1] == triangle[2] || triangle[2] == triangle[0] {
+			continue
+		}
+		b.triangles = append(b.triangles, triangle)
+		b.triangleColors = append(b.triangleColors, originalColor)
+	}
+	if len(cut) == 2 && cut[0] != cut[1] {
+		b.segments = append(b.segments, [2]int{cut[0], cut[1]})
+	}
+}
+
+// addCaps は切り口の輪郭を繋いで閉じた多角形を作り、切り口を塞ぐ三角形を追加します
+// 輪郭の内側にある輪郭（トーラスの切り口など）は穴として扱います
+// 閉じていない輪郭（開いたメッシュの切り口）は塞ぎません
+// 塞ぐ三角形の表面は取り除いた側（法線の向き）を向きます
+func (b *sectionBuilder) addCaps(capColor color.RGBA) {
+	u, v := planeBasis(b.plane.Normal)
+
+	// テクスチャ座標の継ぎ目などで同じ位置に複数の頂点がある場合も輪郭が繋がるように、位置でまとめてから繋ぐ
+	grid := NewVertexGrid(b.source.MergeTolerance())
+	representatives := make(map[int]int, len(b.segments))
+	welded := make([][2]int, 0, len(b.segments))
+	for _, segment := range b.segments {
+		var weldedSegment [2]int
+		for k, index := range segment {
+			id := grid.AddVertex(b.vertices[index])
+			if _, ok := representatives[id]; !ok {
+				representatives[id] = index
+			}
+			weldedSegment[k] = id
+		}
+		if weldedSegment[0] != weldedSegment[1] {
+			welded = append(welded, weldedSegment)
+		}
+	}
+
+	// 切り口の輪郭を平面上の多角形にし、外周と穴の組ごとに塞ぐ
+	loops := make([][]int, 0)
+	polygons := make([][]Vector2D, 0)
+	for _, weldedLoop := range chainSegments(welded) {
+		loop := make([]int, 0, len(weldedLoop))
+		points := make([]Vector2D, 0, len(weldedLoop))
+		for _, id := range weldedLoop {
+			index := representatives[id]
+			loop = append(loop, index)
+			points = append(points, Vector2D{b.vertices[index].Dot(u), b.vertices[index].Dot(v)})
+		}
+		loops = append(loops, loop)
+		polygons = append(polygons, points)
+	}
+
+	for _, group := range nestPolygons2D(polygons) {
+		indexes := append([]int{}, loops[group[0]]...)
+		holes := make([][]Vector2D, 0, len(group)-1)
+		for _, hole := range group[1:] {
+			indexes = append(indexes, loops[hole]...)
+			holes = append(holes, polygons[hole])
+		}
+
+		for _, triangle := range TriangulatePolygon2D(polygons[group[0]], holes...) {
+			a, c1, c2 := indexes[triangle[0]], indexes[triangle[1]], indexes[triangle[2]]
+			normal := b.vertices[c2].Sub(b.vertices[a]).Cross(b.vertices[c1].Sub(b.vertices[a]))
+			if normal.Dot(b.plane.Normal) < 0 {
+				c1, c2 = c2, c1
+			}
+			b.triangles = append(b.triangles, [3]int{a, c1, c2})
+			b.triangleColors = append(b.triangleColors, capColor)
+		}
+	}
+}
+
+func (b *sectionBuilder) toObject() Object {
+	if len(b.triangles) == 0 {
+		return Object{CullMode: b.source.CullMode, Texture: b.source.Texture}
+	}
+
+	edges := make([][2]int, 0, len(b.triangles)*3)
+	for _, triangle := range b.triangles {
+		edges = append(edges, [2]int{triangle[0], triangle[1]}, [2]int{triangle[1], triangle[2]}, [2]int{triangle[2], triangle[0]})
+	}
+
+	return Object{
+		VertexMatrix:   NewVertexMatrix(b.vertices),
+		Edges:          CleanEdges(edges),
+		Triangles:      b.triangles,
+		TriangleColors: b.triangleColors,
+		CullMode:       b.source.CullMode,
+		Texture:        b.source.Texture,
+		Attributes:     newVertexAttributes(attributeLayout(b.source.Attributes), b.attributeValues),
+	}
+}
+
+// planeBasis は法線に直交する2つの単位ベクトルを返します
+func planeBasis(normal Vector3D) (Vector3D, Vector3D) {
+	n := normal.Normalize()
+	axis := Vector3D{1, 0, 0}
+	if math.Abs(n[0]) > 0.9 {
+		axis = Vector3D{0, 1, 0}
+	}
+	u := n.Cross(axis).Normalize()
+	v := n.Cross(u)
+	return u, v
+}
+
+// chainSegments は線分を端点で繋いで閉じた輪郭（頂点の添字番号の列）にします
+// 閉じない輪郭は除外します
+func chainSegments(segments [][2]int) [][]int {
+	neighbors := make(map[int][]int, len(segments)*2)
+	for _, segment := range segments {
+		neighbors[segment[0]] = append(neighbors[segment[0]], segment[1])
+		neighbors[segment[1]] = append(neighbors[segment[1]], segment[0])
+	}
+
+	used := make(map[[2]int]bool, len(segments))
+	makeKey := func(a, b int) [2]int {
+		if a > b {
+			return [2]int{b, a}
+		}
+		return [2]int{a, b}
+	}
+
+	loops := make([][]int, 0)
+	for _, segment := range segments {
+		if used[makeKey(segment[0], segment[1])] {
+			continue
+		}
+		used[makeKey(segment[0], segment[1])] = true
+
+		start := segment[0]
+		loop := []int{start, segment[1]}
+		closed := false
+		for {
+			current := loop[len(loop)-1]
+			next := -1
+			for _, candidate := range neighbors[current] {
+				if !used[makeKey(current, candidate)] {
+					next = candidate
+					break
+				}
+			}
+			if next < 0 {
+				break
+			}
+			used[makeKey(current, next)] = true
+			if next == start {
+				closed = true
+				break
+			}
+			loop = append(loop, next)
+		}
+
+		if closed && len(loop) >= 3 {
+			loops = append(loops, loop)
+		}
+	}
+	return loops
+}
@@ -0,0 +1,183 @@
+package domain
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// assertClosedMesh は全ての辺がちょうど2つの三角形に逆向きで共有されている（閉じていて向きが揃っている）ことを検証します
+func assertClosedMesh(t *testing.T, o Object) {
+	directedEdges := make(map[[2]int]int)
+	for _, triangle := range o.Triangles {
+		for k := 0; k < 3; k++ {
+			directedEdges[[2]int{triangle[k], triangle[(k+1)%3]}]++
+		}
+	}
+	for edge, count := range directedEdges {
+		assert.Equal(t, 1, count, "%v", edge)
+		assert.Equal(t, 1, directedEdges[[2]int{edge[1], edge[0]}], "%v", edge)
+	}
+}
+
+func TestClipPlane_Distance(t *testing.T) {
+	plane := ClipPlane{Point: Vector3D{0, 1, 0}, Normal: Vector3D{0, 2, 0}}
+
+	assert.InDelta(t, 2.0, plane.Distance(Vector3D{5, 3, 1}), 1e-9)
+	assert.InDelta(t, -1.0, plane.Distance(Vector3D{0, 0, 0}), 1e-9)
+}
+
+func TestClipPlane_SectionObject_開いたメッシュ(t *testing.T) {
+	plane := ClipPlane{Normal: Vector3D{1, 0, 0}, CapColor: &color.RGBA{0, 255, 0, 255}}
+	red := color.RGBA{255, 0, 0, 255}
+
+	result := plane.SectionObject(NewPlaneObject(2, 2, red))
+
+	// 取り除く側（x > 0）の頂点は残らない
+	_, box := result.BoundingBox()
+	assert.InDelta(t, 0.0, box.Max.X(), 1e-9)
+	assert.InDelta(t, -1.0, box.Min.X(), 1e-9)
+	// 切り口が閉じていないため塞がない
+	for _, c := range result.TriangleColors {
+		assert.Equal(t, red, c)
+	}
+}
+
+func TestClipPlane_SectionObject_全て残す側と全て取り除く側(t *testing.T) {
+	obj := NewTetrahedronObject(1)
+
+	inside := ClipPlane{Point: Vector3D{0, 5, 0}, Normal: Vector3D{0, 1, 0}}
+	assert.Equal(t, obj, inside.SectionObject(obj))
+
+	outside := ClipPlane{Point: Vector3D{0, -5, 0}, Normal: Vector3D{0, 1, 0}}
+	result := outside.SectionObject(obj)
+	ok, _ := result.BoundingBox()
+	assert.False(t, ok)
+}
+
+func TestClipPlane_SectionObject_断面を塞ぐ(t *testing.T) {
+	capColor := color.RGBA{10, 20, 30, 255}
+	plane := ClipPlane{Normal: Vector3D{0, 1, 0}, CapColor: &capColor}
+
+	result := plane.SectionObject(NewTetrahedronObject(1))
+
+	_, box := result.BoundingBox()
+	assert.InDelta(t, 0.0, box.Max.Y(), 1e-9)
+
+	// 切り口を塞いだ立体は閉じている
+	assertClosedMesh(t, result)
+
+	caps := 0
+	for i, triangle := range result.Triangles {
+		if result.TriangleColors[i] != capColor {
+			continue
+		}
+		caps++
+		a := result.VertexMatrix.GetVertex(triangle[0])
+		b := result.VertexMatrix.GetVertex(triangle[1])
+		c := result.VertexMatrix.GetVertex(triangle[2])
+		// 断面の表面は取り除いた側を向く
+		normal := CalcNormalFromPoints(a, b, c)
+		assert.InDelta(t, 1.0, normal.Y(), 1e-9)
+	}
+	// 三角形の断面
+	assert.Equal(t, 1, caps)
+}
+
+func TestClipPlane_SectionObject_頂点属性を補間すること(t *testing.T) {
+	plane := ClipPlane{Point: Vector3D{0.5, 0, 0}, Normal: Vector3D{1, 0, 0}}
+	obj := NewPlaneObject(2, 2, color.RGBA{})
+	obj.Attributes = []VertexAttribute{{Name: "u", Size: 1, Values: []float64{0, 1, 1, 0}}}
+
+	result := plane.SectionObject(obj)
+
+	ok, u := result.Attribute("u")
+	assert.True(t, ok)
+	result.VertexMatrix.EachVertex(func(i int, v Vertex) bool {
+		assert.InDelta(t, (v.X()+1)/2, u.Values[i], 1e-9)
+		return true
+	})
+}
+
+func TestClipPlane_SectionObject_テクスチャ座標の継ぎ目がある立体(t *testing.T) {
+	capColor := color.RGBA{10, 20, 30, 255}
+	plane := ClipPlane{Point: Vector3D{0, 0.1, 0}, Normal: Vector3D{0.3, 1, 0.2}, CapColor: &capColor}
+
+	result := plane.SectionObject(NewUVSphereObject(1, 16, 8))
+
+	// 継ぎ目で頂点が分かれていても切り口が塞がれる
+	caps := 0
+	for _, c := range result.TriangleColors {
+		if c == capColor {
+			caps++
+		}
+	}
+	assert.Greater(t, caps, 0)
+	assertWeldedClosedMesh(t, result)
+}
+
+func TestWorld_SectionObject_複数の切断面(t *testing.T) {
+	world := World{
+		ClipPlanes: []ClipPlane{
+			{Normal: Vector3D{1, 0, 0}},
+			{Normal: Vector3D{0, -1, 0}},
+		},
+	}
+
+	result := world.SectionObject(NewPlaneObject(2, 2, color.RGBA{}), NewTranslateMatrix4(0, 0, 3))
+
+	// ワールド座標系に変換してから切断する
+	_, box := result.BoundingBox()
+	assert.InDeltaSlice(t, []float64{-1, 0, 3}, box.Min[:], 1e-9)
+	assert.InDeltaSlice(t, []float64{0, 1, 3}, box.Max[:], 1e-9)
+}
+
+func TestWorld_SectionObject_頂点がないオブジェクト(t *testing.T) {
+	capColor := color.RGBA{10, 20, 30, 255}
+	world := World{
+		ClipPlanes: []ClipPlane{{Normal: Vector3D{1, 0, 0}, CapColor: &capColor}},
+	}
+
+	assert.NotPanics(t, func() {
+		assert.Equal(t, Object{CullMode: CullNone}, world.SectionObject(Object{CullMode: CullNone}, NewTranslateMatrix4(0, 0, 3)))
+		assert.Equal(t, Object{}, world.ClipPlanes[0].SectionObject(Object{}))
+	})
+
+	// 頂点がないオブジェクトを含むワールドも描画できる
+	world = newTestWorld(16, 12, LocatedObject{Scale: Vector3D{1, 1, 1}})
+	world.ClipPlanes = []ClipPlane{{Normal: Vector3D{1, 0, 0}, CapColor: &capColor}}
+	assert.NotPanics(t, func() {
+		assert.Empty(t, world.Transform())
+	})
+}
+
+func TestWorld_Transform_断面図(t *testing.T) {
+	capColor := color.RGBA{10, 20, 30, 255}
+	world := World{
+		LocatedObjects: []LocatedObject{
+			{
+				Location: Vector3D{0, 0, 2},
+				Scale:    Vector3D{1, 1, 1},
+				Object:   NewTetrahedronObject(0.5),
+			},
+		},
+		Viewport: Viewport{Width: 16, Height: 12},
+		Clipping: Clipping{NearDistance: 0.1, FarDistance: 10.0, FieldOfView: math.Pi / 4},
+		// カメラ側の半分を取り除く
+		ClipPlanes: []ClipPlane{
+			{Point: Vector3D{0, 0, 2}, Normal: Vector3D{0, 0, -1}, CapColor: &capColor},
+		},
+	}
+
+	frameBuffer := world.Transform()
+
+	assert.Equal(t, capColor, frameBuffer[FrameBufferKey{X: 8, Y: 6}].Color)
+
+	// 塗りつぶさない場合は切り口から内側（裏面）が見えるが、裏面は描画されない
+	world.ClipPlanes[0].CapColor = nil
+	frameBuffer = world.Transform()
+	_, ok := frameBuffer[FrameBufferKey{X: 8, Y: 6}]
+	assert.False(t, ok)
+}
@@ -0,0 +1,144 @@
+package domain
+
+import (
+	"image/color"
+	"math"
+)
+
+// StereoMode はステレオ画像の出力方式を表します
+type StereoMode int
+
+const (
+	// SideBySide 左目を左、右目を右に並べる（幅が2倍になる）
+	SideBySide StereoMode = iota
+	// OverUnder 左目を上、右目を下に並べる（高さが2倍になる）
+	OverUnder
+	// Anaglyph 左目を赤、右目をシアンで合成する（赤青メガネ用）
+	Anaglyph
+)
+
+// StereoRig はステレオカメラの設定を表します
+type StereoRig struct {
+	// InterocularDistance 左右の目の間の距離
+	InterocularDistance float64
+	// ConvergenceDistance 左右の視線が交わる距離（この距離の物体は視差0になる）
+	// 0以下の場合は視線が交わらない（平行な視錐台になる）ものとして扱う
+	ConvergenceDistance float64
+	Mode                StereoMode
+}
+
+// Right はカメラの右方向の単位ベクトルをワールド座標系で返します
+// カメラ座標変換（Z→Y→X軸の順で逆回転）の逆変換で、カメラ座標系のX軸を回転させます
+func (c Camera) Right() Vector3D {
+	y := c.Direction.Y()
+	z := c.Direction.Z()
+	return Vector3D{
+		math.Cos(y) * math.Cos(z),
+		math.Cos(y) * math.Sin(z),
+		-math.Sin(y),
+	}
+}
+
+// EyeWorlds は左目用と右目用のワールドを返します
+// カメラを左右にInterocularDistanceの半分ずつ移動し、
+// ConvergenceDistanceで視錐台が一致するようにオフアクシスの視錐台を設定します
+// ConvergenceDistanceが0以下（または有限でない）場合は視錐台をずらさず、平行な視錐台にします
+func (w World) EyeWorlds(rig StereoRig) (World, World) {
+	halfDistance := rig.InterocularDistance / 2
+	right := w.Camera.Right()
+	// 前方クリップ面でのずれ（輻輳距離での視差が0になるようにずらす）
+	shift := 0.0
+	if rig.ConvergenceDistance > 0 && !math.IsInf(rig.ConvergenceDistance, 1) {
+		shift = halfDistance * w.Clipping.NearDistance / rig.ConvergenceDistance
+	}
+
+	left := w
+	left.Camera.Location = w.Camera.Location.Sub(right.MulScalar(halfDistance))
+	left.Clipping.HorizontalShift = w.Clipping.HorizontalShift + shift
+
+	rightWorld := w
+	rightWorld.Camera.Location = w.Camera.Location.Add(right.MulScalar(halfDistance))
+	rightWorld.Clipping.HorizontalShift = w.Clipping.HorizontalShift - shift
+
+	return left, rightWorld
+}
+
+// OutputSize はステレオ画像の幅と高さを返します
+func (rig StereoRig) OutputSize(v Viewport) (int32, int32) {
+	switch rig.Mode {
+	case SideBySide:
+		return v.Width * 2, v.Height
+	case OverUnder:
+		return v.Width, v.Height * 2
+	}
+	return v.Width, v.Height
+}
+
+// TransformStereo はステレオカメラでワールドをレンダリングします
+// 出力画像のサイズはOutputSizeで取得できます
+func (w World) TransformStereo(rig StereoRig) FrameBuffer {
+	leftWorld, rightWorld := w.EyeWorlds(rig)
+	leftFrameBuffer := leftWorld.Transform()
+	rightFrameBuffer := rightWorld.Transform()
+
+	switch rig.Mode {
+	case SideBySide:
+		return ComposeFrameBuffers(leftFrameBuffer, rightFrameBuffer, FrameBufferKey{X: w.Viewport.Width})
+	case OverUnder:
+		return ComposeFrameBuffers(leftFrameBuffer, rightFrameBuffer, FrameBufferKey{Y: w.Viewport.Height})
+	case Anaglyph:
+		return ComposeAnaglyph(leftFrameBuffer, rightFrameBuffer)
+	}
+	return FrameBuffer{}
+}
+
+// ComposeFrameBuffers は2つのFrameBufferを並べて1つにします
+// secondはoffsetだけずらして配置します
+func ComposeFrameBuffers(first, second FrameBuffer, offset FrameBufferKey) FrameBuffer {
+	frameBuffer := make(FrameBuffer, len(first)+len(second))
+	for key, value := range first {
+		frameBuffer[key] = value
+	}
+	for key, value := range second {
+		frameBuffer[FrameBufferKey{X: key.X + offset.X, Y: key.Y + offset.Y}] = value
+	}
+	return frameBuffer
+}
+
+// ComposeAnaglyph は左目の赤成分と右目の緑・青成分を合成します
+// 片方の目にしか描画されていない画素は、もう片方を背景色として合成します
+func ComposeAnaglyph(left, right FrameBuffer) FrameBuffer {
+	frameBuffer := make(FrameBuffer, len(left))
+
+	keys := make(map[FrameBufferKey]bool, len(left)+len(right))
+	for key := range left {
+		keys[key] = true
+	}
+	for key := range right {
+		keys[key] = true
+	}
+
+	for key := range keys {
+		leftValue, leftOk := left[key]
+		rightValue, rightOk := right[key]
+
+		leftColor := backgroundColor
+		depth := math.Inf(1)
+		if leftOk {
+			leftColor = leftValue.Color
+			depth = leftValue.Depth
+		}
+		rightColor := backgroundColor
+		if rightOk {
+			rightColor = rightValue.Color
+			depth = math.Min(depth, rightValue.Depth)
+		}
+
+		frameBuffer[key] = FrameBufferValue{
+			Color: color.RGBA{leftColor.R, rightColor.G, rightColor.B, 255},
+			Depth: depth,
+		}
+	}
+
+	return frameBuffer
+}
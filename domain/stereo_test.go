@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// centerX は描画された画素のX座標の平均を返します
+func centerX(fb FrameBuffer) float64 {
+	sum := 0.0
+	for key := range fb {
+		sum += float64(key.X)
+	}
+	return sum / float64(len(fb))
+}
+
+func TestCamera_Right(t *testing.T) {
+	right := Camera{}.Right()
+	assert.InDelta(t, 1.0, right.X(), 1e-9)
+	assert.InDelta(t, 0.0, right.Y(), 1e-9)
+	assert.InDelta(t, 0.0, right.Z(), 1e-9)
+
+	// Y軸周りに90度回転すると+Xを向くため、右は-Zになる
+	right = Camera{Direction: Vector3D{0, math.Pi / 2, 0}}.Right()
+	assert.InDelta(t, 0.0, right.X(), 1e-9)
+	assert.InDelta(t, 0.0, right.Y(), 1e-9)
+	assert.InDelta(t, -1.0, right.Z(), 1e-9)
+}
+
+func TestWorld_ViewVolume_オフアクシス(t *testing.T) {
+	world := newTestWorld(40, 40, newTestPlane(1, 0.2, color.RGBA{255, 255, 255, 255}))
+	world.Clipping.HorizontalShift = 0.01
+
+	result := world.ViewVolume()
+
+	symmetric := newTestWorld(40, 40, newTestPlane(1, 0.2, color.RGBA{255, 255, 255, 255})).ViewVolume()
+	assert.InDelta(t, symmetric.NearTopLeft.X()+0.01, result.NearTopLeft.X(), 1e-9)
+	assert.InDelta(t, symmetric.NearTopRight.X()+0.01, result.NearTopRight.X(), 1e-9)
+	// 後方クリップ面は距離に比例してずれる
+	assert.InDelta(t, symmetric.FarTopLeft.X()+1.0, result.FarTopLeft.X(), 1e-9)
+	assert.InDelta(t, symmetric.FarBottomRight.X()+1.0, result.FarBottomRight.X(), 1e-9)
+}
+
+func TestWorld_TransformPerspectiveProjection_オフアクシス(t *testing.T) {
+	world := newTestWorld(40, 40, newTestPlane(1, 0.2, color.RGBA{255, 255, 255, 255}))
+	world.Clipping.HorizontalShift = 0.01
+	viewVolume := world.ViewVolume()
+
+	obj := Object{
+		VertexMatrix: NewVertexMatrix([]Vector3D{
+			viewVolume.NearTopLeft,
+			viewVolume.FarBottomRight,
+		}),
+	}
+
+	result := world.TransformPerspectiveProjection(obj)
+
+	// ずれた視錐台の左端・右端がNDCの-1と1に変換される
+	assert.InDelta(t, -1.0, result.VertexMatrix.GetVertex(0).X(), 1e-9)
+	assert.InDelta(t, 1.0, result.VertexMatrix.GetVertex(1).X(), 1e-9)
+}
+
+func TestWorld_EyeWorlds(t *testing.T) {
+	world := newTestWorld(40, 40, newTestPlane(1, 0.2, color.RGBA{255, 255, 255, 255}))
+	rig := StereoRig{InterocularDistance: 0.06, ConvergenceDistance: 1.0}
+
+	left, right := world.EyeWorlds(rig)
+
+	assert.InDelta(t, -0.03, left.Camera.Location.X(), 1e-9)
+	assert.InDelta(t, 0.03, right.Camera.Location.X(), 1e-9)
+	assert.InDelta(t, 0.003, left.Clipping.HorizontalShift, 1e-9)
+	assert.InDelta(t, -0.003, right.Clipping.HorizontalShift, 1e-9)
+}
+
+func TestWorld_EyeWorlds_輻輳距離が正ではない場合は平行な視錐台になること(t *testing.T) {
+	world := newTestWorld(40, 40, newTestPlane(1, 0.2, color.RGBA{255, 255, 255, 255}))
+
+	for _, convergenceDistance := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		left, right := world.EyeWorlds(StereoRig{InterocularDistance: 0.06, ConvergenceDistance: convergenceDistance})
+
+		assert.InDelta(t, -0.03, left.Camera.Location.X(), 1e-9)
+		assert.InDelta(t, 0.03, right.Camera.Location.X(), 1e-9)
+		assert.Equal(t, 0.0, left.Clipping.HorizontalShift, "%v", convergenceDistance)
+		assert.Equal(t, 0.0, right.Clipping.HorizontalShift, "%v", convergenceDistance)
+		assert.NotEmpty(t, left.Transform())
+	}
+}
+
+func TestWorld_EyeWorlds_輻輳距離の物体は視差が0になること(t *testing.T) {
+	rig := StereoRig{InterocularDistance: 0.06, ConvergenceDistance: 1.0}
+
+	// 輻輳距離にある物体は左右で同じ位置に描画される
+	left, right := newTestWorld(40, 40, newTestPlane(1, 0.2, color.RGBA{255, 255, 255, 255})).EyeWorlds(rig)
+	assert.InDelta(t, centerX(left.Transform()), centerX(right.Transform()), 0.5)
+
+	// 輻輳距離より奥にある物体は左目では左寄り、右目では右寄りに描画される
+	left, right = newTestWorld(40, 40, newTestPlane(3, 0.2, color.RGBA{255, 255, 255, 255})).EyeWorlds(rig)
+	assert.Less(t, centerX(left.Transform())+0.5, centerX(right.Transform()))
+}
+
+func TestStereoRig_OutputSize(t *testing.T) {
+	viewport := Viewport{Width: 40, Height: 30}
+
+	w, h := StereoRig{Mode: SideBySide}.OutputSize(viewport)
+	assert.Equal(t, int32(80), w)
+	assert.Equal(t, int32(30), h)
+
+	w, h = StereoRig{Mode: OverUnder}.OutputSize(viewport)
+	assert.Equal(t, int32(40), w)
+	assert.Equal(t, int32(60), h)
+
+	w, h = StereoRig{Mode: Anaglyph}.OutputSize(viewport)
+	assert.Equal(t, int32(40), w)
+	assert.Equal(t, int32(30), h)
+}
+
+func TestWorld_TransformStereo_SideBySide(t *testing.T) {
+	world := newTestWorld(40, 40, newTestPlane(1, 0.2, color.RGBA{255, 255, 255, 255}))
+	rig := StereoRig{InterocularDistance: 0.06, ConvergenceDistance: 1.0, Mode: SideBySide}
+
+	result := world.TransformStereo(rig)
+
+	left, right := world.EyeWorlds(rig)
+	leftFrameBuffer := left.Transform()
+	rightFrameBuffer := right.Transform()
+	assert.Len(t, result, len(leftFrameBuffer)+len(rightFrameBuffer))
+	for key := range rightFrameBuffer {
+		assert.Contains(t, result, FrameBufferKey{X: key.X + 40, Y: key.Y})
+	}
+}
+
+func TestComposeAnaglyph(t *testing.T) {
+	left := FrameBuffer{
+		{X: 0, Y: 0}: {Color: color.RGBA{100, 110, 120, 255}, Depth: 1},
+		{X: 1, Y: 0}: {Color: color.RGBA{100, 110, 120, 255}, Depth: 1},
+	}
+	right := FrameBuffer{
+		{X: 0, Y: 0}: {Color: color.RGBA{10, 20, 30, 255}, Depth: 0.5},
+	}
+
+	result := ComposeAnaglyph(left, right)
+
+	assert.Len(t, result, 2)
+	assert.Equal(t, color.RGBA{100, 20, 30, 255}, result[FrameBufferKey{X: 0, Y: 0}].Color)
+	assert.Equal(t, 0.5, result[FrameBufferKey{X: 0, Y: 0}].Depth)
+	// 右目に描画されていない画素は背景色と合成する
+	assert.Equal(t, color.RGBA{100, 255, 255, 255}, result[FrameBufferKey{X: 1, Y: 0}].Color)
+}
@@ -0,0 +1,557 @@
+package domain
+
+import (
+	"image/color"
+	"math"
+)
+
+// quadPlanarityTolerance は隣り合う2つの三角形を1つの四角形とみなす、法線の内積の1からの差の上限です
+const quadPlanarityTolerance = 1e-6
+
+// Crease は細分割しても尖ったまま残す辺（折り目）です
+type Crease struct {
+	// Edge 辺の両端の頂点の添字番号
+	Edge [2]int
+	// Sharpness 尖ったまま残す細分割の回数。1未満の端数の分だけ滑らかな辺に近づく
+	// 0以下の場合は何回細分割しても尖ったまま残す
+	Sharpness float64
+}
+
+// SubdivideLoop はLoop細分割をlevels回繰り返した三角形メッシュを返します
+// 1回ごとに三角形を4つに分け、頂点を周囲の頂点の重み付き平均の位置に動かして滑らかにします
+// 三角形の色は元の三角形から引き継ぎ、頂点属性は元の頂点の値を線形補間します（法線は正規化し直します）
+// 位置が同じ頂点（UVの継ぎ目など）はつながっているものとして扱い、境界の辺とcreasesの辺は尖ったまま残します
+func (o Object) SubdivideLoop(levels int, creases ...Crease) Object {
+	if levels <= 0 || len(o.Triangles) == 0 {
+		return o
+	}
+
+	faces := make([][]int, 0, len(o.Triangles))
+	colors := make([]color.RGBA, 0, len(o.Triangles))
+	for i, triangle := range o.Triangles {
+		faces = append(faces, []int{triangle[0], triangle[1], triangle[2]})
+		colors = append(colors, o.TriangleColor(i))
+	}
+
+	mesh := newSubdivisionMesh(o, faces, colors, creases)
+	for level := 0; level < levels; level++ {
+		mesh = mesh.subdivideLoop()
+	}
+	return mesh.toObject(o)
+}
+
+// SubdivideCatmullClark はCatmull-Clark細分割をlevels回繰り返したメッシュを返します
+// 同じ平面上で隣り合う同じ色の三角形の組は1つの四角形の面として扱います（QuadFaces）
+// 1回ごとに面を角の数だけの四角形に分けるので、1回目の細分割で全ての面が四角形になります
+// 四角形は2つの三角形にして返します。Edgesには四角形の対角線を含めません
+// 色・頂点属性・継ぎ目・折り目の扱いはSubdivideLoopと同じです
+func (o Object) SubdivideCatmullClark(levels int, creases ...Crease) Object {
+	if levels <= 0 || len(o.Triangles) == 0 {
+		return o
+	}
+
+	faces, colors := o.QuadFaces()
+	mesh := newSubdivisionMesh(o, faces, colors, creases)
+	for level := 0; level < levels; level++ {
+		mesh = mesh.subdivideCatmullClark()
+	}
+	return mesh.toObject(o)
+}
+
+// QuadFaces は同じ平面上で辺を共有する同じ色の三角形の組を四角形にまとめた面と、面ごとの色を返します
+// 四角形の対角線になる辺は三角形の最も長い辺とし、凸にならない組はまとめません。まとめられない三角形はそのまま返します
+// 面の頂点の順番は三角形と同じ向きです
+func (o Object) QuadFaces() ([][]int, []color.RGBA) {
+	// 向きのある辺から、その辺を持つ三角形を引く
+	triangleByEdge := make(map[[2]int]int, len(o.Triangles)*3)
+	for i, triangle := range o.Triangles {
+		for k := 0; k < 3; k++ {
+			triangleByEdge[[2]int{triangle[k], triangle[(k+1)%3]}] = i
+		}
+	}
+
+	used := make([]bool, len(o.Triangles))
+	faces := make([][]int, 0, len(o.Triangles))
+	colors := make([]color.RGBA, 0, len(o.Triangles))
+	for i, triangle := range o.Triangles {
+		if used[i] {
+			continue
+		}
+		used[i] = true
+		colors = append(colors, o.TriangleColor(i))
+
+		// 最も長い辺(x, y)を対角線とする
+		longest := 0
+		longestLength := -1.0
+		for k := 0; k < 3; k++ {
+			length := o.VertexMatrix.GetVertex(triangle[k]).DistanceTo(o.VertexMatrix.GetVertex(triangle[(k+1)%3]))
+			if length > longestLength {
+				longest, longestLength = k, length
+			}
+		}
+		x, y, z := triangle[longest], triangle[(longest+1)%3], triangle[(longest+2)%3]
+
+		j, ok := triangleByEdge[[2]int{y, x}]
+		if ok && !used[j] && o.TriangleColor(j) == o.TriangleColor(i) {
+			w := o.Triangles[j][0] + o.Triangles[j][1] + o.Triangles[j][2] - x - y
+			quad := []int{x, w, y, z}
+			if o.isPlanarConvexQuad(triangle, o.Triangles[j], quad) {
+				used[j] = true
+				faces = append(faces, quad)
+				continue
+			}
+		}
+		faces = append(faces, []int{triangle[0], triangle[1], triangle[2]})
+	}
+	return faces, colors
+}
+
+// isPlanarConvexQuad は2つの三角形をまとめた四角形quadが平面上にあり凸であるかを判定します
+func (o Object) isPlanarConvexQuad(a, b [3]int, quad []int) bool {
+	normalA := CalcNormalFromPoints(o.VertexMatrix.GetVertex(a[0]), o.VertexMatrix.GetVertex(a[1]), o.VertexMatrix.GetVertex(a[2]))
+	normalB := CalcNormalFromPoints(o.VertexMatrix.GetVertex(b[0]), o.VertexMatrix.GetVertex(b[1]), o.VertexMatrix.GetVertex(b[2]))
+	if !(normalA.Dot(normalB) > 1-quadPlanarityTolerance) {
+		return false
+	}
+
+	// 全ての角で同じ向きに曲がる（三角形の表面の向きはCalcNormalFromPointsなので外積は逆向きになる）
+	for k := range quad {
+		previous := o.VertexMatrix.GetVertex(quad[(k+len(quad)-1)%len(quad)])
+		current := o.VertexMatrix.GetVertex(quad[k])
+		next := o.VertexMatrix.GetVertex(quad[(k+1)%len(quad)])
+		if !(current.Sub(previous).Cross(next.Sub(current)).Dot(normalA) < 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// subdivisionMesh は細分割の途中の多角形メッシュです
+type subdivisionMesh struct {
+	vertices []Vector3D
+	// attributes 頂点ごとの全属性の値（VertexAttributeValues）
+	attributes [][]float64
+	// welded 頂点ごとの、位置が同じ頂点をまとめた番号
+	welded []int
+	// weldedCount まとめた頂点の数
+	weldedCount int
+	faces       [][]int
+	colors      []color.RGBA
+	// creases 折り目のシャープネス。キーはまとめた頂点の番号の組（weldedEdgeKey）
+	creases map[[2]int]float64
+}
+
+// newSubdivisionMesh はオブジェクトの頂点と面から細分割するメッシュを作ります
+// 位置が同じ頂点をまとめ、まとめた頂点が重なる面は取り除きます
+func newSubdivisionMesh(o Object, faces [][]int, colors []color.RGBA, creases []Crease) subdivisionMesh {
+	grid := NewVertexGrid(o.MergeTolerance())
+	m := subdivisionMesh{
+		vertices:   make([]Vector3D, 0, o.VertexMatrix.Len()),
+		attributes: make([][]float64, 0, o.VertexMatrix.Len()),
+		welded:     make([]int, 0, o.VertexMatrix.Len()),
+		faces:      make([][]int, 0, len(faces)),
+		colors:     make([]color.RGBA, 0, len(faces)),
+		creases:    make(map[[2]int]float64, len(creases)),
+	}
+	o.VertexMatrix.EachVertex(func(i int, v Vertex) bool {
+		m.attributes = append(m.attributes, o.VertexAttributeValues(i))
+		m.welded = append(m.welded, grid.AddVertex(v))
+		return true
+	})
+	// まとめた頂点は同じ位置に揃える
+	weldedVertices := grid.Vertices()
+	m.weldedCount = len(weldedVertices)
+	for _, w := range m.welded {
+		m.vertices = append(m.vertices, weldedVertices[w])
+	}
+
+	for i, face := range faces {
+		if m.isDegenerateFace(face) {
+			continue
+		}
+		m.faces = append(m.faces, face)
+		m.colors = append(m.colors, colors[i])
+	}
+
+	for _, crease := range creases {
+		a, b := crease.Edge[0], crease.Edge[1]
+		if a < 0 || b < 0 || a >= len(m.welded) || b >= len(m.welded) || m.welded[a] == m.welded[b] {
+			continue
+		}
+		sharpness := crease.Sharpness
+		if sharpness <= 0 {
+			sharpness = math.Inf(1)
+		}
+		key := weldedEdgeKey(m.welded[a], m.welded[b])
+		m.creases[key] = math.Max(m.creases[key], sharpness)
+	}
+	return m
+}
+
+// isDegenerateFace は面にまとめた頂点が重なって含まれるかを判定します
+func (m subdivisionMesh) isDegenerateFace(face []int) bool {
+	for i := range face {
+		for j := i + 1; j < len(face); j++ {
+			if m.welded[face[i]] == m.welded[face[j]] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// weldedEdgeKey はまとめた頂点の番号の組を小さい順に並べた辺のキーを返します
+func weldedEdgeKey(a, b int) [2]int {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]int{a, b}
+}
+
+// subdivisionTopology はまとめた頂点の番号で表したメッシュの隣接関係です
+type subdivisionTopology struct {
+	// positions まとめた頂点ごとの位置
+	positions []Vector3D
+	// edges 辺のキー（weldedEdgeKey）。面を順番に辿って見つけた順に並べる
+	edges       [][2]int
+	edgeIndexes map[[2]int]int
+	// edgeFaces 辺ごとの、その辺を持つ面の番号
+	edgeFaces [][]int
+	// vertexEdges まとめた頂点ごとの、その頂点を端点とする辺の番号
+	vertexEdges [][]int
+	// vertexFaces まとめた頂点ごとの、その頂点を含む面の番号
+	vertexFaces [][]int
+}
+
+func (m subdivisionMesh) topology() subdivisionTopology {
+	t := subdivisionTopology{
+		positions:   make([]Vector3D, m.weldedCount),
+		edges:       make([][2]int, 0, len(m.faces)*2),
+		edgeIndexes: make(map[[2]int]int, len(m.faces)*2),
+		edgeFaces:   make([][]int, 0, len(m.faces)*2),
+		vertexEdges: make([][]int, m.weldedCount),
+		vertexFaces: make([][]int, m.weldedCount),
+	}
+	for i, v := range m.vertices {
+		t.positions[m.welded[i]] = v
+	}
+	for f, face := range m.faces {
+		for k, index := range face {
+			a := m.welded[index]
+			b := m.welded[face[(k+1)%len(face)]]
+			t.vertexFaces[a] = append(t.vertexFaces[a], f)
+
+			key := weldedEdgeKey(a, b)
+			e, ok := t.edgeIndexes[key]
+			if !ok {
+				e = len(t.edges)
+				t.edgeIndexes[key] = e
+				t.edges = append(t.edges, key)
+				t.edgeFaces = append(t.edgeFaces, nil)
+				t.vertexEdges[a] = append(t.vertexEdges[a], e)
+				t.vertexEdges[b] = append(t.vertexEdges[b], e)
+			}
+			t.edgeFaces[e] = append(t.edgeFaces[e], f)
+		}
+	}
+	return t
+}
+
+// otherEnd は辺eの頂点vではない方の端点を返します
+func (t subdivisionTopology) otherEnd(e, v int) int {
+	if t.edges[e][0] == v {
+		return t.edges[e][1]
+	}
+	return t.edges[e][0]
+}
+
+// sharpness は辺eのシャープネスを返します
+// 境界の辺や3つ以上の面が共有する辺は常に尖らせます
+func (m subdivisionMesh) sharpness(t subdivisionTopology, e int) float64 {
+	if len(t.edgeFaces[e]) != 2 {
+		return math.Inf(1)
+	}
+	return m.creases[t.edges[e]]
+}
+
+// creaseVertexPosition は頂点vの新しい位置を、滑らかな場合の位置smoothと周りの折り目から決めます
+// 折り目が2本の頂点は折り目に沿って動かし、3本以上の頂点（角）は動かしません
+func (m subdivisionMesh) creaseVertexPosition(t subdivisionTopology, v int, smooth Vector3D) Vector3D {
+	sharpEdges := make([]int, 0, 2)
+	sum := 0.0
+	for _, e := range t.vertexEdges[v] {
+		if s := m.sharpness(t, e); s > 0 {
+			sharpEdges = append(sharpEdges, e)
+			sum += s
+		}
+	}
+	if len(sharpEdges) < 2 {
+		return smooth
+	}
+
+	p := t.positions[v]
+	sharp := p
+	if len(sharpEdges) == 2 {
+		a := t.positions[t.otherEnd(sharpEdges[0], v)]
+		b := t.positions[t.otherEnd(sharpEdges[1], v)]
+		sharp = p.MulScalar(0.75).Add(a.Add(b).MulScalar(0.125))
+	}
+	return blendSharpness(smooth, sharp, sum/float64(len(sharpEdges)))
+}
+
+// creaseEdgePosition は辺eに追加する頂点の位置を、滑らかな場合の位置smoothと辺のシャープネスから決めます
+// 尖った辺では辺の中点になります
+func (m subdivisionMesh) creaseEdgePosition(t subdivisionTopology, e int, smooth Vector3D) Vector3D {
+	midpoint := t.positions[t.edges[e][0]].Add(t.positions[t.edges[e][1]]).MulScalar(0.5)
+	return blendSharpness(smooth, midpoint, m.sharpness(t, e))
+}
+
+// blendSharpness はシャープネスsが1以上ならsharp、0以下ならsmooth、その間なら線形補間した位置を返します
+func blendSharpness(smooth, sharp Vector3D, s float64) Vector3D {
+	if s >= 1 {
+		return sharp
+	}
+	if s <= 0 {
+		return smooth
+	}
+	return smooth.MulScalar(1 - s).Add(sharp.MulScalar(s))
+}
+
+// loopBeta はLoop細分割で、隣接する頂点の数がnの頂点に掛ける隣接する頂点の重みです
+func loopBeta(n int) float64 {
+	c := 3.0/8 + math.Cos(2*math.Pi/float64(n))/4
+	return (5.0/8 - c*c) / float64(n)
+}
+
+// subdivideLoop はLoop細分割を1回行います
+// 面は三角形である必要があります
+func (m subdivisionMesh) subdivideLoop() subdivisionMesh {
+	t := m.topology()
+
+	positions := make([]Vector3D, m.weldedCount+len(t.edges))
+	for v := 0; v < m.weldedCount; v++ {
+		p := t.positions[v]
+		n := len(t.vertexEdges[v])
+		if n == 0 {
+			positions[v] = p
+			continue
+		}
+		sum := Vector3D{}
+		for _, e := range t.vertexEdges[v] {
+			sum = sum.Add(t.positions[t.otherEnd(e, v)])
+		}
+		beta := loopBeta(n)
+		smooth := p.MulScalar(1 - float64(n)*beta).Add(sum.MulScalar(beta))
+		positions[v] = m.creaseVertexPosition(t, v, smooth)
+	}
+	for e, key := range t.edges {
+		smooth := Vector3D{}
+		if faces := t.edgeFaces[e]; len(faces) == 2 {
+			r := t.positions[m.oppositeVertex(m.faces[faces[0]], key)]
+			s := t.positions[m.oppositeVertex(m.faces[faces[1]], key)]
+			smooth = t.positions[key[0]].Add(t.positions[key[1]]).MulScalar(3.0 / 8).Add(r.Add(s).MulScalar(1.0 / 8))
+		}
+		positions[m.weldedCount+e] = m.creaseEdgePosition(t, e, smooth)
+	}
+
+	b := newSubdivisionBuilder(m, t, positions)
+	for f, face := range m.faces {
+		a, c, d := face[0], face[1], face[2]
+		ac, cd, da := b.edgeVertex(a, c), b.edgeVertex(c, d), b.edgeVertex(d, a)
+		b.addFace([]int{a, ac, da}, m.colors[f])
+		b.addFace([]int{ac, c, cd}, m.colors[f])
+		b.addFace([]int{da, cd, d}, m.colors[f])
+		b.addFace([]int{ac, cd, da}, m.colors[f])
+	}
+	return b.result
+}
+
+// oppositeVertex は三角形の面の、辺keyの端点ではない頂点のまとめた番号を返します
+func (m subdivisionMesh) oppositeVertex(face []int, key [2]int) int {
+	for _, index := range face {
+		if w := m.welded[index]; w != key[0] && w != key[1] {
+			return w
+		}
+	}
+	return key[0]
+}
+
+// subdivideCatmullClark はCatmull-Clark細分割を1回行います
+func (m subdivisionMesh) subdivideCatmullClark() subdivisionMesh {
+	t := m.topology()
+
+	facePointOffset := m.weldedCount + len(t.edges)
+	positions := make([]Vector3D, facePointOffset+len(m.faces))
+	for f, face := range m.faces {
+		sum := Vector3D{}
+		for _, index := range face {
+			sum = sum.Add(m.vertices[index])
+		}
+		positions[facePointOffset+f] = sum.MulScalar(1 / float64(len(face)))
+	}
+	for v := 0; v < m.weldedCount; v++ {
+		p := t.positions[v]
+		n := len(t.vertexEdges[v])
+		if n == 0 {
+			positions[v] = p
+			continue
+		}
+		faceSum := Vector3D{}
+		for _, f := range t.vertexFaces[v] {
+			faceSum = faceSum.Add(positions[facePointOffset+f])
+		}
+		edgeSum := Vector3D{}
+		for _, e := range t.vertexEdges[v] {
+			edgeSum = edgeSum.Add(p.Add(t.positions[t.otherEnd(e, v)]).MulScalar(0.5))
+		}
+		// (面の点の平均 + 2 × 辺の中点の平均 + (n - 3) × 元の位置) / n
+		smooth := faceSum.MulScalar(1 / float64(len(t.vertexFaces[v]))).
+			Add(edgeSum.MulScalar(2 / float64(n))).
+			Add(p.MulScalar(float64(n - 3))).
+			MulScalar(1 / float64(n))
+		positions[v] = m.creaseVertexPosition(t, v, smooth)
+	}
+	for e, key := range t.edges {
+		smooth := Vector3D{}
+		if faces := t.edgeFaces[e]; len(faces) == 2 {
+			smooth = t.positions[key[0]].Add(t.positions[key[1]]).
+				Add(positions[facePointOffset+faces[0]]).
+				Add(positions[facePointOffset+faces[1]]).
+				MulScalar(0.25)
+		}
+		positions[m.weldedCount+e] = m.creaseEdgePosition(t, e, smooth)
+	}
+
+	b := newSubdivisionBuilder(m, t, positions)
+	for f, face := range m.faces {
+		corners := make([][]float64, 0, len(face))
+		for _, index := range face {
+			corners = append(corners, m.attributes[index])
+		}
+		center := b.addVertex(facePointOffset+f, averageAttributeValues(corners...))
+		for k, index := range face {
+			next := face[(k+1)%len(face)]
+			previous := face[(k+len(face)-1)%len(face)]
+			b.addFace([]int{index, b.edgeVertex(index, next), center, b.edgeVertex(previous, index)}, m.colors[f])
+		}
+	}
+	return b.result
+}
+
+// subdivisionBuilder は細分割した後のメッシュを作ります
+// 元の頂点は同じ添字番号のまま新しい位置に動かし、辺と面に追加する頂点をその後ろに並べます
+type subdivisionBuilder struct {
+	source   subdivisionMesh
+	topology subdivisionTopology
+	// positions 細分割した後のまとめた頂点ごとの位置
+	positions []Vector3D
+	// edgeVertices 元の頂点の添字番号の組（小さい順）から、辺に追加した頂点の添字番号を引く
+	edgeVertices map[[2]int]int
+	result       subdivisionMesh
+}
+
+func newSubdivisionBuilder(m subdivisionMesh, t subdivisionTopology, positions []Vector3D) *subdivisionBuilder {
+	b := &subdivisionBuilder{
+		source:       m,
+		topology:     t,
+		positions:    positions,
+		edgeVertices: make(map[[2]int]int, len(t.edges)),
+		result: subdivisionMesh{
+			vertices:    make([]Vector3D, 0, len(m.vertices)+len(t.edges)*2),
+			attributes:  make([][]float64, 0, len(m.vertices)+len(t.edges)*2),
+			welded:      make([]int, 0, len(m.vertices)+len(t.edges)*2),
+			weldedCount: len(positions),
+			faces:       make([][]int, 0, len(m.faces)*4),
+			colors:      make([]color.RGBA, 0, len(m.faces)*4),
+			creases:     make(map[[2]int]float64, len(m.creases)*2),
+		},
+	}
+	for i, w := range m.welded {
+		b.addVertex(w, m.attributes[i])
+	}
+
+	// 折り目は2つに分かれ、シャープネスは1つ減る
+	for key, s := range m.creases {
+		e, ok := t.edgeIndexes[key]
+		if !ok || s <= 1 {
+			continue
+		}
+		middle := m.weldedCount + e
+		b.result.creases[weldedEdgeKey(key[0], middle)] = s - 1
+		b.result.creases[weldedEdgeKey(middle, key[1])] = s - 1
+	}
+	return b
+}
+
+// addVertex はまとめた番号がweldedの頂点を追加し、添字番号を返します
+func (b *subdivisionBuilder) addVertex(welded int, attributes []float64) int {
+	b.result.vertices = append(b.result.vertices, b.positions[welded])
+	b.result.attributes = append(b.result.attributes, attributes)
+	b.result.welded = append(b.result.welded, welded)
+	return len(b.result.vertices) - 1
+}
+
+// edgeVertex は元の頂点i, jを結ぶ辺に追加した頂点の添字番号を返します
+// 継ぎ目で頂点属性が異なるように、元の頂点の添字番号の組ごとに別の頂点を追加します
+func (b *subdivisionBuilder) edgeVertex(i, j int) int {
+	key := weldedEdgeKey(i, j)
+	if index, ok := b.edgeVertices[key]; ok {
+		return index
+	}
+	e := b.topology.edgeIndexes[weldedEdgeKey(b.source.welded[i], b.source.welded[j])]
+	index := b.addVertex(b.source.weldedCount+e, averageAttributeValues(b.source.attributes[i], b.source.attributes[j]))
+	b.edgeVertices[key] = index
+	return index
+}
+
+func (b *subdivisionBuilder) addFace(face []int, c color.RGBA) {
+	b.result.faces = append(b.result.faces, face)
+	b.result.colors = append(b.result.colors, c)
+}
+
+// averageAttributeValues は頂点属性の値の平均を返します
+func averageAttributeValues(values ...[]float64) []float64 {
+	if len(values) == 0 || len(values[0]) == 0 {
+		return nil
+	}
+	average := make([]float64, len(values[0]))
+	for _, v := range values {
+		for k := range average {
+			average[k] += v[k]
+		}
+	}
+	for k := range average {
+		average[k] /= float64(len(values))
+	}
+	return average
+}
+
+// toObject は面を三角形に分けたオブジェクトを返します
+// 辺は面の周りの辺です。面以外の値（CullMode・Texture）と頂点属性の並びはoから引き継ぎます
+func (m subdivisionMesh) toObject(o Object) Object {
+	triangles := make([][3]int, 0, len(m.faces)*2)
+	triangleColors := make([]color.RGBA, 0, len(m.faces)*2)
+	edges := make([][2]int, 0, len(m.faces)*4)
+	for f, face := range m.faces {
+		for k := 1; k < len(face)-1; k++ {
+			triangles = append(triangles, [3]int{face[0], face[k], face[k+1]})
+			triangleColors = append(triangleColors, m.colors[f])
+		}
+		for k := range face {
+			edges = append(edges, [2]int{face[k], face[(k+1)%len(face)]})
+		}
+	}
+
+	attributes := newVertexAttributes(attributeLayout(o.Attributes), m.attributes)
+	normalizeNormals(attributes)
+
+	return Object{
+		VertexMatrix:   NewVertexMatrix(m.vertices),
+		Edges:          CleanEdges(edges),
+		Triangles:      triangles,
+		TriangleColors: triangleColors,
+		CullMode:       o.CullMode,
+		Attributes:     attributes,
+		Texture:        o.Texture,
+	}
+}
@@ -0,0 +1,226 @@
+package domain
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// radiusRange は原点から頂点までの距離の最小値と最大値を返します
+func radiusRange(o Object) (float64, float64) {
+	minimum, maximum := math.Inf(1), 0.0
+	o.VertexMatrix.EachVertex(func(i int, v Vertex) bool {
+		minimum = math.Min(minimum, v.Distance())
+		maximum = math.Max(maximum, v.Distance())
+		return true
+	})
+	return minimum, maximum
+}
+
+// cornerCoordinate は立方体の角だった頂点（添字番号0）の座標の絶対値を返します
+// 細分割しても元の頂点の添字番号は変わりません
+func cornerCoordinate(o Object) float64 {
+	return math.Abs(o.VertexMatrix.GetVertex(0)[0])
+}
+
+// cubeEdgeCreases は立方体の辺を折り目にします（面ごとに頂点を持つので、1本の辺を両側の面の頂点で2回指定します）
+func cubeEdgeCreases(o Object, sharpness float64) []Crease {
+	creases := make([]Crease, 0, 12)
+	for _, edge := range o.Edges {
+		a, b := o.VertexMatrix.GetVertex(edge[0]), o.VertexMatrix.GetVertex(edge[1])
+		// 面の対角線は除く
+		if a.DistanceTo(b) < 1.1 {
+			creases = append(creases, Crease{Edge: edge, Sharpness: sharpness})
+		}
+	}
+	return creases
+}
+
+func TestObject_SubdivideLoop_四面体(t *testing.T) {
+	o := NewTetrahedronObject(1)
+
+	subdivided := o.SubdivideLoop(1)
+
+	// 4頂点 + 6辺、三角形は4倍
+	assert.Equal(t, 10, subdivided.VertexMatrix.Len())
+	assert.Len(t, subdivided.Triangles, 16)
+	assert.Len(t, subdivided.TriangleColors, 16)
+	assert.Len(t, subdivided.Edges, 24)
+	assertClosedMesh(t, subdivided)
+	assertConvexOutward(t, subdivided)
+
+	// 元の頂点は隣接する頂点の方へ動く（n = 3 のとき β = 3/16）
+	expected := o.VertexMatrix.GetVertex(0).MulScalar(1 - 3*3.0/16)
+	for i := 1; i < 4; i++ {
+		expected = expected.Add(o.VertexMatrix.GetVertex(i).MulScalar(3.0 / 16))
+	}
+	actual := subdivided.VertexMatrix.GetVertex(0)
+	assert.InDeltaSlice(t, expected[:], actual[:], 1e-9)
+
+	// 回数を重ねるごとに尖った頂点が内側に縮む
+	_, maximum1 := radiusRange(subdivided)
+	_, maximum3 := radiusRange(o.SubdivideLoop(3))
+	assert.Less(t, maximum1, 1.0)
+	assert.Less(t, maximum3, maximum1)
+}
+
+func TestObject_SubdivideLoop_色と頂点属性(t *testing.T) {
+	red, blue := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 255, 255}
+	o := NewGridPlaneObject(2, 2, 1, 1, red, blue)
+	o.TriangleColors = []color.RGBA{red, blue}
+
+	subdivided := o.SubdivideLoop(2)
+
+	// 1つの三角形が16個になり、元の三角形の色を引き継ぐ
+	assert.Len(t, subdivided.Triangles, 32)
+	for i, c := range subdivided.TriangleColors {
+		assert.Equal(t, o.TriangleColors[i/16], c)
+	}
+
+	// 平面は平面のまま、境界の辺は尖った辺として扱うので四角形の範囲からはみ出さない
+	ok, box := subdivided.BoundingBox()
+	assert.True(t, ok)
+	assert.InDelta(t, 0.0, box.Min[2], 1e-12)
+	assert.InDelta(t, 0.0, box.Max[2], 1e-12)
+	assert.GreaterOrEqual(t, box.Min[0], -1.0)
+	assert.LessOrEqual(t, box.Max[0], 1.0)
+
+	// テクスチャ座標は元の頂点の値の線形補間
+	_, uvs := subdivided.Attribute(AttributeUV)
+	assert.Len(t, uvs.Values, subdivided.VertexMatrix.Len()*2)
+	for _, value := range uvs.Values {
+		assert.True(t, value >= 0 && value <= 1)
+	}
+	assertFacesMatchNormals(t, subdivided)
+}
+
+func TestObject_Subdivide_継ぎ目がある立体(t *testing.T) {
+	subdivided := map[string]Object{
+		"loop":         NewUVSphereObject(1, 12, 6).SubdivideLoop(2),
+		"catmullClark": NewCylinderObject(1, 2, 10).SubdivideCatmullClark(2),
+	}
+
+	for name, o := range subdivided {
+		t.Run(name, func(t *testing.T) {
+			// 継ぎ目で分かれた頂点も同じ位置に動くので穴は開かない
+			assertWeldedClosedMesh(t, o)
+			assertFacesMatchNormals(t, o)
+			assertConvexOutward(t, o)
+		})
+	}
+}
+
+func TestObject_QuadFaces(t *testing.T) {
+	// 箱は6つの四角形
+	faces, colors := NewCubeObject(1).QuadFaces()
+	assert.Len(t, faces, 6)
+	assert.Len(t, colors, 6)
+	for _, face := range faces {
+		assert.Len(t, face, 4)
+	}
+
+	// 色が異なる三角形はまとめない
+	o := NewPlaneObject(1, 1, color.RGBA{})
+	o.TriangleColors[1] = color.RGBA{1, 1, 1, 255}
+	faces, _ = o.QuadFaces()
+	assert.Len(t, faces, 2)
+
+	// 平面上にない三角形はまとめない
+	faces, _ = NewTetrahedronObject(1).QuadFaces()
+	assert.Len(t, faces, 4)
+}
+
+func TestObject_SubdivideCatmullClark_立方体(t *testing.T) {
+	o := NewCubeObject(1)
+
+	subdivided := o.SubdivideCatmullClark(1)
+
+	// 6つの四角形が24個の四角形（48個の三角形）になる
+	assert.Len(t, subdivided.Triangles, 48)
+	assert.Len(t, subdivided.TriangleColors, 48)
+	assertWeldedClosedMesh(t, subdivided)
+	assertFacesMatchNormals(t, subdivided)
+	assertConvexOutward(t, subdivided)
+
+	// 角の頂点は (面の点の平均 + 2 × 辺の中点の平均) / 3 = 5/18
+	// 辺の点は (両端 + 両側の面の点) / 4
+	corners, edges := 0, 0
+	subdivided.VertexMatrix.EachVertex(func(i int, v Vertex) bool {
+		abs := Vector3D{math.Abs(v[0]), math.Abs(v[1]), math.Abs(v[2])}
+		if math.Abs(abs[0]-5.0/18) < 1e-9 && math.Abs(abs[1]-5.0/18) < 1e-9 && math.Abs(abs[2]-5.0/18) < 1e-9 {
+			corners++
+		}
+		zeros, threeEighths := 0, 0
+		for _, value := range abs {
+			if value < 1e-9 {
+				zeros++
+			} else if math.Abs(value-0.375) < 1e-9 {
+				threeEighths++
+			}
+		}
+		if zeros == 1 && threeEighths == 2 {
+			edges++
+		}
+		return true
+	})
+	// 箱は面ごとに頂点を持つので、8つの角は3つずつ、12本の辺は2つずつの頂点になる
+	assert.Equal(t, 24, corners)
+	assert.Equal(t, 24, edges)
+
+	// Edgesは四角形の周りの辺だけ（面ごとに2×2の格子の12本）
+	assert.Len(t, subdivided.Edges, 6*12)
+}
+
+func TestObject_SubdivideCatmullClark_折り目(t *testing.T) {
+	o := NewCubeObject(1)
+	smooth1 := cornerCoordinate(o.SubdivideCatmullClark(1))
+	smooth2 := cornerCoordinate(o.SubdivideCatmullClark(2))
+
+	// 折り目がない場合は角が縮んで丸くなる
+	assert.InDelta(t, 5.0/18, smooth1, 1e-12)
+	assert.Less(t, smooth2, smooth1)
+
+	// 全ての辺を折り目にすると立方体の形のまま
+	sharp := o.SubdivideCatmullClark(2, cubeEdgeCreases(o, 0)...)
+	assert.Equal(t, 0.5, cornerCoordinate(sharp))
+	sharp.VertexMatrix.EachVertex(func(i int, v Vertex) bool {
+		assert.InDelta(t, 0.5, math.Max(math.Abs(v[0]), math.Max(math.Abs(v[1]), math.Abs(v[2]))), 1e-12, "%v", v)
+		return true
+	})
+
+	// シャープネスが1の場合は、1回目だけ尖らせて2回目は滑らかにする
+	semiSharp := cornerCoordinate(o.SubdivideCatmullClark(2, cubeEdgeCreases(o, 1)...))
+	assert.Less(t, semiSharp, 0.5)
+	assert.Greater(t, semiSharp, smooth2)
+	// 端数の場合は尖った位置と滑らかな位置の中間
+	fractional := cornerCoordinate(o.SubdivideCatmullClark(1, cubeEdgeCreases(o, 0.5)...))
+	assert.InDelta(t, (0.5+smooth1)/2, fractional, 1e-12)
+}
+
+func TestObject_SubdivideLoop_折り目(t *testing.T) {
+	o := NewTetrahedronObject(1)
+	a, b := o.VertexMatrix.GetVertex(0), o.VertexMatrix.GetVertex(1)
+
+	subdivided := o.SubdivideLoop(1, Crease{Edge: [2]int{0, 1}})
+
+	// 折り目の辺に追加した頂点は中点、折り目が1本だけの頂点は滑らかな場合と同じ
+	found := false
+	subdivided.VertexMatrix.EachVertex(func(i int, v Vertex) bool {
+		if v.DistanceTo(a.Add(b).MulScalar(0.5)) < 1e-12 {
+			found = true
+		}
+		return true
+	})
+	assert.True(t, found)
+	assert.Equal(t, o.SubdivideLoop(1).VertexMatrix.GetVertex(0), subdivided.VertexMatrix.GetVertex(0))
+}
+
+func TestObject_Subdivide_回数が0(t *testing.T) {
+	o := NewCubeObject(1)
+
+	assert.Equal(t, o, o.SubdivideLoop(0))
+	assert.Equal(t, o, o.SubdivideCatmullClark(0))
+	assert.Empty(t, Object{}.SubdivideLoop(2).Triangles)
+}
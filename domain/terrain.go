@@ -0,0 +1,207 @@
+package domain
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// DefaultTerrainMaxVertices は Terrain.MaxVertices を指定しない場合の格子点の数の上限です
+const DefaultTerrainMaxVertices = 256 * 256
+
+// DefaultTerrainSpacing は Terrain.Spacing を指定しない場合の格子点の間隔です
+const DefaultTerrainSpacing = 1.0
+
+// Heightmap は地形の高さ（標高）を格子状に並べたものです
+type Heightmap struct {
+	// Columns 横方向の格子点の数
+	Columns int
+	// Rows 縦方向の格子点の数
+	Rows int
+	// Values 上の行から順に、行ごとに左から並べた高さ
+	Values []float64
+}
+
+// NewHeightmap は行ごとに並べた高さからHeightmapを作ります
+// 行がない場合や、行によって要素数が異なる場合はfalseを返します
+func NewHeightmap(grid [][]float64) (bool, Heightmap) {
+	if len(grid) == 0 || len(grid[0]) == 0 {
+		return false, Heightmap{}
+	}
+	h := Heightmap{Columns: len(grid[0]), Rows: len(grid), Values: make([]float64, 0, len(grid)*len(grid[0]))}
+	for _, row := range grid {
+		if len(row) != h.Columns {
+			return false, Heightmap{}
+		}
+		h.Values = append(h.Values, row...)
+	}
+	return true, h
+}
+
+// NewHeightmapFromImage はグレースケール画像の明るさ（黒が0、白が1）を高さとするHeightmapを作ります
+// カラー画像は輝度に変換します。16ビットの画像は16ビットの精度で読み取ります
+func NewHeightmapFromImage(img image.Image) Heightmap {
+	bounds := img.Bounds()
+	h := Heightmap{Columns: bounds.Dx(), Rows: bounds.Dy(), Values: make([]float64, 0, bounds.Dx()*bounds.Dy())}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			gray := color.Gray16Model.Convert(img.At(x, y)).(color.Gray16)
+			h.Values = append(h.Values, float64(gray.Y)/math.MaxUint16)
+		}
+	}
+	return h
+}
+
+// At はcolumn列目、row行目の格子点の高さを返します
+func (h Heightmap) At(column, row int) float64 {
+	return h.Values[row*h.Columns+column]
+}
+
+// Range は高さの最小値と最大値を返します
+func (h Heightmap) Range() (float64, float64) {
+	if len(h.Values) == 0 {
+		return 0, 0
+	}
+	min, max := h.Values[0], h.Values[0]
+	for _, value := range h.Values {
+		min = math.Min(min, value)
+		max = math.Max(max, value)
+	}
+	return min, max
+}
+
+// ColorStop は高さと色の組です
+type ColorStop struct {
+	Height float64
+	Color  color.RGBA
+}
+
+// ColorRamp は高さに応じて色を決めるグラデーションです。高さの昇順に並べます
+type ColorRamp []ColorStop
+
+// At は高さに対応する色を返します
+// 隣り合う2つの色を線形補間し、範囲外の高さは端の色にします。空の場合は黒を返します
+func (r ColorRamp) At(height float64) color.RGBA {
+	if len(r) == 0 {
+		return color.RGBA{0, 0, 0, 255}
+	}
+	if height <= r[0].Height {
+		return r[0].Color
+	}
+	for i := 1; i < len(r); i++ {
+		if height > r[i].Height {
+			continue
+		}
+		from, to := r[i-1], r[i]
+		s := 0.0
+		if to.Height > from.Height {
+			s = (height - from.Height) / (to.Height - from.Height)
+		}
+		lerp := func(a, b uint8) uint8 {
+			return uint8(math.Round(LerpFloat(float64(a), float64(b), s)))
+		}
+		return color.RGBA{lerp(from.Color.R, to.Color.R), lerp(from.Color.G, to.Color.G), lerp(from.Color.B, to.Color.B), lerp(from.Color.A, to.Color.A)}
+	}
+	return r[len(r)-1].Color
+}
+
+// Terrain は高さマップから地形のオブジェクトを作る設定を表します
+type Terrain struct {
+	// Spacing 隣り合う格子点の水平方向の間隔
+	// 0以下の場合は DefaultTerrainSpacing
+	Spacing float64
+	// HeightScale 高さの倍率（オブジェクトのY座標は高さ×HeightScale）
+	HeightScale float64
+	// ColorRamp 三角形の色を決めるグラデーション。三角形の頂点の高さ（HeightScaleを掛ける前の値）の平均で色を決める
+	// 空の場合は高さマップの最小値を黒、最大値を白とするグレースケールにする
+	ColorRamp ColorRamp
+	// MaxVertices 格子点の数の上限。超える場合は縦横同じ間隔で格子点を間引く
+	// 0の場合は DefaultTerrainMaxVertices、負の場合は間引かない
+	MaxVertices int
+}
+
+// LevelOfDetail は格子点を間引く間隔（何点ごとに使うか）を返します
+func (t Terrain) LevelOfDetail(h Heightmap) int {
+	limit := t.MaxVertices
+	if limit == 0 {
+		limit = DefaultTerrainMaxVertices
+	}
+	if limit < 0 || h.Columns*h.Rows <= limit {
+		return 1
+	}
+
+	step := int(math.Ceil(math.Sqrt(float64(h.Columns*h.Rows) / float64(limit))))
+	// 端の格子点を残すため、切り上げで1点多くなる場合は間隔を広げる
+	for len(sampleIndexes(h.Columns, step))*len(sampleIndexes(h.Rows, step)) > limit && step < max(h.Columns, h.Rows) {
+		step++
+	}
+	return step
+}
+
+// NewTerrainObject は高さマップを格子状のメッシュにしたオブジェクト（地形）を作ります
+// 高さマップの列をX軸、行を-Z軸（画像の上側が奥）、高さをY軸とし、水平方向の中心を原点にします
+// 表面は上（+Y）を向きます。格子の1マスが1つの面になり、法線とテクスチャ座標（画像全体が0から1）を持ちます
+// 大きな高さマップは Terrain.MaxVertices に収まるように格子点を間引きます（端の格子点は必ず残します）
+// 格子点が縦横2点以上ない場合は空のオブジェクトを返します
+func NewTerrainObject(h Heightmap, t Terrain) Object {
+	if h.Columns < 2 || h.Rows < 2 || len(h.Values) < h.Columns*h.Rows {
+		return Object{}
+	}
+
+	spacing := t.Spacing
+	if !(spacing > 0) {
+		spacing = DefaultTerrainSpacing
+	}
+
+	step := t.LevelOfDetail(h)
+	columns := sampleIndexes(h.Columns, step)
+	rows := sampleIndexes(h.Rows, step)
+
+	position := func(column, row int) Vector3D {
+		return Vector3D{
+			(float64(column) - float64(h.Columns-1)/2) * spacing,
+			h.At(column, row) * t.HeightScale,
+			(float64(h.Rows-1)/2 - float64(row)) * spacing,
+		}
+	}
+
+	b := newPrimitiveBuilder()
+	heights := make([]float64, 0, len(columns)*len(rows))
+	b.addIndexedGrid(len(columns)-1, len(rows)-1, func(i, j int) (Vector3D, Vector3D, [2]float64) {
+		column, row := columns[i], rows[j]
+		heights = append(heights, h.At(column, row))
+
+		// 法線は間引いた後の隣の格子点との中心差分で求める
+		left, right := columns[max(i-1, 0)], columns[min(i+1, len(columns)-1)]
+		top, bottom := rows[max(j-1, 0)], rows[min(j+1, len(rows)-1)]
+		dx := position(right, row).Sub(position(left, row))
+		dz := position(column, top).Sub(position(column, bottom))
+		normal := dz.Cross(dx).Normalize()
+		if normal.Distance() == 0 {
+			normal = Vector3D{0, 1, 0}
+		}
+
+		return position(column, row), normal, [2]float64{float64(column) / float64(h.Columns-1), float64(row) / float64(h.Rows-1)}
+	})
+
+	o := b.toObject(nil)
+	ramp := t.ColorRamp
+	if len(ramp) == 0 {
+		min, max := h.Range()
+		ramp = ColorRamp{{Height: min, Color: color.RGBA{0, 0, 0, 255}}, {Height: max, Color: color.RGBA{255, 255, 255, 255}}}
+	}
+	for i, triangle := range o.Triangles {
+		height := (heights[triangle[0]] + heights[triangle[1]] + heights[triangle[2]]) / 3
+		o.TriangleColors[i] = ramp.At(height)
+	}
+	return o
+}
+
+// sampleIndexes は0からn-1までをstepごとに選んだ番号を返します。最後の番号（n-1）は必ず含めます
+func sampleIndexes(n, step int) []int {
+	indexes := make([]int, 0, n/step+2)
+	for i := 0; i < n-1; i += step {
+		indexes = append(indexes, i)
+	}
+	return append(indexes, n-1)
+}
@@ -0,0 +1,171 @@
+package domain
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newWaveHeightmap は波打つ地形の高さマップを作ります
+func newWaveHeightmap(columns, rows int) Heightmap {
+	h := Heightmap{Columns: columns, Rows: rows, Values: make([]float64, 0, columns*rows)}
+	for row := 0; row < rows; row++ {
+		for column := 0; column < columns; column++ {
+			h.Values = append(h.Values, 0.5+0.25*math.Sin(float64(column)/5)*math.Cos(float64(row)/7))
+		}
+	}
+	return h
+}
+
+func TestNewHeightmap(t *testing.T) {
+	ok, h := NewHeightmap([][]float64{{0, 1, 2}, {3, 4, 5}})
+
+	assert.True(t, ok)
+	assert.Equal(t, 3, h.Columns)
+	assert.Equal(t, 2, h.Rows)
+	assert.Equal(t, 5.0, h.At(2, 1))
+	min, max := h.Range()
+	assert.Equal(t, 0.0, min)
+	assert.Equal(t, 5.0, max)
+
+	ok, _ = NewHeightmap([][]float64{{0, 1}, {2}})
+	assert.False(t, ok)
+	ok, _ = NewHeightmap(nil)
+	assert.False(t, ok)
+}
+
+func TestNewHeightmapFromImage(t *testing.T) {
+	img := image.NewGray16(image.Rect(10, 20, 12, 21))
+	img.SetGray16(10, 20, color.Gray16{Y: 0})
+	img.SetGray16(11, 20, color.Gray16{Y: 0x8000})
+
+	h := NewHeightmapFromImage(img)
+
+	assert.Equal(t, 2, h.Columns)
+	assert.Equal(t, 1, h.Rows)
+	assert.Equal(t, 0.0, h.At(0, 0))
+	assert.InDelta(t, 0.5, h.At(1, 0), 1e-4)
+
+	// カラー画像は輝度に変換する
+	rgba := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	rgba.Set(0, 0, color.RGBA{255, 255, 255, 255})
+	assert.Equal(t, 1.0, NewHeightmapFromImage(rgba).At(0, 0))
+}
+
+func TestColorRamp_At(t *testing.T) {
+	ramp := ColorRamp{
+		{Height: 0, Color: color.RGBA{0, 0, 255, 255}},
+		{Height: 1, Color: color.RGBA{0, 200, 0, 255}},
+		{Height: 3, Color: color.RGBA{255, 255, 255, 255}},
+	}
+
+	assert.Equal(t, color.RGBA{0, 0, 255, 255}, ramp.At(-1))
+	assert.Equal(t, color.RGBA{0, 100, 128, 255}, ramp.At(0.5))
+	assert.Equal(t, color.RGBA{0, 200, 0, 255}, ramp.At(1))
+	assert.Equal(t, color.RGBA{128, 228, 128, 255}, ramp.At(2))
+	assert.Equal(t, color.RGBA{255, 255, 255, 255}, ramp.At(10))
+	assert.Equal(t, color.RGBA{0, 0, 0, 255}, ColorRamp{}.At(1))
+}
+
+func TestNewTerrainObject(t *testing.T) {
+	_, h := NewHeightmap([][]float64{
+		{0, 1, 0},
+		{1, 2, 1},
+		{0, 1, 0},
+	})
+	ramp := ColorRamp{
+		{Height: 0, Color: color.RGBA{0, 0, 0, 255}},
+		{Height: 2, Color: color.RGBA{200, 200, 200, 255}},
+	}
+
+	o := NewTerrainObject(h, Terrain{Spacing: 0.5, HeightScale: 0.1, ColorRamp: ramp})
+
+	assert.Equal(t, 9, o.VertexMatrix.Len())
+	assert.Len(t, o.Triangles, 8)
+	assertFacesMatchNormals(t, o)
+	assertUVInRange(t, o, 1)
+	for i, triangle := range o.Triangles {
+		a := o.VertexMatrix.GetVertex(triangle[0])
+		b := o.VertexMatrix.GetVertex(triangle[1])
+		c := o.VertexMatrix.GetVertex(triangle[2])
+		// 上から見て表面
+		assert.Greater(t, CalcNormalFromPoints(a, b, c).Dot(Vector3D{0, 1, 0}), 0.0, "%v", triangle)
+		// 色は頂点の高さ（HeightScaleを掛ける前）の平均で決まる
+		height := (a[1] + b[1] + c[1]) / 0.1 / 3
+		assert.Equal(t, ramp.At(height), o.TriangleColors[i])
+		assert.NotEqual(t, color.RGBA{0, 0, 0, 255}, o.TriangleColors[i])
+	}
+
+	ok, box := o.BoundingBox()
+	assert.True(t, ok)
+	assert.InDelta(t, 0, box.Min.Sub(Vector3D{-0.5, 0, -0.5}).Distance(), 1e-9)
+	assert.InDelta(t, 0, box.Max.Sub(Vector3D{0.5, 0.2, 0.5}).Distance(), 1e-9)
+
+	// 画像の上側（0行目）が奥（+Z）
+	assert.Equal(t, Vector3D{-0.5, 0, 0.5}, o.VertexMatrix.GetVertex(0))
+}
+
+func TestNewTerrainObject_グレースケール(t *testing.T) {
+	_, h := NewHeightmap([][]float64{{10, 10}, {20, 20}})
+
+	o := NewTerrainObject(h, Terrain{Spacing: 1, HeightScale: 1})
+
+	assert.Len(t, o.Triangles, 2)
+	for i, triangle := range o.Triangles {
+		height := 0.0
+		for _, index := range triangle {
+			height += o.VertexMatrix.GetVertex(index)[1] / 3
+		}
+		gray := uint8(math.Round((height - 10) / 10 * 255))
+		assert.Equal(t, color.RGBA{gray, gray, gray, 255}, o.TriangleColors[i])
+	}
+}
+
+func TestNewTerrainObject_間引き(t *testing.T) {
+	h := newWaveHeightmap(101, 81)
+
+	full := NewTerrainObject(h, Terrain{Spacing: 0.1, HeightScale: 1, MaxVertices: -1})
+	reduced := NewTerrainObject(h, Terrain{Spacing: 0.1, HeightScale: 1, MaxVertices: 1000})
+
+	assert.Equal(t, 101*81, full.VertexMatrix.Len())
+	assert.LessOrEqual(t, reduced.VertexMatrix.Len(), 1000)
+	assert.Greater(t, reduced.VertexMatrix.Len(), 500)
+	assertFacesMatchNormals(t, reduced)
+	assertUVInRange(t, reduced, 1)
+
+	// 端の格子点を残すので、水平方向の範囲は変わらない
+	_, fullBox := full.BoundingBox()
+	_, reducedBox := reduced.BoundingBox()
+	assert.Equal(t, fullBox.Min[0], reducedBox.Min[0])
+	assert.Equal(t, fullBox.Max[0], reducedBox.Max[0])
+	assert.Equal(t, fullBox.Min[2], reducedBox.Min[2])
+	assert.Equal(t, fullBox.Max[2], reducedBox.Max[2])
+}
+
+func TestTerrain_LevelOfDetail(t *testing.T) {
+	assert.Equal(t, 1, Terrain{}.LevelOfDetail(Heightmap{Columns: 256, Rows: 256}))
+	assert.Equal(t, 2, Terrain{}.LevelOfDetail(Heightmap{Columns: 257, Rows: 256}))
+	assert.Equal(t, 1, Terrain{MaxVertices: -1}.LevelOfDetail(Heightmap{Columns: 4096, Rows: 4096}))
+	assert.Equal(t, 4, Terrain{MaxVertices: 100}.LevelOfDetail(Heightmap{Columns: 33, Rows: 33}))
+}
+
+func TestNewTerrainObject_格子点の間隔を指定しない場合(t *testing.T) {
+	_, h := NewHeightmap([][]float64{{0, 1, 2}, {3, 4, 5}})
+
+	o := NewTerrainObject(h, Terrain{HeightScale: 1})
+
+	// 間隔は DefaultTerrainSpacing になる
+	assert.Len(t, o.Triangles, 4)
+	_, box := o.BoundingBox()
+	assert.InDeltaSlice(t, []float64{-1, 0, -0.5}, box.Min[:], 1e-9)
+	assert.InDeltaSlice(t, []float64{1, 5, 0.5}, box.Max[:], 1e-9)
+}
+
+func TestNewTerrainObject_格子点が足りない(t *testing.T) {
+	_, h := NewHeightmap([][]float64{{1, 2, 3}})
+
+	assert.Empty(t, NewTerrainObject(h, Terrain{Spacing: 1, HeightScale: 1}).Triangles)
+}
@@ -0,0 +1,341 @@
+package domain
+
+import (
+	"image/color"
+	"math"
+)
+
+const (
+	// AttributeObjectPosition オブジェクト座標系での頂点の位置（3次元のテクスチャを評価するため、レンダリング時に追加する）
+	AttributeObjectPosition = "object_position"
+	// AttributeInverseW 透視除算で割ったwの逆数（テクスチャ座標を透視補正して補間するため、透視除算時に追加する）
+	AttributeInverseW = "inverse_w"
+)
+
+// Texture は表面の色を決めるテクスチャです
+// Object.Textureに指定すると、レンダリング時にTriangleColorsの代わりに使います
+type Texture interface {
+	// Sample はテクスチャ座標uv（AttributeUV）とオブジェクト座標系の位置pでの色を返します
+	Sample(uv Vector2D, p Vector3D) color.RGBA
+}
+
+// Pattern は2次元（テクスチャ座標）と3次元（オブジェクト座標系）の点に、0から1の値を割り当てる手続き的な模様です
+type Pattern interface {
+	Value2D(u, v float64) float64
+	Value3D(p Vector3D) float64
+}
+
+// TextureSpace は模様を評価する座標です
+type TextureSpace int
+
+const (
+	// TextureSpaceUV テクスチャ座標（AttributeUV）で評価する。属性がない場合は(0, 0)
+	TextureSpaceUV TextureSpace = iota
+	// TextureSpaceObject オブジェクト座標系の位置で評価する（物体を彫り出したような模様になる）
+	TextureSpaceObject
+)
+
+// ProceduralTexture は模様の値をColorRampで色に変換するテクスチャです
+type ProceduralTexture struct {
+	Pattern Pattern
+	// ColorRamp 模様の値（0から1）に対応する色。空の場合は0を黒、1を白とする
+	ColorRamp ColorRamp
+	Space     TextureSpace
+}
+
+// grayRamp は0を黒、1を白とするグラデーションです
+var grayRamp = ColorRamp{
+	{Height: 0, Color: color.RGBA{0, 0, 0, 255}},
+	{Height: 1, Color: color.RGBA{255, 255, 255, 255}},
+}
+
+func (t ProceduralTexture) Sample(uv Vector2D, p Vector3D) color.RGBA {
+	ramp := t.ColorRamp
+	if len(ramp) == 0 {
+		ramp = grayRamp
+	}
+	if t.Space == TextureSpaceObject {
+		return ramp.At(t.Pattern.Value3D(p))
+	}
+	return ramp.At(t.Pattern.Value2D(uv[0], uv[1]))
+}
+
+// NoisePattern はノイズをそのまま模様にします
+type NoisePattern struct {
+	Noise Noise
+	Kind  NoiseKind
+	// Frequency 座標に掛ける倍率（0は1）。大きいほど細かい模様になる
+	Frequency float64
+}
+
+func (p NoisePattern) Value2D(u, v float64) float64 {
+	f := nonZero(p.Frequency, 1)
+	return clamp01(0.5 + 0.5*p.Noise.Sample2D(p.Kind, u*f, v*f))
+}
+
+func (p NoisePattern) Value3D(v Vector3D) float64 {
+	return clamp01(0.5 + 0.5*p.Noise.Sample3D(p.Kind, v.MulScalar(nonZero(p.Frequency, 1))))
+}
+
+// FBmPattern はフラクタルブラウン運動（fBm）の模様です（雲や地形のような模様）
+type FBmPattern struct {
+	Noise     Noise
+	Kind      NoiseKind
+	Frequency float64
+	Fractal   Fractal
+}
+
+func (p FBmPattern) Value2D(u, v float64) float64 {
+	f := nonZero(p.Frequency, 1)
+	return clamp01(0.5 + 0.5*p.Noise.FBm2D(p.Kind, u*f, v*f, p.Fractal))
+}
+
+func (p FBmPattern) Value3D(v Vector3D) float64 {
+	return clamp01(0.5 + 0.5*p.Noise.FBm3D(p.Kind, v.MulScalar(nonZero(p.Frequency, 1)), p.Fractal))
+}
+
+// TurbulencePattern は乱流（ノイズの絶対値の重ね合わせ）の模様です（炎や煙のような模様）
+type TurbulencePattern struct {
+	Noise     Noise
+	Kind      NoiseKind
+	Frequency float64
+	Fractal   Fractal
+}
+
+func (p TurbulencePattern) Value2D(u, v float64) float64 {
+	f := nonZero(p.Frequency, 1)
+	return clamp01(p.Noise.Turbulence2D(p.Kind, u*f, v*f, p.Fractal))
+}
+
+func (p TurbulencePattern) Value3D(v Vector3D) float64 {
+	return clamp01(p.Noise.Turbulence3D(p.Kind, v.MulScalar(nonZero(p.Frequency, 1)), p.Fractal))
+}
+
+// WorleyPattern はセルラーノイズ（最も近い特徴点までの距離）の模様です（細胞や石畳のような模様）
+type WorleyPattern struct {
+	Noise     Noise
+	Frequency float64
+}
+
+func (p WorleyPattern) Value2D(u, v float64) float64 {
+	f := nonZero(p.Frequency, 1)
+	return clamp01(p.Noise.Worley2D(u*f, v*f))
+}
+
+func (p WorleyPattern) Value3D(v Vector3D) float64 {
+	v = v.MulScalar(nonZero(p.Frequency, 1))
+	return clamp01(p.Noise.Worley3D(v[0], v[1], v[2]))
+}
+
+// CheckerPattern は1辺がSize（0は1）の正方形（立方体）を0と1で交互に並べた市松模様です
+type CheckerPattern struct {
+	Size float64
+}
+
+func (p CheckerPattern) Value2D(u, v float64) float64 {
+	return p.Value3D(Vector3D{u, v, 0})
+}
+
+func (p CheckerPattern) Value3D(v Vector3D) float64 {
+	size := nonZero(p.Size, 1)
+	sum := 0
+	for _, value := range v {
+		sum += int(math.Floor(value / size))
+	}
+	if sum%2 == 0 {
+		return 0
+	}
+	return 1
+}
+
+// StripePattern はDirection（零ベクトルの場合はX軸、2次元ではu）の向きに、幅Width（0は1）で0と1を交互に並べた縞模様です
+type StripePattern struct {
+	Width     float64
+	Direction Vector3D
+}
+
+func (p StripePattern) Value2D(u, v float64) float64 {
+	return p.Value3D(Vector3D{u, v, 0})
+}
+
+func (p StripePattern) Value3D(v Vector3D) float64 {
+	direction := p.Direction.Normalize()
+	if direction.Distance() == 0 {
+		direction = Vector3D{1, 0, 0}
+	}
+	if int(math.Floor(v.Dot(direction)/nonZero(p.Width, 1)))%2 == 0 {
+		return 0
+	}
+	return 1
+}
+
+// WoodPattern は木目の模様です
+// Y軸（2次元では原点）を中心とする年輪を、ノイズで揺らがせます
+type WoodPattern struct {
+	Noise Noise
+	Kind  NoiseKind
+	// Rings 単位長さあたりの年輪の数（0は10）
+	Rings float64
+	// Turbulence 年輪の揺らぎの大きさ（年輪の数に対する割合）
+	Turbulence float64
+	// Frequency 揺らぎのノイズの周波数（0は1）
+	Frequency float64
+	Fractal   Fractal
+}
+
+func (p WoodPattern) Value2D(u, v float64) float64 {
+	f := nonZero(p.Frequency, 1)
+	return p.rings(math.Hypot(u, v), p.Noise.FBm2D(p.Kind, u*f, v*f, p.Fractal))
+}
+
+func (p WoodPattern) Value3D(v Vector3D) float64 {
+	return p.rings(math.Hypot(v[0], v[2]), p.Noise.FBm3D(p.Kind, v.MulScalar(nonZero(p.Frequency, 1)), p.Fractal))
+}
+
+// rings は中心からの距離とノイズから年輪の値（年輪ごとに0から1へ変化する）を返します
+func (p WoodPattern) rings(radius, noise float64) float64 {
+	rings := nonZero(p.Rings, 10)
+	value := radius*rings + p.Turbulence*rings*noise
+	return value - math.Floor(value)
+}
+
+// MarblePattern は大理石の模様です
+// X軸方向の正弦波の縞を乱流で揺らがせます
+type MarblePattern struct {
+	Noise Noise
+	Kind  NoiseKind
+	// Stripes 単位長さあたりの縞の数（0は1）
+	Stripes float64
+	// Turbulence 縞の揺らぎの大きさ（縞の数に対する割合。5程度で大理石らしくなる）
+	Turbulence float64
+	// Frequency 揺らぎのノイズの周波数（0は1）
+	Frequency float64
+	Fractal   Fractal
+}
+
+func (p MarblePattern) Value2D(u, v float64) float64 {
+	f := nonZero(p.Frequency, 1)
+	return p.veins(u, p.Noise.Turbulence2D(p.Kind, u*f, v*f, p.Fractal))
+}
+
+func (p MarblePattern) Value3D(v Vector3D) float64 {
+	return p.veins(v[0], p.Noise.Turbulence3D(p.Kind, v.MulScalar(nonZero(p.Frequency, 1)), p.Fractal))
+}
+
+func (p MarblePattern) veins(x, turbulence float64) float64 {
+	phase := 2 * math.Pi * (x*nonZero(p.Stripes, 1) + p.Turbulence*turbulence)
+	return 0.5 + 0.5*math.Sin(phase)
+}
+
+// clamp01 は値を0から1の範囲に収めます
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+// TriangleColor はi番目の三角形の色（TriangleColors）を返します。色がない場合は黒を返します
+func (o Object) TriangleColor(i int) color.RGBA {
+	if i < len(o.TriangleColors) {
+		return o.TriangleColors[i]
+	}
+	return color.RGBA{0, 0, 0, 255} // デフォルト色
+}
+
+// SurfaceColor はi番目の三角形上の点pの色を返します
+// Textureを指定しない場合は三角形の色（TriangleColor）です
+// Textureを指定した場合は、頂点のテクスチャ座標とオブジェクト座標系の位置を重心座標で補間してテクスチャを評価します
+// 透視除算で追加したAttributeInverseWがある場合は透視補正して補間します
+func (o Object) SurfaceColor(i int, p Vector3D) color.RGBA {
+	if o.Texture == nil {
+		return o.TriangleColor(i)
+	}
+
+	triangle := o.Triangles[i]
+	weights := Barycentric(p, o.VertexMatrix.GetVertex(triangle[0]), o.VertexMatrix.GetVertex(triangle[1]), o.VertexMatrix.GetVertex(triangle[2]))
+	if ok, inverseW := o.Attribute(AttributeInverseW); ok {
+		sum := 0.0
+		for k, index := range triangle {
+			weights[k] *= inverseW.Values[index]
+			sum += weights[k]
+		}
+		if sum != 0 {
+			for k := range weights {
+				weights[k] /= sum
+			}
+		}
+	}
+
+	interpolate := func(attribute VertexAttribute, values []float64) {
+		for k, index := range triangle {
+			for c := range values {
+				values[c] += weights[k] * attribute.Values[index*attribute.Size+c]
+			}
+		}
+	}
+	uv := make([]float64, 2)
+	if ok, attribute := o.Attribute(AttributeUV); ok {
+		interpolate(attribute, uv)
+	}
+	position := p[:]
+	if ok, attribute := o.Attribute(AttributeObjectPosition); ok {
+		position = make([]float64, 3)
+		interpolate(attribute, position)
+	}
+	return o.Texture.Sample(Vector2D{uv[0], uv[1]}, Vector3D{position[0], position[1], position[2]})
+}
+
+// Barycentric は三角形(a, b, c)の平面上の点pの重心座標を返します
+// 面積0の三角形では各頂点の重みを等しくします
+func Barycentric(p, a, b, c Vector3D) [3]float64 {
+	v0, v1, v2 := b.Sub(a), c.Sub(a), p.Sub(a)
+	d00, d01, d11 := v0.Dot(v0), v0.Dot(v1), v1.Dot(v1)
+	d20, d21 := v2.Dot(v0), v2.Dot(v1)
+	denominator := d00*d11 - d01*d01
+	if denominator == 0 || math.IsNaN(denominator) {
+		return [3]float64{1.0 / 3, 1.0 / 3, 1.0 / 3}
+	}
+	v := (d11*d20 - d01*d21) / denominator
+	w := (d00*d21 - d01*d20) / denominator
+	return [3]float64{1 - v - w, v, w}
+}
+
+// withTextureAttributes はテクスチャを持つオブジェクトに、オブジェクト座標系の頂点の位置（AttributeObjectPosition）を追加します
+// テクスチャを持たないオブジェクトと、既に追加したオブジェクトはそのまま返します
+func (o Object) withTextureAttributes() Object {
+	if o.Texture == nil {
+		return o
+	}
+	if ok, _ := o.Attribute(AttributeObjectPosition); ok {
+		return o
+	}
+	values := make([]float64, 0, o.VertexMatrix.Len()*3)
+	o.VertexMatrix.EachVertex(func(i int, v Vertex) bool {
+		values = append(values, v[0], v[1], v[2])
+		return true
+	})
+	return o.withAttribute(VertexAttribute{Name: AttributeObjectPosition, Size: 3, Values: values})
+}
+
+// withInverseW はテクスチャを持つオブジェクトに、透視除算で割った各頂点のwの逆数（AttributeInverseW）を追加します
+func (o Object) withInverseW(ws []float64) Object {
+	if o.Texture == nil {
+		return o
+	}
+	values := make([]float64, 0, len(ws))
+	for _, w := range ws {
+		values = append(values, 1/w)
+	}
+	return o.withAttribute(VertexAttribute{Name: AttributeInverseW, Size: 1, Values: values})
+}
+
+// withAttribute は属性を追加（同じ名前の属性がある場合は置き換え）したオブジェクトを返します
+// 元のオブジェクトと属性のスライスを共有しないように作り直します
+func (o Object) withAttribute(attribute VertexAttribute) Object {
+	attributes := make([]VertexAttribute, 0, len(o.Attributes)+1)
+	for _, a := range o.Attributes {
+		if a.Name != attribute.Name {
+			attributes = append(attributes, a)
+		}
+	}
+	o.Attributes = append(attributes, attribute)
+	return o
+}
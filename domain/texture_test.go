@@ -0,0 +1,169 @@
+package domain
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	black = color.RGBA{0, 0, 0, 255}
+	white = color.RGBA{255, 255, 255, 255}
+)
+
+func TestPatterns_値域(t *testing.T) {
+	noise := NewNoise(11)
+	patterns := map[string]Pattern{
+		"noise":      NoisePattern{Noise: noise, Kind: NoiseSimplex, Frequency: 3},
+		"fbm":        FBmPattern{Noise: noise, Frequency: 2},
+		"turbulence": TurbulencePattern{Noise: noise, Kind: NoiseSimplex},
+		"worley":     WorleyPattern{Noise: noise, Frequency: 4},
+		"checker":    CheckerPattern{Size: 0.25},
+		"stripe":     StripePattern{Width: 0.1, Direction: Vector3D{1, 1, 0}},
+		"wood":       WoodPattern{Noise: noise, Turbulence: 0.1},
+		"marble":     MarblePattern{Noise: noise, Turbulence: 5},
+	}
+
+	for name, pattern := range patterns {
+		for _, p := range noiseSamplePoints() {
+			v2 := pattern.Value2D(p[0], p[1])
+			v3 := pattern.Value3D(p)
+			assert.True(t, v2 >= 0 && v2 <= 1, "%s %v %v", name, p, v2)
+			assert.True(t, v3 >= 0 && v3 <= 1, "%s %v %v", name, p, v3)
+		}
+	}
+}
+
+func TestCheckerPattern(t *testing.T) {
+	p := CheckerPattern{Size: 0.5}
+
+	assert.Equal(t, 0.0, p.Value2D(0.25, 0.25))
+	assert.Equal(t, 1.0, p.Value2D(0.75, 0.25))
+	assert.Equal(t, 0.0, p.Value2D(0.75, 0.75))
+	// 負の座標でも交互に並ぶ
+	assert.Equal(t, 1.0, p.Value2D(-0.25, 0.25))
+	assert.Equal(t, 1.0, p.Value3D(Vector3D{0.25, 0.25, 0.75}))
+}
+
+func TestStripePattern(t *testing.T) {
+	p := StripePattern{Width: 0.5, Direction: Vector3D{0, 2, 0}}
+
+	assert.Equal(t, 0.0, p.Value3D(Vector3D{5, 0.25, 5}))
+	assert.Equal(t, 1.0, p.Value3D(Vector3D{-5, 0.75, 5}))
+	assert.Equal(t, 1.0, p.Value3D(Vector3D{0, -0.25, 0}))
+	// 向きを指定しない場合はX軸（u）方向
+	assert.Equal(t, 1.0, StripePattern{}.Value2D(1.5, 0))
+}
+
+func TestWoodPattern_年輪(t *testing.T) {
+	// 揺らぎがない場合は中心からの距離で決まる
+	p := WoodPattern{Rings: 4}
+
+	assert.InDelta(t, 0.5, p.Value3D(Vector3D{0.125, 3, 0}), 1e-9)
+	assert.InDelta(t, 0.5, p.Value3D(Vector3D{0, -1, 0.375}), 1e-9)
+	assert.InDelta(t, 0.5, p.Value2D(0.6*0.625, 0.8*0.625), 1e-9)
+}
+
+func TestMarblePattern_縞(t *testing.T) {
+	// 揺らぎがない場合はX軸方向の正弦波
+	p := MarblePattern{Stripes: 2}
+
+	assert.InDelta(t, 1.0, p.Value3D(Vector3D{0.125, 7, 3}), 1e-9)
+	assert.InDelta(t, 0.0, p.Value2D(0.375, 2), 1e-9)
+}
+
+func TestProceduralTexture_Sample(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	texture := ProceduralTexture{
+		Pattern:   CheckerPattern{},
+		ColorRamp: ColorRamp{{Height: 0, Color: red}, {Height: 1, Color: white}},
+	}
+
+	// テクスチャ座標で評価する
+	assert.Equal(t, red, texture.Sample(Vector2D{0.5, 0.5}, Vector3D{1.5, 0.5, 0.5}))
+	// オブジェクト座標系で評価する
+	texture.Space = TextureSpaceObject
+	assert.Equal(t, white, texture.Sample(Vector2D{0.5, 0.5}, Vector3D{1.5, 0.5, 0.5}))
+	// ColorRampを指定しない場合はグレースケール
+	texture.ColorRamp = nil
+	assert.Equal(t, white, texture.Sample(Vector2D{}, Vector3D{1.5, 0.5, 0.5}))
+}
+
+func TestBarycentric(t *testing.T) {
+	a, b, c := Vector3D{0, 0, 1}, Vector3D{2, 0, 1}, Vector3D{0, 2, 1}
+
+	weights := Barycentric(Vector3D{0.5, 1, 1}, a, b, c)
+
+	assert.InDelta(t, 0.25, weights[0], 1e-12)
+	assert.InDelta(t, 0.25, weights[1], 1e-12)
+	assert.InDelta(t, 0.5, weights[2], 1e-12)
+	assert.Equal(t, [3]float64{1.0 / 3, 1.0 / 3, 1.0 / 3}, Barycentric(a, a, a, a))
+}
+
+func TestObject_SurfaceColor(t *testing.T) {
+	o := NewPlaneObject(2, 2, color.RGBA{1, 2, 3, 255})
+	assert.Equal(t, color.RGBA{1, 2, 3, 255}, o.SurfaceColor(0, Vector3D{}))
+
+	// 頂点の位置を重心座標で補間してテクスチャを評価する
+	o.Texture = ProceduralTexture{Pattern: CheckerPattern{}, Space: TextureSpaceObject}
+	o = o.withTextureAttributes()
+	assert.Equal(t, white, o.SurfaceColor(0, Vector3D{-0.5, 0.5, 0}))
+	assert.Equal(t, black, o.SurfaceColor(1, Vector3D{0.5, 0.25, 0}))
+}
+
+// newTexturedTestPlane は横方向（u）の前半が黒、後半が白のテクスチャを貼った平面を、Y軸の周りに傾けて置きます
+func newTexturedTestPlane() LocatedObject {
+	plane := NewGridPlaneObject(1, 1, 1, 1)
+	plane.CullMode = CullNone
+	plane.Texture = ProceduralTexture{Pattern: StripePattern{Width: 0.5}}
+	return LocatedObject{
+		Location: Vector3D{0, 0, 2},
+		Scale:    Vector3D{1, 1, 1},
+		Rotation: Vector3D{0, math.Pi / 3, 0},
+		Object:   plane,
+	}
+}
+
+func TestWorld_Transform_テクスチャの透視補正(t *testing.T) {
+	for _, clipSpace := range []bool{false, true} {
+		world := newTestWorld(160, 120, newTexturedTestPlane())
+		world.Clipping.ClipSpace = clipSpace
+
+		frameBuffer := world.Transform()
+
+		// 平面の中心（u = 0.5）は画面の中心に映るので、テクスチャの境目も画面の中心になる
+		// 透視補正しない場合は境目が数画素ずれる
+		row := int32(60)
+		left, ok := frameBuffer[FrameBufferKey{X: 77, Y: row}]
+		assert.True(t, ok)
+		right, ok := frameBuffer[FrameBufferKey{X: 82, Y: row}]
+		assert.True(t, ok)
+		assert.NotEqual(t, left.Color, right.Color, "ClipSpace=%v", clipSpace)
+		assert.ElementsMatch(t, []color.RGBA{black, white}, []color.RGBA{left.Color, right.Color})
+	}
+}
+
+func TestWorld_Transform_3次元のテクスチャと断面(t *testing.T) {
+	// オブジェクト座標系の模様は、切断した断面にも続く
+	capColor := color.RGBA{10, 20, 30, 255}
+	tetrahedron := NewTetrahedronObject(0.8)
+	tetrahedron.Texture = ProceduralTexture{Pattern: CheckerPattern{Size: 0.25}, Space: TextureSpaceObject}
+	world := World{
+		LocatedObjects: []LocatedObject{{Location: Vector3D{0, 0, 2}, Scale: Vector3D{1, 1, 1}, Object: tetrahedron}},
+		ClipPlanes:     []ClipPlane{{Point: Vector3D{0, 0, 1.9}, Normal: Vector3D{0, 0, -1}, CapColor: &capColor}},
+		Viewport:       Viewport{Width: 40, Height: 30},
+		Clipping:       Clipping{NearDistance: 0.1, FarDistance: 10.0, FieldOfView: math.Pi / 4},
+	}
+
+	frameBuffer := world.Transform()
+
+	colors := map[color.RGBA]int{}
+	for _, value := range frameBuffer {
+		colors[value.Color]++
+	}
+	assert.Greater(t, colors[black], 0)
+	assert.Greater(t, colors[white], 0)
+	assert.Len(t, colors, 2)
+}
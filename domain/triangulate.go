@@ -0,0 +1,360 @@
+package domain
+
+import (
+	"math"
+	"sort"
+)
+
+// PolygonNormal はNewell法で多角形の単位法線を求めます
+// 向きは三角形(a, b, c)に対する (b-a)×(c-a) と同じです（IsFrontFacingの表面の法線とは逆向き）
+// 面積が0の多角形は零ベクトルを返します
+func PolygonNormal(vertices []Vector3D) Vector3D {
+	normal := Vector3D{}
+	for i := range vertices {
+		p := vertices[i]
+		q := vertices[(i+1)%len(vertices)]
+		normal[0] += (p[1] - q[1]) * (p[2] + q[2])
+		normal[1] += (p[2] - q[2]) * (p[0] + q[0])
+		normal[2] += (p[0] - q[0]) * (p[1] + q[1])
+	}
+	return normal.Normalize()
+}
+
+// TriangulatePolygon は同一平面上にある自己交差しない多角形を三角形に分割します
+// 凹多角形も分割できます。holesには外周の内側にある穴の多角形を渡します（外周・穴同士は交差しないこと）
+// 戻り値は外周、穴の順に頂点を連結したときの添字番号です
+// 三角形の頂点の回り方は外周の頂点の回り方と同じで、穴の頂点の回り方はどちらでも構いません
+func TriangulatePolygon(outer []Vector3D, holes ...[]Vector3D) [][3]int {
+	u, v := planeBasis(PolygonNormal(outer))
+	project := func(vertices []Vector3D) []Vector2D {
+		points := make([]Vector2D, 0, len(vertices))
+		for _, vertex := range vertices {
+			points = append(points, Vector2D{vertex.Dot(u), vertex.Dot(v)})
+		}
+		return points
+	}
+
+	// 法線の向きから見て外周が反時計回りになる平面に投影する
+	projectedHoles := make([][]Vector2D, 0, len(holes))
+	for _, hole := range holes {
+		projectedHoles = append(projectedHoles, project(hole))
+	}
+	return TriangulatePolygon2D(project(outer), projectedHoles...)
+}
+
+// TriangulatePolygon2D は自己交差しない2次元の多角形を耳刈り法で三角形に分割します
+// 凹多角形も分割できます。holesには外周の内側にある穴の多角形を渡します。穴は外周と橋渡しの辺で繋いでから分割します
+// 戻り値は外周、穴の順に頂点を連結したときの添字番号で、三角形は全て反時計回りです
+// 外周・穴の頂点の回り方はどちらでも構いません
+// 穴がない（一直線に並ぶ頂点のない）凸多角形は、クリッピング結果の分割（Triangulate）と同じく最初の頂点から扇状に分割します
+func TriangulatePolygon2D(outer []Vector2D, holes ...[]Vector2D) [][3]int {
+	if len(outer) < 3 {
+		return [][3]int{}
+	}
+
+	points := make([]Vector2D, 0, len(outer))
+	points = append(points, outer...)
+	polygon := make([]int, len(outer))
+	for i := range polygon {
+		polygon[i] = i
+	}
+	// 外周は反時計回り、穴は時計回りに揃える
+	if signedArea2D(outer) < 0 {
+		reverseIndexes(polygon)
+	}
+
+	if len(holes) == 0 && isConvex2D(points, polygon) {
+		if polygon[0] != 0 {
+			// 扇の始点を最初の頂点にする
+			polygon = append([]int{0}, polygon[:len(polygon)-1]...)
+		}
+		triangles := make([][3]int, 0, len(polygon)-2)
+		for i := 1; i < len(polygon)-1; i++ {
+			triangles = append(triangles, [3]int{polygon[0], polygon[i], polygon[i+1]})
+		}
+		return triangles
+	}
+
+	holeIndexes := make([][]int, 0, len(holes))
+	for _, hole := range holes {
+		indexes := make([]int, len(hole))
+		for i := range indexes {
+			indexes[i] = len(points) + i
+		}
+		points = append(points, hole...)
+		if len(hole) < 3 {
+			continue
+		}
+		if signedArea2D(hole) > 0 {
+			reverseIndexes(indexes)
+		}
+		holeIndexes = append(holeIndexes, indexes)
+	}
+
+	// 右端（xが最大）の頂点が右にある穴から順に繋ぐ
+	rightmost := func(indexes []int) int {
+		m := 0
+		for i, index := range indexes {
+			if points[index][0] > points[indexes[m]][0] {
+				m = i
+			}
+		}
+		return m
+	}
+	sort.SliceStable(holeIndexes, func(i, j int) bool {
+		return points[holeIndexes[i][rightmost(holeIndexes[i])]][0] > points[holeIndexes[j][rightmost(holeIndexes[j])]][0]
+	})
+	for _, hole := range holeIndexes {
+		polygon = bridgeHole(points, polygon, hole, rightmost(hole))
+	}
+
+	return earClip(points, polygon)
+}
+
+// bridgeHole は穴のm番目の頂点（右端の頂点）から見える多角形の頂点を探し、橋渡しの辺で繋いだ1つの多角形を返します
+// 多角形は反時計回り、穴は時計回りであることを前提とします（D. Eberly "Triangulation by Ear Clipping"）
+func bridgeHole(points []Vector2D, polygon []int, hole []int, m int) []int {
+	mp := points[hole[m]]
+
+	// Mから+x方向の半直線と最初に交わる辺（内側から外側へ抜ける上向きの辺）を探す
+	edge := -1
+	hitX := math.Inf(1)
+	for i := range polygon {
+		a := points[polygon[i]]
+		b := points[polygon[(i+1)%len(polygon)]]
+		if !(a[1] <= mp[1] && mp[1] <= b[1]) || a[1] == b[1] {
+			continue
+		}
+		x := a[0] + (mp[1]-a[1])*(b[0]-a[0])/(b[1]-a[1])
+		if x < mp[0] || x >= hitX {
+			continue
+		}
+		hitX = x
+		edge = i
+	}
+	if edge < 0 {
+		// 穴が外周の内側にない場合は繋がない
+		return polygon
+	}
+
+	// 交わった辺のうちxが大きい方の端点を候補にする
+	candidate := edge
+	next := (edge + 1) % len(polygon)
+	if points[polygon[next]][0] > points[polygon[candidate]][0] {
+		candidate = next
+	}
+	hit := Vector2D{hitX, mp[1]}
+	cp := points[polygon[candidate]]
+
+	// 三角形(M, 交点, 候補)の中に他の頂点がある場合は、半直線との角度が最も小さい頂点に繋ぐ
+	if hit != cp {
+		bestTan := math.Inf(1)
+		bestDistance := math.Inf(1)
+		for i, index := range polygon {
+			p := points[index]
+			if i == candidate || p == cp || p[0] < mp[0] {
+				continue
+			}
+			if !pointInTriangle2D(mp, hit, cp, p) {
+				continue
+			}
+			tan := math.Abs(p[1]-mp[1]) / (p[0] - mp[0])
+			distance := p.Sub(mp).Distance()
+			if tan < bestTan || (tan == bestTan && distance < bestDistance) {
+				bestTan = tan
+				bestDistance = distance
+				candidate = i
+			}
+		}
+	}
+
+	// 同じ位置の頂点が複数ある（既に繋いだ穴の橋渡し）場合は、Mがその頂点の内角の内側に見えるものを選ぶ
+	cp = points[polygon[candidate]]
+	for i, index := range polygon {
+		if points[index] != cp {
+			continue
+		}
+		if locallyInside2D(points[polygon[(i+len(polygon)-1)%len(polygon)]], cp, points[polygon[(i+1)%len(polygon)]], mp) {
+			candidate = i
+			break
+		}
+	}
+
+	// 候補 → 穴（Mから1周してMに戻る） → 候補 の順に繋ぐ
+	merged := make([]int, 0, len(polygon)+len(hole)+2)
+	merged = append(merged, polygon[:candidate+1]...)
+	for i := 0; i <= len(hole); i++ {
+		merged = append(merged, hole[(m+i)%len(hole)])
+	}
+	merged = append(merged, polygon[candidate:]...)
+	return merged
+}
+
+// earClip は反時計回りの多角形（pointsの添字番号の列）を耳刈り法で三角形に分割します
+func earClip(points []Vector2D, polygon []int) [][3]int {
+	indexes := append([]int{}, polygon...)
+	triangles := make([][3]int, 0, len(indexes)-2)
+	add := func(triangle [3]int) {
+		// 橋渡しで同じ頂点を2回通る場合に潰れた三角形ができるので除く
+		if triangle[0] != triangle[1] && triangle[1] != triangle[2] && triangle[2] != triangle[0] {
+			triangles = append(triangles, triangle)
+		}
+	}
+
+	for len(indexes) > 3 {
+		ear := -1
+		for i := range indexes {
+			prev := indexes[(i+len(indexes)-1)%len(indexes)]
+			next := indexes[(i+1)%len(indexes)]
+			if isEar2D(points, indexes, prev, indexes[i], next) {
+				ear = i
+				break
+			}
+		}
+		if ear < 0 {
+			// 誤差などで耳が見つからない場合は、凸な頂点を他の頂点を含むかに関わらず切り取る
+			for i := range indexes {
+				prev := indexes[(i+len(indexes)-1)%len(indexes)]
+				next := indexes[(i+1)%len(indexes)]
+				if cross2D(points[prev], points[indexes[i]], points[next]) > 0 {
+					ear = i
+					break
+				}
+			}
+		}
+		if ear < 0 {
+			// 退化した多角形で凸な頂点もない場合は残りを扇状に分割する
+			break
+		}
+
+		prev := indexes[(ear+len(indexes)-1)%len(indexes)]
+		next := indexes[(ear+1)%len(indexes)]
+		add([3]int{prev, indexes[ear], next})
+		indexes = append(indexes[:ear], indexes[ear+1:]...)
+	}
+	for i := 1; i < len(indexes)-1; i++ {
+		add([3]int{indexes[0], indexes[i], indexes[i+1]})
+	}
+	return triangles
+}
+
+// nestPolygons2D は互いに交差しない閉じた輪郭を、外周とその内側の穴の組に分けます
+// 戻り値の各要素は輪郭の番号の列で、最初が外周、残りがその直接の穴です
+// 穴の中にある輪郭は再び外周として扱います
+func nestPolygons2D(loops [][]Vector2D) [][]int {
+	depth := make([]int, len(loops))
+	parent := make([]int, len(loops))
+	for i := range loops {
+		parent[i] = -1
+		for j := range loops {
+			if i == j || len(loops[i]) == 0 || !pointInPolygon2D(loops[j], loops[i][0]) {
+				continue
+			}
+			depth[i]++
+			// 含む輪郭のうち最も内側（面積が最小）のものが親
+			if parent[i] < 0 || math.Abs(signedArea2D(loops[j])) < math.Abs(signedArea2D(loops[parent[i]])) {
+				parent[i] = j
+			}
+		}
+	}
+
+	groups := make([][]int, 0, len(loops))
+	groupOf := make(map[int]int, len(loops))
+	for i := range loops {
+		if depth[i]%2 == 0 {
+			groupOf[i] = len(groups)
+			groups = append(groups, []int{i})
+		}
+	}
+	for i := range loops {
+		if depth[i]%2 == 1 && parent[i] >= 0 {
+			group := groupOf[parent[i]]
+			groups[group] = append(groups[group], i)
+		}
+	}
+	return groups
+}
+
+// signedArea2D は多角形の符号付き面積を返します（反時計回りが正）
+func signedArea2D(points []Vector2D) float64 {
+	area := 0.0
+	for i := range points {
+		p := points[i]
+		q := points[(i+1)%len(points)]
+		area += p[0]*q[1] - q[0]*p[1]
+	}
+	return area / 2
+}
+
+// cross2D は点oから見た点a, bの外積を返します（反時計回りが正）
+func cross2D(o, a, b Vector2D) float64 {
+	return (a[0]-o[0])*(b[1]-o[1]) - (a[1]-o[1])*(b[0]-o[0])
+}
+
+// isConvex2D は反時計回りの多角形が厳密に凸かを判定します
+// 一直線に並ぶ頂点がある場合は、扇状に分割すると面積0の三角形ができるため凸とみなしません
+func isConvex2D(points []Vector2D, polygon []int) bool {
+	for i := range polygon {
+		prev := points[polygon[(i+len(polygon)-1)%len(polygon)]]
+		next := points[polygon[(i+1)%len(polygon)]]
+		if cross2D(prev, points[polygon[i]], next) <= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// isEar2D は頂点currentが耳（凸で、他の頂点を含まない三角形の頂点）かを判定します
+// 三角形の頂点と同じ位置にある頂点（穴の橋渡しで重なる頂点）は含まないものとします
+func isEar2D(points []Vector2D, indexes []int, prev, current, next int) bool {
+	a, b, c := points[prev], points[current], points[next]
+	if cross2D(a, b, c) <= 0 {
+		return false
+	}
+	for _, index := range indexes {
+		p := points[index]
+		if index == prev || index == current || index == next || p == a || p == b || p == c {
+			continue
+		}
+		if pointInTriangle2D(a, b, c, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// pointInTriangle2D は点pが三角形(a, b, c)の内側または辺上にあるかを判定します（頂点の回り方はどちらでも構いません）
+func pointInTriangle2D(a, b, c, p Vector2D) bool {
+	d1, d2, d3 := cross2D(a, b, p), cross2D(b, c, p), cross2D(c, a, p)
+	hasNegative := d1 < 0 || d2 < 0 || d3 < 0
+	hasPositive := d1 > 0 || d2 > 0 || d3 > 0
+	return !(hasNegative && hasPositive)
+}
+
+// pointInPolygon2D は点pが多角形の内側にあるかを判定します（交差数判定）
+func pointInPolygon2D(polygon []Vector2D, p Vector2D) bool {
+	inside := false
+	for i := range polygon {
+		a := polygon[i]
+		b := polygon[(i+1)%len(polygon)]
+		if (a[1] > p[1]) != (b[1] > p[1]) && p[0] < a[0]+(p[1]-a[1])*(b[0]-a[0])/(b[1]-a[1]) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// locallyInside2D は反時計回りの多角形の頂点vから点pへの向きが、頂点vの内角の内側にあるかを判定します
+func locallyInside2D(prev, v, next, p Vector2D) bool {
+	if cross2D(prev, v, next) >= 0 {
+		return cross2D(v, next, p) >= 0 && cross2D(v, p, prev) >= 0
+	}
+	return !(cross2D(v, prev, p) > 0 && cross2D(v, p, next) > 0)
+}
+
+// reverseIndexes は添字番号の列を逆順にします
+func reverseIndexes(indexes []int) {
+	for i, j := 0, len(indexes)-1; i < j; i, j = i+1, j-1 {
+		indexes[i], indexes[j] = indexes[j], indexes[i]
+	}
+}
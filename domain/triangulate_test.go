@@ -0,0 +1,201 @@
+package domain
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// assertTriangulation は全ての三角形が反時計回りで、面積の合計が期待する面積と一致することを検証します
+func assertTriangulation(t *testing.T, points []Vector2D, triangles [][3]int, expectedArea float64) {
+	area := 0.0
+	for _, triangle := range triangles {
+		a := cross2D(points[triangle[0]], points[triangle[1]], points[triangle[2]]) / 2
+		assert.Greater(t, a, 0.0, "%v", triangle)
+		area += a
+	}
+	// 三角形が重なったり多角形の外側にはみ出したりすると面積が一致しない
+	assert.InDelta(t, expectedArea, area, 1e-9)
+}
+
+func TestTriangulatePolygon2D_凹多角形(t *testing.T) {
+	// L字型（時計回り）
+	points := []Vector2D{{0, 0}, {0, 2}, {1, 2}, {1, 1}, {2, 1}, {2, 0}}
+
+	triangles := TriangulatePolygon2D(points)
+
+	assert.Len(t, triangles, 4)
+	assertTriangulation(t, points, triangles, 3)
+}
+
+func TestTriangulatePolygon2D_凸多角形は扇状に分割(t *testing.T) {
+	// 時計回りでも最初の頂点から扇状に分割する
+	points := []Vector2D{{0, 0}, {0, 1}, {1, 1}, {1, 0}}
+
+	triangles := TriangulatePolygon2D(points)
+
+	assert.Equal(t, [][3]int{{0, 3, 2}, {0, 2, 1}}, triangles)
+}
+
+func TestTriangulatePolygon2D_一直線に並ぶ頂点がある凸多角形(t *testing.T) {
+	// 下の辺に頂点が並んでいるため、最初の頂点から扇状に分割すると面積0の三角形ができる
+	points := []Vector2D{{0, 0}, {1, 0}, {2, 0}, {3, 0}, {3, 1}, {0, 1}}
+
+	triangles := TriangulatePolygon2D(points)
+
+	assert.Len(t, triangles, 4)
+	assertTriangulation(t, points, triangles, 3)
+}
+
+func TestTriangulatePolygon2D_穴(t *testing.T) {
+	outer := []Vector2D{{0, 0}, {4, 0}, {4, 4}, {0, 4}}
+	// 穴は外周と同じ回り方でもよい
+	hole := []Vector2D{{1, 1}, {3, 1}, {3, 3}, {1, 3}}
+
+	points := append(append([]Vector2D{}, outer...), hole...)
+
+	triangles := TriangulatePolygon2D(outer, hole)
+
+	// n + 2h - 2 = 8 + 2 - 2
+	assert.Len(t, triangles, 8)
+	assertTriangulation(t, points, triangles, 16-4)
+	for _, triangle := range triangles {
+		// 穴の内側を覆う三角形がない
+		centroid := points[triangle[0]].Add(points[triangle[1]]).Add(points[triangle[2]]).MulScalar(1.0 / 3)
+		assert.False(t, pointInPolygon2D(hole, centroid), "%v", triangle)
+	}
+}
+
+func TestTriangulatePolygon2D_複数の穴(t *testing.T) {
+	// 凹多角形（U字型）の中に3つの穴
+	outer := []Vector2D{{0, 0}, {9, 0}, {9, 6}, {6, 6}, {6, 3}, {3, 3}, {3, 6}, {0, 6}}
+	holes := [][]Vector2D{
+		{{1, 1}, {2, 1}, {2, 2}, {1, 2}},
+		{{4, 1}, {5, 1}, {5, 2}, {4, 2}},
+		{{7, 1}, {8, 1}, {8, 5}, {7, 5}},
+	}
+	points := append([]Vector2D{}, outer...)
+	for _, hole := range holes {
+		points = append(points, hole...)
+	}
+
+	triangles := TriangulatePolygon2D(outer, holes...)
+
+	assert.Len(t, triangles, len(points)+2*len(holes)-2)
+	assertTriangulation(t, points, triangles, 54-9-1-1-4)
+}
+
+func TestTriangulatePolygon2D_頂点が足りない(t *testing.T) {
+	assert.Empty(t, TriangulatePolygon2D([]Vector2D{{0, 0}, {1, 0}}))
+}
+
+func TestPolygonNormal(t *testing.T) {
+	// 反時計回り（(b-a)×(c-a) が+Z）
+	vertices := []Vector3D{{0, 0, 0}, {2, 0, 0}, {2, 1, 0}, {1, 0.5, 0}, {0, 1, 0}}
+
+	assert.Equal(t, Vector3D{0, 0, 1}, PolygonNormal(vertices))
+	assert.Equal(t, Vector3D{}, PolygonNormal([]Vector3D{{0, 0, 0}, {1, 1, 1}, {2, 2, 2}}))
+}
+
+func TestTriangulatePolygon_傾いた平面の凹多角形(t *testing.T) {
+	// L字型をX軸の周りに傾けた平面に置く
+	angle := math.Pi / 3
+	outer := make([]Vector3D, 0, 6)
+	for _, p := range []Vector2D{{0, 0}, {2, 0}, {2, 1}, {1, 1}, {1, 2}, {0, 2}} {
+		outer = append(outer, Vector3D{p[0], p[1] * math.Cos(angle), p[1] * math.Sin(angle)})
+	}
+	normal := PolygonNormal(outer)
+
+	triangles := TriangulatePolygon(outer)
+
+	assert.Len(t, triangles, 4)
+	area := 0.0
+	for _, triangle := range triangles {
+		a, b, c := outer[triangle[0]], outer[triangle[1]], outer[triangle[2]]
+		cross := b.Sub(a).Cross(c.Sub(a))
+		// 三角形の回り方は外周の回り方と同じ
+		assert.Greater(t, cross.Dot(normal), 0.0, "%v", triangle)
+		area += cross.Distance() / 2
+	}
+	assert.InDelta(t, 3.0, area, 1e-9)
+}
+
+func TestTriangulatePolygon_穴(t *testing.T) {
+	outer := []Vector3D{{0, 0, 1}, {0, 4, 1}, {0, 4, 5}, {0, 0, 5}}
+	hole := []Vector3D{{0, 1, 2}, {0, 3, 2}, {0, 3, 4}, {0, 1, 4}}
+	vertices := append(append([]Vector3D{}, outer...), hole...)
+	normal := PolygonNormal(outer)
+
+	triangles := TriangulatePolygon(outer, hole)
+
+	assert.Len(t, triangles, 8)
+	area := 0.0
+	for _, triangle := range triangles {
+		a, b, c := vertices[triangle[0]], vertices[triangle[1]], vertices[triangle[2]]
+		cross := b.Sub(a).Cross(c.Sub(a))
+		assert.Greater(t, cross.Dot(normal), 0.0, "%v", triangle)
+		area += cross.Distance() / 2
+	}
+	assert.InDelta(t, 12.0, area, 1e-9)
+}
+
+func TestTriangulatePolygon_最初の頂点が凹んだ多角形(t *testing.T) {
+	// 扇状に分割すると多角形の外側にはみ出す形（頂点0が凹んだ頂点）
+	vertices := []Vector3D{{1, 1, 0}, {0, 2, 0}, {0, 0, 0}, {2, 0, 0}, {2, 2, 0}}
+
+	result := TriangulatePolygon(vertices)
+
+	assert.Len(t, result, 3)
+	area := 0.0
+	for _, indexes := range result {
+		triangle := [3]Vector3D{vertices[indexes[0]], vertices[indexes[1]], vertices[indexes[2]]}
+		cross := triangle[1].Sub(triangle[0]).Cross(triangle[2].Sub(triangle[0]))
+		assert.Greater(t, cross[2], 0.0, "%v", triangle)
+		area += cross.Distance() / 2
+	}
+	assert.InDelta(t, 3.0, area, 1e-9)
+}
+
+func TestNestPolygons2D(t *testing.T) {
+	square := func(min, max float64) []Vector2D {
+		return []Vector2D{{min, min}, {max, min}, {max, max}, {min, max}}
+	}
+	loops := [][]Vector2D{
+		square(2, 3),   // 穴の中の島
+		square(0, 5),   // 外周
+		square(1, 4),   // 穴
+		square(10, 11), // 別の外周
+	}
+
+	groups := nestPolygons2D(loops)
+
+	assert.ElementsMatch(t, [][]int{{0}, {1, 2}, {3}}, groups)
+}
+
+func TestClipPlane_SectionObject_穴のある断面(t *testing.T) {
+	capColor := color.RGBA{10, 20, 30, 255}
+	plane := ClipPlane{Normal: Vector3D{0, 1, 0}, CapColor: &capColor}
+	torus := NewTorusObject(1, 0.3, 32, 12)
+
+	result := plane.SectionObject(torus)
+
+	assertWeldedClosedMesh(t, result)
+	area := 0.0
+	for i, triangle := range result.Triangles {
+		if result.TriangleColors[i] != capColor {
+			continue
+		}
+		a := result.VertexMatrix.GetVertex(triangle[0])
+		b := result.VertexMatrix.GetVertex(triangle[1])
+		c := result.VertexMatrix.GetVertex(triangle[2])
+		area += b.Sub(a).Cross(c.Sub(a)).Distance() / 2
+		// 穴（トーラスの内側）を塞ぐ三角形がない
+		centroid := a.Add(b).Add(c).MulScalar(1.0 / 3)
+		assert.Greater(t, math.Hypot(centroid[0], centroid[2]), 0.7-1e-9, "%v", triangle)
+	}
+	// 円環の面積（多角形で近似しているので少し小さい）
+	expected := math.Pi * (1.3*1.3 - 0.7*0.7)
+	assert.InDelta(t, expected, area, expected*0.02)
+}
@@ -0,0 +1,138 @@
+package domain
+
+import (
+	"math"
+)
+
+// Turntable はオブジェクトの周りを1周するカメラの設定を表します
+type Turntable struct {
+	// Frames 1周のフレーム数
+	Frames int
+	// FPS 1秒あたりのフレーム数
+	FPS float64
+	// Elevation カメラの仰角(単位：ラジアン)。正の値で上から見下ろす
+	Elevation float64
+	// Margin 境界球の半径に対する余白の割合（0.1なら半径の1.1倍が画面に収まる）
+	Margin float64
+}
+
+// Forward はカメラの前方向の単位ベクトルをワールド座標系で返します
+// カメラ座標変換の回転行列は直交行列なので、転置（逆変換）でカメラ座標系のZ軸を回転させます
+func (c Camera) Forward() Vector3D {
+	return NewRotateMatrix4(-c.Direction.X(), -c.Direction.Y(), -c.Direction.Z()).
+		Transpose().
+		MulPoint(Vector3D{0, 0, 1})
+}
+
+// Bounds はLocatedObjectsとシーングラフの全オブジェクトを囲む、ワールド座標系の軸平行境界ボックスを返します
+// オブジェクトを1つも持たない場合はfalseを返します
+func (w World) Bounds() (bool, Vector3D, Vector3D) {
+	found := false
+	bounds := BoundingBox{}
+
+	w.EachObject(func(obj Object, modelMatrix Matrix4) {
+		if ok, _ := obj.BoundingBox(); !ok {
+			return
+		}
+		obj.VertexMatrix.TransformMatrix4(modelMatrix)
+		_, box := obj.BoundingBox()
+		if found {
+			bounds = bounds.Union(box)
+		} else {
+			found, bounds = true, box
+		}
+	})
+
+	if !found {
+		return false, Vector3D{}, Vector3D{}
+	}
+	return true, bounds.Min, bounds.Max
+}
+
+// VisibleSlope は深度zの点が画面に映る、カメラ座標系での垂直・水平方向の傾き（y/z, x/z）の上限を返します
+// レイトレースは透視投影後の空間で原点からレイを飛ばすため、映る範囲は投影後の深度に応じて狭くなります
+func (w World) VisibleSlope(z float64) (float64, float64) {
+	aspect := float64(w.Viewport.Width) / float64(w.Viewport.Height)
+	tan := math.Tan(w.Clipping.FieldOfView / 2)
+	depth := w.ProjectDepth(z)
+	return tan * tan * depth, aspect * aspect * tan * tan * depth
+}
+
+// FitDistance は半径radiusの球全体が画面に映るカメラからの距離を返します
+// 垂直・水平方向のうち映る範囲が狭い方に合わせます
+func (w World) FitDistance(radius float64) float64 {
+	fits := func(distance float64) bool {
+		// 球の接線の傾きが、最も手前の点の深度で映る範囲に収まるか
+		vertical, horizontal := w.VisibleSlope(distance - radius)
+		slope := radius / math.Sqrt(distance*distance-radius*radius)
+		return slope <= math.Min(vertical, horizontal)
+	}
+
+	low := radius + w.Clipping.NearDistance
+	high := low * 2
+	for !fits(high) {
+		low, high = high, high*2
+	}
+	// 距離が遠いほど収まりやすいため二分法で求める
+	for i := 0; i < 100; i++ {
+		mid := (low + high) / 2
+		if fits(mid) {
+			high = mid
+		} else {
+			low = mid
+		}
+	}
+	return high
+}
+
+// Turntable はワールド全体の中心を注視しながら周りを1周するカメラアニメーションを設定したワールドを返します
+// カメラの距離は境界球が画面に収まるように決め、後方クリップ面が境界球に届かない場合は遠ざけます
+// オブジェクトを1つも持たない場合と、FramesかFPSが0以下の場合はfalseを返します
+func (w World) Turntable(tt Turntable) (bool, World) {
+	if tt.Frames <= 0 || !(tt.FPS > 0) {
+		return false, w
+	}
+	ok, min, max := w.Bounds()
+	if !ok {
+		return false, w
+	}
+
+	center := min.Add(max).MulScalar(0.5)
+	radius := max.Sub(min).Distance() / 2 * (1 + tt.Margin)
+	distance := w.FitDistance(radius)
+	// 後方クリップ面を遠ざけると投影後の深度が変わるため、距離を求め直す
+	for w.Clipping.FarDistance < distance+radius {
+		w.Clipping.FarDistance = (distance + radius) * 1.1
+		distance = w.FitDistance(radius)
+	}
+
+	duration := FrameTime(tt.Frames, tt.FPS)
+	animation := &CameraAnimation{
+		// Y軸周りの回転は線形補間でちょうど1周する
+		Direction: Track[Vector3D]{
+			{Time: 0, Value: Vector3D{tt.Elevation, 0, 0}},
+			{Time: duration, Value: Vector3D{tt.Elevation, 2 * math.Pi, 0}},
+		},
+	}
+	// 位置は円周上を移動するため、線形補間で弦を通らないようにフレームごとにキーフレームを置く
+	for i := 0; i <= tt.Frames; i++ {
+		camera := Camera{Direction: Vector3D{tt.Elevation, 2 * math.Pi * float64(i) / float64(tt.Frames), 0}}
+		animation.Location = append(animation.Location, Keyframe[Vector3D]{
+			Time:  FrameTime(i, tt.FPS),
+			Value: center.Sub(camera.Forward().MulScalar(distance)),
+		})
+	}
+
+	w.Camera.Animation = animation
+	return true, w
+}
+
+// RenderTurntable はワールドの周りを1周するフレームを順にレンダリングします
+// オブジェクトを1つも持たない場合と、FramesかFPSが0以下の場合はfalseを返します
+func (w World) RenderTurntable(tt Turntable) (bool, FrameSequence) {
+	ok, world := w.Turntable(tt)
+	if !ok {
+		return false, FrameSequence{}
+	}
+	return true, world.RenderSequence(tt.Frames, tt.FPS)
+}
@@ -0,0 +1,171 @@
+package domain
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTurntableTestObject は原点から離れた位置に四面体を置きます
+func newTurntableTestObject() LocatedObject {
+	return LocatedObject{
+		Location: Vector3D{3, 1, 5},
+		Scale:    Vector3D{1, 1, 1},
+		Object:   NewTetrahedronObject(0.5),
+	}
+}
+
+func TestCamera_Forward(t *testing.T) {
+	// 回転しない場合は+Z方向
+	result := Camera{}.Forward()
+	assert.InDelta(t, 0.0, result.X(), 1e-9)
+	assert.InDelta(t, 0.0, result.Y(), 1e-9)
+	assert.InDelta(t, 1.0, result.Z(), 1e-9)
+
+	// 前方の点はカメラ座標系で+Z軸上になる
+	camera := Camera{Location: Vector3D{1, 2, 3}, Direction: Vector3D{0.3, -1.2, 0.4}}
+	point := camera.Location.Add(camera.Forward().MulScalar(2))
+	result = camera.ViewMatrix().MulPoint(point)
+	assert.InDelta(t, 0.0, result.X(), 1e-9)
+	assert.InDelta(t, 0.0, result.Y(), 1e-9)
+	assert.InDelta(t, 2.0, result.Z(), 1e-9)
+}
+
+func TestWorld_Bounds(t *testing.T) {
+	plane := NewPlaneObject(2, 2, color.RGBA{})
+	world := World{
+		LocatedObjects: []LocatedObject{
+			{
+				Location: Vector3D{1, 0, 0},
+				Scale:    Vector3D{1, 1, 1},
+				Object:   NewPlaneObject(2, 2, color.RGBA{}),
+			},
+		},
+		Scene: []SceneNode{
+			{
+				Location: Vector3D{0, 0, 5},
+				Scale:    Vector3D{1, 1, 1},
+				Object:   &plane,
+			},
+		},
+	}
+
+	ok, min, max := world.Bounds()
+
+	assert.True(t, ok)
+	assert.InDelta(t, -1.0, min.X(), 1e-9)
+	assert.InDelta(t, -1.0, min.Y(), 1e-9)
+	assert.InDelta(t, 0.0, min.Z(), 1e-9)
+	assert.InDelta(t, 2.0, max.X(), 1e-9)
+	assert.InDelta(t, 1.0, max.Y(), 1e-9)
+	assert.InDelta(t, 5.0, max.Z(), 1e-9)
+
+	ok, _, _ = World{}.Bounds()
+	assert.False(t, ok)
+}
+
+func TestWorld_VisibleSlope(t *testing.T) {
+	world := World{
+		Viewport: Viewport{Width: 200, Height: 100},
+		Clipping: Clipping{NearDistance: 1, FarDistance: 3, FieldOfView: math.Pi / 2},
+	}
+
+	// 投影後の深度が1（後方クリップ面）の場合は視野角と同じ範囲になる
+	vertical, horizontal := world.VisibleSlope(3)
+	assert.InDelta(t, 1.0, vertical, 1e-9)
+	assert.InDelta(t, 4.0, horizontal, 1e-9)
+
+	// 手前ほど映る範囲は狭くなる
+	vertical, _ = world.VisibleSlope(2)
+	assert.InDelta(t, world.ProjectDepth(2), vertical, 1e-9)
+}
+
+func TestWorld_FitDistance(t *testing.T) {
+	world := newTestWorld(32, 24, newTurntableTestObject())
+	world.Clipping.FarDistance = 100
+
+	distance := world.FitDistance(1)
+
+	// 最も手前の点の深度で映る範囲に、球の接線の傾きがちょうど収まる
+	vertical, horizontal := world.VisibleSlope(distance - 1)
+	assert.InDelta(t, math.Min(vertical, horizontal), 1/math.Sqrt(distance*distance-1), 1e-9)
+
+	// 縦長の場合は水平方向に合わせるため遠くなる
+	world.Viewport = Viewport{Width: 24, Height: 32}
+	assert.Greater(t, world.FitDistance(1), distance)
+}
+
+func TestWorld_Turntable(t *testing.T) {
+	world := newTestWorld(32, 24, newTurntableTestObject())
+	world.Clipping.FarDistance = 2.0
+	tt := Turntable{Frames: 8, FPS: 4, Elevation: 0.3, Margin: 0.1}
+
+	ok, result := world.Turntable(tt)
+	assert.True(t, ok)
+
+	_, min, max := world.Bounds()
+	center := min.Add(max).MulScalar(0.5)
+	radius := max.Sub(min).Distance() / 2 * 1.1
+	distance := result.FitDistance(radius)
+	// 後方クリップ面は境界球が収まるまで遠ざける
+	assert.GreaterOrEqual(t, result.Clipping.FarDistance, distance+radius)
+
+	for i := 0; i < tt.Frames; i++ {
+		camera := result.At(FrameTime(i, tt.FPS)).Camera
+
+		// 中心から一定の距離にある
+		assert.InDelta(t, distance, camera.Location.DistanceTo(center), 1e-6)
+		// 常に中心を向いている
+		toCenter := camera.ViewMatrix().MulPoint(center)
+		assert.InDelta(t, 0.0, toCenter.X(), 1e-6)
+		assert.InDelta(t, 0.0, toCenter.Y(), 1e-6)
+		// 上から見下ろしている
+		assert.Greater(t, camera.Location.Y(), center.Y())
+	}
+
+	// 1周すると最初の位置に戻る
+	first := result.At(0).Camera.Location
+	last := result.At(FrameTime(tt.Frames, tt.FPS)).Camera.Location
+	assert.InDelta(t, 0.0, first.DistanceTo(last), 1e-9)
+
+	// 元のワールドは変更されない
+	assert.Nil(t, world.Camera.Animation)
+}
+
+func TestWorld_Turntable_オブジェクトがない場合(t *testing.T) {
+	ok, _ := World{}.Turntable(Turntable{Frames: 4, FPS: 4})
+
+	assert.False(t, ok)
+}
+
+func TestWorld_Turntable_フレーム数とFPSが正ではない場合(t *testing.T) {
+	world := newTestWorld(32, 24, newTurntableTestObject())
+
+	for _, tt := range []Turntable{{Frames: 0, FPS: 4}, {Frames: -1, FPS: 4}, {Frames: 4, FPS: 0}, {Frames: 4, FPS: -4}, {Frames: 4, FPS: math.NaN()}} {
+		ok, result := world.Turntable(tt)
+		assert.False(t, ok, "%v", tt)
+		assert.Nil(t, result.Camera.Animation)
+	}
+}
+
+func TestWorld_RenderTurntable(t *testing.T) {
+	world := newTestWorld(32, 24, newTurntableTestObject())
+	world.Clipping.FarDistance = 2.0
+
+	ok, result := world.RenderTurntable(Turntable{Frames: 4, FPS: 4, Margin: 0.1})
+
+	assert.True(t, ok)
+	assert.Len(t, result.Frames, 4)
+	for _, frame := range result.Frames {
+		// オブジェクトが画面内に描画され、画面からはみ出さない
+		assert.NotEmpty(t, frame)
+		for key := range frame {
+			assert.Greater(t, key.X, int32(0))
+			assert.Less(t, key.X, int32(31))
+			assert.Greater(t, key.Y, int32(0))
+			assert.Less(t, key.Y, int32(23))
+		}
+	}
+}
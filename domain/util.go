@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"image/color"
 	"math"
 	"sort"
 
@@ -166,8 +167,12 @@ func CrossVecDense(a, b mat.VecDense) mat.VecDense {
 }
 
 // NormalizeVecDense はベクトルを正規化します
+// 長さが0のベクトルは零ベクトルのまま返します
 func NormalizeVecDense(v mat.VecDense) mat.VecDense {
 	norm := v.Norm(2)
+	if norm == 0 {
+		return *mat.NewVecDense(3, nil)
+	}
 	return *mat.NewVecDense(3, []float64{
 		v.At(0, 0) / norm,
 		v.At(1, 0) / norm,
@@ -185,22 +190,87 @@ func ClassifyEdgeByPlane(targetP Vector3D, planeNormal Vector3D, pInPlane Vector
 	return result < 0
 }
 
+// ClippingEpsilon はクリッピングで平面上にあるとみなす距離の許容誤差です
+// 平面からの距離がこの値以下の点は内側として扱い、平面上の頂点から長さ0の辺や面積0の三角形が作られるのを防ぎます
+const ClippingEpsilon = 1e-9
+
+// PlaneSignedDistance は点から平面までの符号付き距離を返します
+// 法線の向きの側が正になります。法線の長さが0の場合は0を返します
+func PlaneSignedDistance(targetP Vector3D, planeNormal Vector3D, pInPlane Vector3D) float64 {
+	length := planeNormal.Distance()
+	if length == 0 {
+		return 0
+	}
+	return planeNormal.Dot(targetP.Sub(pInPlane)) / length
+}
+
+// ClassifyEdgeByPlaneWithEpsilon は許容誤差を考慮して点が平面のどちら側にあるかを判定します
+// 平面からの距離がepsilon以下（平面上を含む）の場合は内側としてtrueを返します
+func ClassifyEdgeByPlaneWithEpsilon(targetP Vector3D, planeNormal Vector3D, pInPlane Vector3D, epsilon float64) bool {
+	return PlaneSignedDistance(targetP, planeNormal, pInPlane) <= epsilon
+}
+
 // IntersectPlaneIntersectionPoint は平面と線分の交点を計算します
 func IntersectPlaneIntersectionPoint(planeNormal Vector3D, planePoint Vector3D, fromVertex, toVertex Vector3D) Vector3D {
+	t := IntersectPlaneParameter(planeNormal, planePoint, fromVertex, toVertex)
+
+	p := fromVertex.Add(toVertex.Sub(fromVertex).MulScalar(t))
+
+	return p
+}
+
+// IntersectPlaneParameter は平面と線分の交点の位置を、始点を0・終点を1とした比率で返します
+// 頂点属性の補間にも同じ比率を使います
+func IntersectPlaneParameter(planeNormal Vector3D, planePoint Vector3D, fromVertex, toVertex Vector3D) float64 {
 	d := -(planeNormal[0]*planePoint[0] + planeNormal[1]*planePoint[1] + planeNormal[2]*planePoint[2])
 
 	f := func(v Vector3D) float64 {
 		return v[0]*planeNormal[0] + v[1]*planeNormal[1] + v[2]*planeNormal[2] + d
 	}
 
-	t := -f(fromVertex) / (f(toVertex) - f(fromVertex))
+	return ClipParameter(f(fromVertex), f(toVertex))
+}
 
-	p := fromVertex.Add(toVertex.Sub(fromVertex).MulScalar(t))
+// ClipParameter は平面からの距離が異なる2点を結ぶ線分と平面の交点の比率（始点を0・終点を1）を返します
+// 平面と平行な線分（距離が同じ）の場合は始点を返し、誤差で範囲外になった比率は0から1に収めます
+func ClipParameter(fromDistance, toDistance float64) float64 {
+	denominator := fromDistance - toDistance
+	if denominator == 0 || math.IsNaN(denominator) || math.IsInf(denominator, 0) {
+		return 0
+	}
+	t := fromDistance / denominator
+	if math.IsNaN(t) {
+		return 0
+	}
+	return math.Min(math.Max(t, 0), 1)
+}
 
-	return p
+// IsDegenerateTriangle は三角形が退化している（面積がほぼ0か、座標にNaN・無限大を含む）かを判定します
+// 辺の長さに対する相対的な面積で判定するため、オブジェクトの大きさに依存しません
+func IsDegenerateTriangle(triangle [3]Vector3D) bool {
+	for _, v := range triangle {
+		if !IsFiniteVector3D(v) {
+			return true
+		}
+	}
+	e1 := triangle[1].Sub(triangle[0])
+	e2 := triangle[2].Sub(triangle[0])
+	scale := e1.Dot(e1) + e2.Dot(e2)
+	return scale == 0 || e1.Cross(e2).Distance() <= ClippingEpsilon*scale
+}
+
+// IsFiniteVector3D はベクトルの全ての要素がNaN・無限大ではないかを判定します
+func IsFiniteVector3D(v Vector3D) bool {
+	for _, value := range v {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return false
+		}
+	}
+	return true
 }
 
-// Triangulate は多角形を三角形に分割します
+// Triangulate は凸多角形（クリッピングの結果）を最初の頂点から扇状に三角形に分割します
+// 凹多角形や穴のある多角形は TriangulatePolygon で分割します
 func Triangulate(vertices []Vector3D) [][3]Vector3D {
 	if len(vertices) < 3 {
 		return [][3]Vector3D{}
@@ -245,7 +315,19 @@ func CleanEdges(edges [][2]int) [][2]int {
 }
 
 func CleanTriangles(triangles [][3]int) [][3]int {
+	newTriangles, _ := CleanTrianglesWithColors(triangles, nil)
+	return newTriangles
+}
+
+// CleanTrianglesWithColors は同じ頂点を含む三角形と重複する三角形を破棄します
+// 残した三角形の色も同じ順番で返すため、三角形と色の対応がずれません
+// 色が足りない三角形にはデフォルト色を使います。triangleColorsがnilの場合はnilを返します
+func CleanTrianglesWithColors(triangles [][3]int, triangleColors []color.RGBA) ([][3]int, []color.RGBA) {
 	newTriangles := make([][3]int, 0, len(triangles))
+	var newTriangleColors []color.RGBA
+	if triangleColors != nil {
+		newTriangleColors = make([]color.RGBA, 0, len(triangles))
+	}
 	existMap := make(map[[3]int]bool, len(triangles))
 
 	makeKey := func(triangle [3]int) [3]int {
@@ -254,7 +336,7 @@ func CleanTriangles(triangles [][3]int) [][3]int {
 		return [3]int{tmp[0], tmp[1], tmp[2]}
 	}
 
-	for _, triangle := range triangles {
+	for i, triangle := range triangles {
 		if triangle[0] == triangle[1] || triangle[1] == triangle[2] || triangle[2] == triangle[0] {
 			// ３つの頂点の添字のうち、同じ添字を持っているものは破棄する
 			continue
@@ -268,9 +350,16 @@ func CleanTriangles(triangles [][3]int) [][3]int {
 
 		existMap[key] = true
 		newTriangles = append(newTriangles, triangle)
+		if triangleColors != nil {
+			if i < len(triangleColors) {
+				newTriangleColors = append(newTriangleColors, triangleColors[i])
+			} else {
+				newTriangleColors = append(newTriangleColors, color.RGBA{0, 0, 0, 255}) // デフォルト色
+			}
+		}
 	}
 
-	return newTriangles
+	return newTriangles, newTriangleColors
 }
 
 // IntersectRayTriangle レイと三角形が交差するかを調べます
@@ -279,6 +368,12 @@ func CleanTriangles(triangles [][3]int) [][3]int {
 // 交差しない場合はfalseと零ベクトルを返します
 // 三角形の向きは右ねじの法則で判別します
 func IntersectRayTriangle(rayDirection Vector3D, vertexMatrix VartexMatrix, triangle [3]int) (bool, Vector3D) {
+	return IntersectRayTriangleFrom(NewZeroVector3D(), rayDirection, vertexMatrix, triangle)
+}
+
+// IntersectRayTriangleFrom は任意の始点から出るレイと三角形が交差するかを調べます
+// 判定方法はIntersectRayTriangleと同じです
+func IntersectRayTriangleFrom(rayOrigin Vector3D, rayDirection Vector3D, vertexMatrix VartexMatrix, triangle [3]int) (bool, Vector3D) {
 	v1 := vertexMatrix.GetVertex(triangle[0])
 	v2 := vertexMatrix.GetVertex(triangle[2]) // 左手座標系なのでv2とv3を入れ替えてます。
 	v3 := vertexMatrix.GetVertex(triangle[1])
@@ -288,11 +383,12 @@ func IntersectRayTriangle(rayDirection Vector3D, vertexMatrix VartexMatrix, tria
 	p := rayDirection.Cross(e2)
 	det := e1.Dot(p)
 
-	if det < 0 {
+	// 真横から見た三角形や面積0の三角形（det = 0）、NaNを含む三角形も交差しないと判定する
+	if !(det > 0) {
 		return false, Vector3D{}
 	}
 
-	tvec := NewZeroVector3D().Sub(v1)
+	tvec := rayOrigin.Sub(v1)
 	u := (tvec.Dot(p)) / det
 
 	if u < 0.0 || u > 1.0 {
@@ -310,5 +406,5 @@ func IntersectRayTriangle(rayDirection Vector3D, vertexMatrix VartexMatrix, tria
 		return false, Vector3D{}
 	}
 
-	return true, rayDirection.MulScalar(t)
+	return true, rayOrigin.Add(rayDirection.MulScalar(t))
 }
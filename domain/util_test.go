@@ -91,6 +91,16 @@ func TestIntersectPlaneIntersectionPoint(t *testing.T) {
 	assert.InDelta(t, 0, result.Z(), 0.001)
 }
 
+func TestIntersectPlaneParameter(t *testing.T) {
+	// 平面 z = 1 と、点(0, 0, 0)から点(0, 0, 4)への線分
+	planeNormal := Vector3D{0, 0, 1}
+	planePoint := Vector3D{0, 0, 1}
+
+	result := IntersectPlaneParameter(planeNormal, planePoint, Vector3D{0, 0, 0}, Vector3D{0, 0, 4})
+
+	assert.InDelta(t, 0.25, result, 1e-9)
+}
+
 func TestTriangulate_LessThanThreeVertices(t *testing.T) {
 	// 頂点が3つ未満の場合のテストケース
 	// 空のスライスや2つの頂点の場合、空の三角形配列が返されるべき
@@ -0,0 +1,307 @@
+package domain
+
+import (
+	"slices"
+)
+
+// MeshIssueKind はメッシュの問題の種類です
+type MeshIssueKind int
+
+const (
+	// MeshIssueInvalidIndex 頂点の添字番号が範囲外の三角形
+	MeshIssueInvalidIndex MeshIssueKind = iota
+	// MeshIssueDegenerateTriangle 面積が0の三角形（同じ頂点を含む三角形やNaN・無限大の座標を含む三角形も含む）
+	MeshIssueDegenerateTriangle
+	// MeshIssueDuplicateVertex 位置と頂点属性が同じ頂点
+	MeshIssueDuplicateVertex
+	// MeshIssueFlippedTriangle 辺を共有する周りの三角形と向きが逆の三角形
+	MeshIssueFlippedTriangle
+	// MeshIssueHole 1つの三角形だけが使う辺を繋いだ輪（穴）
+	MeshIssueHole
+	// MeshIssueTJunction 境界の辺の途中にある頂点（T字の継ぎ目）
+	MeshIssueTJunction
+	// MeshIssueNonManifoldEdge 3つ以上の三角形が共有する辺
+	MeshIssueNonManifoldEdge
+	// MeshIssueNonManifoldVertex 周りの三角形が1つの扇形に繋がっていない頂点
+	MeshIssueNonManifoldVertex
+)
+
+// MeshIssueKinds は全ての問題の種類を返します
+func MeshIssueKinds() []MeshIssueKind {
+	return []MeshIssueKind{
+		MeshIssueInvalidIndex,
+		MeshIssueDegenerateTriangle,
+		MeshIssueDuplicateVertex,
+		MeshIssueFlippedTriangle,
+		MeshIssueHole,
+		MeshIssueTJunction,
+		MeshIssueNonManifoldEdge,
+		MeshIssueNonManifoldVertex,
+	}
+}
+
+func (k MeshIssueKind) String() string {
+	switch k {
+	case MeshIssueInvalidIndex:
+		return "invalid index"
+	case MeshIssueDegenerateTriangle:
+		return "degenerate triangle"
+	case MeshIssueDuplicateVertex:
+		return "duplicate vertex"
+	case MeshIssueFlippedTriangle:
+		return "flipped triangle"
+	case MeshIssueHole:
+		return "hole"
+	case MeshIssueTJunction:
+		return "T-junction"
+	case MeshIssueNonManifoldEdge:
+		return "non-manifold edge"
+	case MeshIssueNonManifoldVertex:
+		return "non-manifold vertex"
+	}
+	return "unknown"
+}
+
+// MeshIssue はメッシュの1つの問題です
+type MeshIssue struct {
+	Kind MeshIssueKind
+	// Triangles 問題のある三角形の番号
+	Triangles []int
+	// Vertices 問題のある頂点の添字番号
+	// 穴は輪を辿る順、T字の継ぎ目は辺の途中にある頂点・辺の始点・辺の終点の順です
+	Vertices []int
+	// Location 問題の位置（オブジェクト座標系）。問題のある頂点の重心です
+	// 範囲外の添字番号だけを含む三角形は原点です
+	Location Vector3D
+}
+
+// MeshReport はメッシュの検査結果です
+type MeshReport struct {
+	Issues []MeshIssue
+}
+
+// Count は種類がkindの問題の数を返します
+func (r MeshReport) Count(kind MeshIssueKind) int {
+	count := 0
+	for _, issue := range r.Issues {
+		if issue.Kind == kind {
+			count++
+		}
+	}
+	return count
+}
+
+// IsValid は問題が1つもないかを判定します
+func (r MeshReport) IsValid() bool {
+	return len(r.Issues) == 0
+}
+
+// Validate はメッシュの問題を検査します
+// 位置が同じ頂点（UVの継ぎ目など）は MergeTolerance でまとめて繋がり方を調べるので、継ぎ目は穴として報告しません
+// 開いたメッシュ（平面など）は外周が穴として報告されます
+// 頂点を持たないオブジェクト（Object{}など）は、三角形も持たなければ問題のないメッシュとして扱います
+func (o Object) Validate() MeshReport {
+	report := MeshReport{Issues: make([]MeshIssue, 0)}
+	if o.VertexMatrix.Dense == nil {
+		for i, triangle := range o.Triangles {
+			report.Issues = append(report.Issues, MeshIssue{Kind: MeshIssueInvalidIndex, Triangles: []int{i}, Vertices: triangle[:]})
+		}
+		return report
+	}
+	vertexCount := o.VertexMatrix.Len()
+
+	// 範囲外の添字番号を含む三角形と面積が0の三角形を除いて繋がり方を調べる
+	valid := Object{VertexMatrix: o.VertexMatrix, Triangles: make([][3]int, 0, len(o.Triangles))}
+	validSources := make([]int, 0, len(o.Triangles))
+	for i, triangle := range o.Triangles {
+		inRange := make([]int, 0, 3)
+		for _, index := range triangle {
+			if index >= 0 && index < vertexCount {
+				inRange = append(inRange, index)
+			}
+		}
+		if len(inRange) < 3 {
+			report.Issues = append(report.Issues, MeshIssue{
+				Kind:      MeshIssueInvalidIndex,
+				Triangles: []int{i},
+				Vertices:  triangle[:],
+				Location:  o.vertexCentroid(inRange),
+			})
+			continue
+		}
+
+		positions := [3]Vector3D{o.VertexMatrix.GetVertex(triangle[0]), o.VertexMatrix.GetVertex(triangle[1]), o.VertexMatrix.GetVertex(triangle[2])}
+		if triangle[0] == triangle[1] || triangle[1] == triangle[2] || triangle[2] == triangle[0] || IsDegenerateTriangle(positions) {
+			report.Issues = append(report.Issues, MeshIssue{
+				Kind:      MeshIssueDegenerateTriangle,
+				Triangles: []int{i},
+				Vertices:  triangle[:],
+				Location:  o.vertexCentroid(triangle[:]),
+			})
+			continue
+		}
+		valid.Triangles = append(valid.Triangles, triangle)
+		validSources = append(validSources, i)
+	}
+
+	report.Issues = append(report.Issues, o.duplicateVertexIssues()...)
+
+	if len(valid.Triangles) == 0 {
+		return report
+	}
+	m, faces := newHalfEdgeMesh(valid)
+	// source は半辺のメッシュの三角形の、元のオブジェクトの三角形の番号を返します
+	source := func(f int) int {
+		return validSources[faces[f]]
+	}
+	// faceSources は半辺の三角形の、元のオブジェクトの三角形の番号を重複なく返します
+	faceSources := func(halfEdges []int) []int {
+		triangles := make([]int, 0, len(halfEdges))
+		for _, h := range halfEdges {
+			if t := source(m.HalfEdges[h].Face); !slices.Contains(triangles, t) {
+				triangles = append(triangles, t)
+			}
+		}
+		return triangles
+	}
+
+	_, flipped := m.orientation()
+	for f, flip := range flipped {
+		if flip {
+			triangle := m.Triangle(f)
+			report.Issues = append(report.Issues, MeshIssue{
+				Kind:      MeshIssueFlippedTriangle,
+				Triangles: []int{source(f)},
+				Vertices:  triangle[:],
+				Location:  o.vertexCentroid(triangle[:]),
+			})
+		}
+	}
+
+	for _, loop := range m.boundaryLoopHalfEdges() {
+		vertices := make([]int, 0, len(loop))
+		for _, h := range loop {
+			vertices = append(vertices, m.HalfEdges[h].Origin)
+		}
+		report.Issues = append(report.Issues, MeshIssue{
+			Kind:      MeshIssueHole,
+			Triangles: faceSources(loop),
+			Vertices:  vertices,
+			Location:  o.vertexCentroid(vertices),
+		})
+	}
+
+	report.Issues = append(report.Issues, m.tJunctionIssues(source, o.MergeTolerance())...)
+
+	for _, key := range m.edges {
+		halfEdges := m.edgeHalfEdges[key]
+		if len(halfEdges) <= 2 {
+			continue
+		}
+		vertices := []int{m.HalfEdges[halfEdges[0]].Origin, m.Destination(halfEdges[0])}
+		report.Issues = append(report.Issues, MeshIssue{
+			Kind:      MeshIssueNonManifoldEdge,
+			Triangles: faceSources(halfEdges),
+			Vertices:  vertices,
+			Location:  o.vertexCentroid(vertices),
+		})
+	}
+
+	for _, v := range m.NonManifoldVertices() {
+		triangles := make([]int, 0)
+		for _, f := range m.VertexFaces(v) {
+			triangles = append(triangles, source(f))
+		}
+		report.Issues = append(report.Issues, MeshIssue{
+			Kind:      MeshIssueNonManifoldVertex,
+			Triangles: triangles,
+			Vertices:  []int{v},
+			Location:  m.Vertices[v],
+		})
+	}
+	return report
+}
+
+// vertexCentroid は頂点の重心を返します（頂点がない場合は原点）
+func (o Object) vertexCentroid(vertices []int) Vector3D {
+	centroid := Vector3D{}
+	if len(vertices) == 0 {
+		return centroid
+	}
+	for _, v := range vertices {
+		centroid = centroid.Add(o.VertexMatrix.GetVertex(v))
+	}
+	return centroid.MulScalar(1 / float64(len(vertices)))
+}
+
+// duplicateVertexIssues は位置と頂点属性が同じ頂点の組を、まとめる先の頂点ごとに返します
+func (o Object) duplicateVertexIssues() []MeshIssue {
+	grid := NewVertexGrid(o.MergeTolerance())
+	groups := make([][]int, 0, o.VertexMatrix.Len())
+	o.VertexMatrix.EachVertex(func(i int, v Vertex) bool {
+		merged := grid.AddVertexWithAttributes(v, o.VertexAttributeValues(i))
+		if merged == len(groups) {
+			groups = append(groups, make([]int, 0, 1))
+		}
+		groups[merged] = append(groups[merged], i)
+		return true
+	})
+
+	issues := make([]MeshIssue, 0)
+	for merged, group := range groups {
+		if len(group) > 1 {
+			issues = append(issues, MeshIssue{
+				Kind:     MeshIssueDuplicateVertex,
+				Vertices: group,
+				Location: grid.Vertices()[merged],
+			})
+		}
+	}
+	return issues
+}
+
+// tJunctionIssues は境界の辺の途中（両端から許容誤差より離れた位置）にある境界の頂点を返します
+// T字の継ぎ目の頂点は、その頂点を使わない隣の三角形の辺の上にあるので、両側の辺が境界になります
+func (m HalfEdgeMesh) tJunctionIssues(source func(f int) int, tolerance float64) []MeshIssue {
+	boundaryEdges := make([]int, 0)
+	boundaryVertices := make([]int, 0)
+	seen := make([]bool, len(m.outgoing))
+	for h := range m.HalfEdges {
+		if !m.IsBoundaryEdge(h) {
+			continue
+		}
+		boundaryEdges = append(boundaryEdges, h)
+		if origin := m.HalfEdges[h].Origin; !seen[m.welded[origin]] {
+			seen[m.welded[origin]] = true
+			boundaryVertices = append(boundaryVertices, origin)
+		}
+	}
+
+	issues := make([]MeshIssue, 0)
+	for _, h := range boundaryEdges {
+		from, to := m.HalfEdges[h].Origin, m.Destination(h)
+		a, b := m.Vertices[from], m.Vertices[to]
+		edge := b.Sub(a)
+		length := edge.Distance()
+		for _, v := range boundaryVertices {
+			if m.welded[v] == m.welded[from] || m.welded[v] == m.welded[to] {
+				continue
+			}
+			p := m.Vertices[v]
+			// 辺の上に投影した位置（始点からの距離）
+			t := p.Sub(a).Dot(edge) / length
+			if t <= tolerance || t >= length-tolerance {
+				continue
+			}
+			if p.DistanceTo(a.Add(edge.MulScalar(t/length))) < tolerance {
+				issues = append(issues, MeshIssue{
+					Kind:      MeshIssueTJunction,
+					Triangles: []int{source(m.HalfEdges[h].Face)},
+					Vertices:  []int{v, from, to},
+					Location:  p,
+				})
+			}
+		}
+	}
+	return issues
+}
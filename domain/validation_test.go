@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newWeldedCubeObject は8つの頂点を共有する、頂点属性のない箱を作ります
+func newWeldedCubeObject() Object {
+	o := NewCubeObject(1)
+	o.Attributes = nil
+	welded, _ := o.WeldVertices()
+	return welded
+}
+
+func TestObject_Validate_プリミティブ(t *testing.T) {
+	objects := map[string]Object{
+		"tetrahedron": NewTetrahedronObject(1),
+		"box":         NewCubeObject(1),
+		"sphere":      NewUVSphereObject(1, 16, 8),
+		"icosphere":   NewIcosphereObject(1, 2),
+		"cylinder":    NewCylinderObject(1, 2, 16),
+		"cone":        NewConeObject(1, 2, 16),
+		"capsule":     NewCapsuleObject(0.5, 1, 16, 4),
+		"torus":       NewTorusObject(1, 0.3, 16, 8),
+	}
+
+	for name, o := range objects {
+		report := o.Validate()
+		assert.True(t, report.IsValid(), "%s: %v", name, report.Issues)
+	}
+}
+
+func TestObject_Validate_問題の検出(t *testing.T) {
+	cube := newWeldedCubeObject()
+	assert.Equal(t, 8, cube.VertexMatrix.Len())
+	assert.True(t, cube.Validate().IsValid())
+
+	// 裏返った三角形
+	report := flipTriangles(cube, 4).Validate()
+	assert.Equal(t, 1, report.Count(MeshIssueFlippedTriangle))
+	assert.Len(t, report.Issues, 1)
+	assert.Equal(t, []int{4}, report.Issues[0].Triangles)
+
+	// 穴（1つの面の2つの三角形を取り除く）
+	holed := cube
+	holed.Triangles = cube.Triangles[2:]
+	holed.TriangleColors = cube.TriangleColors[2:]
+	report = holed.Validate()
+	assert.Equal(t, 1, report.Count(MeshIssueHole))
+	assert.Len(t, report.Issues, 1)
+	assert.Len(t, report.Issues[0].Vertices, 4)
+	// 穴の位置は取り除いた面の中心
+	removed := cube.vertexCentroid([]int{cube.Triangles[0][0], cube.Triangles[0][1], cube.Triangles[0][2], cube.Triangles[1][0]})
+	assert.InDelta(t, 0.5, report.Issues[0].Location.Distance(), 1e-9)
+	assert.Greater(t, removed.Dot(report.Issues[0].Location), 0.0)
+
+	// 面積が0の三角形と範囲外の添字番号
+	broken := cube
+	broken.Triangles = append(append([][3]int{}, cube.Triangles...), [3]int{0, 0, 1}, [3]int{0, 1, 99})
+	report = broken.Validate()
+	assert.Equal(t, 1, report.Count(MeshIssueDegenerateTriangle))
+	assert.Equal(t, 1, report.Count(MeshIssueInvalidIndex))
+	assert.Len(t, report.Issues, 2)
+	assert.Equal(t, []int{12}, report.Issues[0].Triangles)
+	assert.Equal(t, []int{13}, report.Issues[1].Triangles)
+
+	// 位置が同じ頂点（頂点属性がないので全てまとめられる）
+	split := NewCubeObject(1)
+	split.Attributes = nil
+	report = split.Validate()
+	assert.Equal(t, 8, report.Count(MeshIssueDuplicateVertex))
+	assert.Len(t, report.Issues, 8)
+	for _, issue := range report.Issues {
+		assert.Len(t, issue.Vertices, 3)
+		assert.InDelta(t, 0.5*1.7320508075688772, issue.Location.Distance(), 1e-9)
+	}
+}
+
+func TestObject_Validate_T字の継ぎ目(t *testing.T) {
+	// 左の正方形の右辺の途中に、右の三角形の頂点(1, 1)がある
+	vertices := []Vector3D{{0, 0, 0}, {1, 0, 0}, {1, 2, 0}, {0, 2, 0}, {2, 0, 0}, {2, 2, 0}, {1, 1, 0}}
+	o := newTrianglesObject(vertices, [][3]int{
+		{0, 2, 1}, {0, 3, 2},
+		{1, 6, 4}, {6, 5, 4}, {6, 2, 5},
+	})
+
+	report := o.Validate()
+
+	assert.Equal(t, 1, report.Count(MeshIssueTJunction))
+	assert.Equal(t, 0, report.Count(MeshIssueFlippedTriangle))
+	for _, issue := range report.Issues {
+		if issue.Kind == MeshIssueTJunction {
+			assert.Equal(t, 6, issue.Vertices[0])
+			assert.ElementsMatch(t, []int{1, 2}, issue.Vertices[1:])
+			assert.Equal(t, []int{0}, issue.Triangles)
+			assert.Equal(t, Vector3D{1, 1, 0}, issue.Location)
+		}
+	}
+}
+
+func TestObject_Validate_多様体ではないメッシュ(t *testing.T) {
+	vertices := []Vector3D{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {0, -1, 0}, {0, 0, 1}, {-1, 0, 0}, {-1, -1, 0}}
+
+	fin := newTrianglesObject(vertices, [][3]int{{0, 1, 2}, {1, 0, 3}, {0, 1, 4}})
+	report := fin.Validate()
+	assert.Equal(t, 1, report.Count(MeshIssueNonManifoldEdge))
+	for _, issue := range report.Issues {
+		if issue.Kind == MeshIssueNonManifoldEdge {
+			assert.Equal(t, []int{0, 1}, issue.Vertices)
+			assert.Equal(t, []int{0, 1, 2}, issue.Triangles)
+			assert.Equal(t, Vector3D{0.5, 0, 0}, issue.Location)
+		}
+	}
+
+	bowtie := newTrianglesObject(vertices, [][3]int{{0, 1, 2}, {0, 5, 6}})
+	report = bowtie.Validate()
+	assert.Equal(t, 1, report.Count(MeshIssueNonManifoldVertex))
+	assert.Equal(t, 2, report.Count(MeshIssueHole))
+}
+
+func TestObject_Validate_頂点がないオブジェクト(t *testing.T) {
+	assert.True(t, Object{}.Validate().IsValid())
+	// 同じ箱の差は頂点のないオブジェクトになる
+	assert.True(t, NewCubeObject(1).Difference(NewCubeObject(1)).Validate().IsValid())
+
+	report := Object{Triangles: [][3]int{{0, 1, 2}}}.Validate()
+	assert.Equal(t, 1, report.Count(MeshIssueInvalidIndex))
+}
+
+func TestMeshIssueKind_String(t *testing.T) {
+	for _, kind := range MeshIssueKinds() {
+		assert.NotEqual(t, "unknown", kind.String())
+	}
+	assert.Equal(t, "T-junction", MeshIssueTJunction.String())
+}
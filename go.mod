@@ -4,6 +4,7 @@ go 1.24.0
 
 require (
 	github.com/fogleman/gg v1.3.0
+	github.com/hajimehoshi/ebiten/v2 v2.9.3
 	github.com/stretchr/testify v1.11.1
 	gonum.org/v1/gonum v0.14.0
 )
@@ -14,7 +15,6 @@ require (
 	github.com/ebitengine/hideconsole v1.0.0 // indirect
 	github.com/ebitengine/purego v0.9.0 // indirect
 	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
-	github.com/hajimehoshi/ebiten/v2 v2.9.3 // indirect
 	github.com/jezek/xgb v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/samber/lo v1.52.0 // indirect
@@ -1,10 +1,19 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"image"
 	"image/color"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
 	"log"
 	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
@@ -13,11 +22,14 @@ import (
 
 const (
 	width, height int32 = 800, 600
+	// spinPeriod 四面体が1回転する時間
+	spinPeriod = time.Second
 )
 
 type Game struct {
 	world      domain.World
 	frameCount int
+	startTime  time.Time
 }
 
 func (g *Game) Update() error {
@@ -58,10 +70,9 @@ func (g *Game) Update() error {
 func (g *Game) Draw(screen *ebiten.Image) {
 	g.frameCount++
 
-	g.world.LocatedObjects[0].Rotation[1] += 0.1
-	g.world.LocatedObjects[0].Rotation[0] += 0.1
-
-	frameBuffer := g.world.Transform()
+	// 経過時間でアニメーションを評価する（フレームレートに依存しない）
+	elapsed := time.Since(g.startTime) % spinPeriod
+	frameBuffer, stats := g.world.At(elapsed).TransformWithStats()
 
 	// 画面をクリア（背景色を白に設定）
 	screen.Fill(color.RGBA{255, 255, 255, 255})
@@ -70,16 +81,25 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		screen.Set(int(key.X), int(key.Y), value.Color)
 	}
 
-	// FPSを表示
-	ebitenutil.DebugPrint(screen, fmt.Sprintf("FPS: %0.2f", ebiten.ActualFPS()))
+	// FPSと視錐台カリングの統計を表示
+	ebitenutil.DebugPrint(screen, fmt.Sprintf("FPS: %0.2f\nObjects: %d (culled: %d, inside: %d, clipped: %d)\nCulled faces: %d",
+		ebiten.ActualFPS(), stats.Objects, stats.Culled, stats.Inside, stats.Clipped, stats.CulledFaces))
 }
 
 func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
 	return int(width), int(height)
 }
 
-func main() {
-	world := domain.World{
+// newDoubleSidedPlane は裏からも見える（両面を描画する）灰色の平面を作成します
+func newDoubleSidedPlane(width, height float64) domain.Object {
+	plane := domain.NewPlaneObject(width, height, color.RGBA{50, 50, 50, 255})
+	plane.CullMode = domain.CullNone
+	return plane
+}
+
+// newWorld はウィンドウに表示するワールドを作成します
+func newWorld() domain.World {
+	return domain.World{
 		Camera: domain.Camera{
 			Location:  domain.Vector3D{0, 0, 0},
 			Direction: domain.Vector3D{0, 0, 0},
@@ -90,24 +110,30 @@ func main() {
 				Scale:    domain.Vector3D{1.0, 1.0, 1.0},
 				Rotation: domain.Vector3D{0.0, 0.0, 0.0},
 				Object:   domain.NewTetrahedronObject(0.15),
+				Animation: &domain.ObjectAnimation{
+					Rotation: domain.Track[domain.Vector3D]{
+						{Time: 0, Value: domain.Vector3D{0, 0, 0}},
+						{Time: spinPeriod, Value: domain.Vector3D{2 * math.Pi, 2 * math.Pi, 0}},
+					},
+				},
 			},
 			{
 				Location: domain.Vector3D{0.0, 0.0, 2.5},
 				Scale:    domain.Vector3D{1.0, 1.0, 1.0},
 				Rotation: domain.Vector3D{0.0, 0.0, 0.0},
-				Object:   domain.NewPlaneObject(0.3, 0.3, color.RGBA{50, 50, 50, 255}),
+				Object:   newDoubleSidedPlane(0.3, 0.3),
 			},
 			{
 				Location: domain.Vector3D{-0.2, 0.0, 2},
 				Scale:    domain.Vector3D{1.0, 1.0, 1.0},
 				Rotation: domain.Vector3D{0.0, -math.Pi / 2.0, 0.0},
-				Object:   domain.NewPlaneObject(0.3, 0.3, color.RGBA{50, 50, 50, 255}),
+				Object:   newDoubleSidedPlane(0.3, 0.3),
 			},
 			{
 				Location: domain.Vector3D{0.2, 0.0, 2},
 				Scale:    domain.Vector3D{1.0, 1.0, 1.0},
 				Rotation: domain.Vector3D{0.0, math.Pi / 2.0, 0.0},
-				Object:   domain.NewPlaneObject(0.3, 0.3, color.RGBA{50, 50, 50, 255}),
+				Object:   newDoubleSidedPlane(0.3, 0.3),
 			},
 		},
 		Viewport: domain.Viewport{
@@ -121,9 +147,393 @@ func main() {
 			FieldOfView:  math.Pi / 4,
 		},
 	}
+}
+
+// primitiveColors はプリミティブの面に順番に割り当てる色です
+var primitiveColors = []color.RGBA{
+	{255, 0, 0, 255},
+	{0, 255, 0, 255},
+	{0, 0, 255, 255},
+	{255, 255, 0, 255},
+	{0, 255, 255, 255},
+	{255, 0, 255, 255},
+}
+
+// vaseProfile は回転体で作る花瓶の輪郭線（下から上）です
+var vaseProfile = []domain.Vector2D{
+	{0, -0.4}, {0.2, -0.4}, {0.3, -0.2}, {0.25, 0.1}, {0.12, 0.3}, {0.15, 0.4},
+}
+
+// starPolygon は星型の多角形を作ります
+func starPolygon(points int, outer, inner float64) []domain.Vector2D {
+	polygon := make([]domain.Vector2D, 0, points*2)
+	for i := 0; i < points*2; i++ {
+		radius := outer
+		if i%2 == 1 {
+			radius = inner
+		}
+		angle := math.Pi/2 + math.Pi*float64(i)/float64(points)
+		polygon = append(polygon, domain.Vector2D{radius * math.Cos(angle), radius * math.Sin(angle)})
+	}
+	return polygon
+}
+
+// circlePolygon は円を近似した多角形を作ります
+func circlePolygon(segments int, radius float64) []domain.Vector2D {
+	polygon := make([]domain.Vector2D, 0, segments)
+	for i := 0; i < segments; i++ {
+		angle := 2 * math.Pi * float64(i) / float64(segments)
+		polygon = append(polygon, domain.Vector2D{radius * math.Cos(angle), radius * math.Sin(angle)})
+	}
+	return polygon
+}
+
+// springPath はY軸の周りをturns回巻くばねの経路を作ります（高さの中心は原点）
+func springPath(turns int, radius, height float64, points int) []domain.Vector3D {
+	path := make([]domain.Vector3D, 0, points+1)
+	for i := 0; i <= points; i++ {
+		t := float64(i) / float64(points)
+		angle := 2 * math.Pi * float64(turns) * t
+		path = append(path, domain.Vector3D{radius * math.Cos(angle), height * (t - 0.5), radius * math.Sin(angle)})
+	}
+	return path
+}
+
+// terrainColorRamp は地形の高さ（0から1）に割り当てる色です
+var terrainColorRamp = domain.ColorRamp{
+	{Height: 0.0, Color: color.RGBA{30, 60, 160, 255}},
+	{Height: 0.3, Color: color.RGBA{220, 200, 140, 255}},
+	{Height: 0.5, Color: color.RGBA{60, 140, 50, 255}},
+	{Height: 0.8, Color: color.RGBA{120, 100, 80, 255}},
+	{Height: 1.0, Color: color.RGBA{250, 250, 250, 255}},
+}
+
+// marbleTexture は"marble"のモデルに貼る大理石の模様です
+var marbleTexture = domain.ProceduralTexture{
+	Pattern: domain.MarblePattern{Noise: domain.NewNoise(1), Stripes: 3, Turbulence: 4, Frequency: 2},
+	ColorRamp: domain.ColorRamp{
+		{Height: 0.0, Color: color.RGBA{60, 60, 70, 255}},
+		{Height: 0.6, Color: color.RGBA{200, 200, 205, 255}},
+		{Height: 1.0, Color: color.RGBA{245, 245, 240, 255}},
+	},
+	Space: domain.TextureSpaceObject,
+}
+
+// woodTexture は"wood"のモデルに貼る木目の模様です
+var woodTexture = domain.ProceduralTexture{
+	Pattern: domain.WoodPattern{Noise: domain.NewNoise(2), Rings: 12, Turbulence: 0.05, Frequency: 3},
+	ColorRamp: domain.ColorRamp{
+		{Height: 0.0, Color: color.RGBA{110, 60, 25, 255}},
+		{Height: 1.0, Color: color.RGBA{200, 140, 80, 255}},
+	},
+	Space: domain.TextureSpaceObject,
+}
+
+// loadHeightmap はグレースケール画像から高さマップを読み込みます
+// pathが空の場合は波打つ地形の高さマップを作ります
+func loadHeightmap(path string) (domain.Heightmap, error) {
+	if path == "" {
+		const size = 64
+		grid := make([][]float64, size)
+		for row := range grid {
+			grid[row] = make([]float64, size)
+			for column := range grid[row] {
+				x, z := float64(column)/size*2*math.Pi, float64(row)/size*2*math.Pi
+				grid[row][column] = 0.5 + 0.25*math.Sin(x)*math.Cos(z) + 0.15*math.Sin(2*x+z)
+			}
+		}
+		_, heightmap := domain.NewHeightmap(grid)
+		return heightmap, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return domain.Heightmap{}, err
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return domain.Heightmap{}, err
+	}
+	return domain.NewHeightmapFromImage(img), nil
+}
+
+// newModelWorld はターンテーブルでレンダリングするワールドを作成します
+// modelが"scene"の場合はウィンドウに表示するワールド、それ以外は原点に置いた単体のオブジェクトになります
+// heightmapは"terrain"で使う高さマップの画像です（空の場合は波打つ地形）
+func newModelWorld(model, heightmap string) (domain.World, error) {
+	world := newWorld()
+	var obj domain.Object
+	switch model {
+	case "scene":
+		return world, nil
+	case "tetrahedron":
+		obj = domain.NewTetrahedronObject(0.5)
+	case "plane":
+		obj = newDoubleSidedPlane(1, 1)
+	case "box":
+		obj = domain.NewCubeObject(0.7, primitiveColors...)
+	case "sphere":
+		obj = domain.NewUVSphereObject(0.5, 24, 12, primitiveColors...)
+	case "icosphere":
+		obj = domain.NewIcosphereObject(0.5, 2, primitiveColors...)
+	case "cylinder":
+		obj = domain.NewCylinderObject(0.4, 0.8, 24, primitiveColors...)
+	case "cone":
+		obj = domain.NewConeObject(0.4, 0.8, 24, primitiveColors...)
+	case "capsule":
+		obj = domain.NewCapsuleObject(0.3, 0.5, 24, 6, primitiveColors...)
+	case "torus":
+		obj = domain.NewTorusObject(0.4, 0.15, 32, 12, primitiveColors...)
+	case "vase":
+		obj = domain.NewLatheObject(vaseProfile, 24, primitiveColors...)
+	case "star":
+		obj = domain.NewExtrudeObject(starPolygon(5, 0.5, 0.2), 0.2, true, primitiveColors...)
+	case "spring":
+		obj = domain.NewSweepObject(circlePolygon(8, 0.05), springPath(3, 0.3, 0.6, 96), domain.SweepRotationMinimizing, true, primitiveColors...)
+	case "csg":
+		// 箱から球を削り、中心に小さな球を加える
+		carved := domain.NewCubeObject(0.7, primitiveColors...).Difference(domain.NewUVSphereObject(0.45, 24, 12, color.RGBA{255, 255, 255, 255}))
+		obj = carved.Union(domain.NewIcosphereObject(0.2, 2, color.RGBA{255, 128, 0, 255}))
+	case "marble":
+		obj = domain.NewIcosphereObject(0.5, 3)
+		obj.Texture = marbleTexture
+	case "wood":
+		obj = domain.NewCylinderObject(0.4, 0.8, 32)
+		obj.Texture = woodTexture
+	case "terrain":
+		h, err := loadHeightmap(heightmap)
+		if err != nil {
+			return domain.World{}, err
+		}
+		// 長い方の辺が1になるように間隔を決める
+		spacing := 1 / float64(max(h.Columns, h.Rows)-1)
+		obj = domain.NewTerrainObject(h, domain.Terrain{Spacing: spacing, HeightScale: 0.2, ColorRamp: terrainColorRamp})
+	default:
+		return domain.World{}, fmt.Errorf("unknown model: %s", model)
+	}
+	return newObjectWorld(obj), nil
+}
+
+// newObjectWorld はウィンドウに表示するワールドのオブジェクトを、原点に置いた1つのオブジェクトに置き換えます
+func newObjectWorld(obj domain.Object) domain.World {
+	world := newWorld()
+	world.LocatedObjects = []domain.LocatedObject{
+		{
+			Scale:  domain.Vector3D{1.0, 1.0, 1.0},
+			Object: obj,
+		},
+	}
+	return world
+}
+
+// loadModelWorld はターンテーブルでレンダリングするワールドを読み込みます
+// inを指定した場合はOBJファイルのオブジェクトを原点に置いたワールド、それ以外はmodelのワールド（newModelWorld）です
+func loadModelWorld(in, model, heightmap string) (domain.World, error) {
+	if in == "" {
+		return newModelWorld(model, heightmap)
+	}
+	obj, err := domain.LoadOBJ(in)
+	if err != nil {
+		return domain.World{}, err
+	}
+	return newObjectWorld(obj), nil
+}
+
+// meshOptions はモデルのメッシュに施す処理です
+type meshOptions struct {
+	// Subdivide 細分割する回数（0の場合は細分割しない）
+	Subdivide int
+	// Scheme 細分割の方法。"loop"（Loop細分割）か"catmull-clark"（Catmull-Clark細分割）
+	Scheme string
+	// Decimate 簡略化した後の三角形の数（0の場合は簡略化しない）
+	Decimate int
+}
+
+// applyMeshOptions はワールドの全てのオブジェクトを細分割・簡略化します
+func applyMeshOptions(world domain.World, options meshOptions) (domain.World, error) {
+	objects := make([]domain.LocatedObject, 0, len(world.LocatedObjects))
+	for _, lObj := range world.LocatedObjects {
+		if options.Subdivide > 0 {
+			switch options.Scheme {
+			case "loop":
+				lObj.Object = lObj.Object.SubdivideLoop(options.Subdivide)
+			case "catmull-clark":
+				lObj.Object = lObj.Object.SubdivideCatmullClark(options.Subdivide)
+			default:
+				return domain.World{}, fmt.Errorf("unknown subdivision scheme: %s", options.Scheme)
+			}
+		}
+		lObj.Object = lObj.Object.Decimate(domain.Decimation{TargetTriangles: options.Decimate})
+		objects = append(objects, lObj)
+	}
+	world.LocatedObjects = objects
+	return world, nil
+}
+
+// renderTurntable はモデルの周りを1周するフレームをレンダリングしてファイルに保存します
+// inを指定した場合はOBJファイルのメッシュ、それ以外はmodelをレンダリングします
+// outの拡張子が.gifならアニメーションGIF、.pngならAPNG、%を含む場合は連番PNGになります
+func renderTurntable(in, model, heightmap string, options meshOptions, tt domain.Turntable, out string, dither bool) error {
+	if tt.Frames <= 0 {
+		return fmt.Errorf("frames must be positive: %d", tt.Frames)
+	}
+	if !(tt.FPS > 0) {
+		return fmt.Errorf("fps must be positive: %v", tt.FPS)
+	}
+
+	world, err := loadModelWorld(in, model, heightmap)
+	if err != nil {
+		return err
+	}
+	world, err = applyMeshOptions(world, options)
+	if err != nil {
+		return err
+	}
+
+	ok, sequence := world.RenderTurntable(tt)
+	if !ok {
+		if in != "" {
+			model = in
+		}
+		return fmt.Errorf("model has no vertices: %s", model)
+	}
+
+	switch {
+	case strings.Contains(out, "%"):
+		return sequence.SavePNGSequence(out)
+	case strings.EqualFold(filepath.Ext(out), ".gif"):
+		return sequence.SaveGIF(out, dither)
+	case strings.EqualFold(filepath.Ext(out), ".png"):
+		return sequence.SaveAPNG(out)
+	}
+	return fmt.Errorf("unsupported output: %s", out)
+}
+
+// maxListedIssues は種類ごとに位置を表示する問題の数の上限です
+const maxListedIssues = 10
+
+// loadMeshObject は検査・修復するオブジェクトを読み込みます
+// inを指定した場合はOBJファイル、それ以外はmodelのオブジェクトです
+func loadMeshObject(in, model string) (domain.Object, error) {
+	if in != "" {
+		return domain.LoadOBJ(in)
+	}
+	world, err := newModelWorld(model, "")
+	if err != nil {
+		return domain.Object{}, err
+	}
+	if len(world.LocatedObjects) != 1 {
+		return domain.Object{}, fmt.Errorf("model has %d objects: %s", len(world.LocatedObjects), model)
+	}
+	return world.LocatedObjects[0].Object, nil
+}
+
+// printMeshReport はメッシュの検査結果を種類ごとに書き出します
+func printMeshReport(w io.Writer, report domain.MeshReport) {
+	if report.IsValid() {
+		fmt.Fprintln(w, "no issues")
+		return
+	}
+	for _, kind := range domain.MeshIssueKinds() {
+		count := report.Count(kind)
+		if count == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s: %d\n", kind, count)
+		listed := 0
+		for _, issue := range report.Issues {
+			if issue.Kind != kind {
+				continue
+			}
+			if listed == maxListedIssues {
+				fmt.Fprintf(w, "  ... %d more\n", count-listed)
+				break
+			}
+			fmt.Fprintf(w, "  at (%.4g, %.4g, %.4g) triangles %v vertices %v\n",
+				issue.Location[0], issue.Location[1], issue.Location[2], issue.Triangles, issue.Vertices)
+			listed++
+		}
+	}
+}
+
+// runMeshCommand はメッシュを検査（validate）・修復（repair）するサブコマンドを実行します
+func runMeshCommand(command string, args []string) error {
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	in := flags.String("in", "", "読み込むOBJファイル")
+	model := flags.String("model", "tetrahedron", "inを指定しない場合に検査するモデル（-turntableの-modelと同じ）")
+	out := flags.String("out", "repaired.obj", "修復したメッシュを保存するOBJファイル（repairのみ）")
+	maxHoleEdges := flags.Int("max-hole-edges", 8, "塞ぐ穴の境界の辺の数の上限（repairのみ。0の場合は塞がない）")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	o, err := loadMeshObject(*in, *model)
+	if err != nil {
+		return err
+	}
+	report := o.Validate()
+	printMeshReport(os.Stdout, report)
+
+	switch command {
+	case "validate":
+		if !report.IsValid() {
+			return fmt.Errorf("mesh has %d issues", len(report.Issues))
+		}
+		return nil
+	case "repair":
+		repaired, stats := o.Repair(domain.MeshRepair{Weld: true, RemoveDegenerate: true, UnifyWinding: true, MaxHoleEdges: *maxHoleEdges})
+		fmt.Printf("repaired: removed %d triangles, welded %d vertices, flipped %d triangles, filled %d holes\n",
+			stats.RemovedTriangles, stats.WeldedVertices, stats.FlippedTriangles, stats.FilledHoles)
+		printMeshReport(os.Stdout, repaired.Validate())
+		return repaired.SaveOBJ(*out)
+	}
+	return fmt.Errorf("unknown command: %s", command)
+}
+
+func main() {
+	// サブコマンド（validate, repair）
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "validate", "repair":
+			if err := runMeshCommand(os.Args[1], os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
+
+	turntable := flag.Bool("turntable", false, "モデルの周りを1周するフレームをレンダリングしてファイルに保存する")
+	in := flag.String("in", "", "ターンテーブルでレンダリングするOBJファイル（指定した場合はmodelより優先する）")
+	model := flag.String("model", "scene", "ターンテーブルでレンダリングするモデル（scene, tetrahedron, plane, box, sphere, icosphere, cylinder, cone, capsule, torus, vase, star, spring, terrain, marble, wood, csg）")
+	heightmap := flag.String("heightmap", "", "terrainの高さマップにするグレースケール画像（PNG, JPEG）")
+	subdivide := flag.Int("subdivide", 0, "モデルを細分割する回数")
+	scheme := flag.String("scheme", "loop", "細分割の方法（loop, catmull-clark）")
+	decimate := flag.Int("decimate", 0, "モデルを簡略化した後の三角形の数（0の場合は簡略化しない）")
+	frames := flag.Int("frames", 36, "1周のフレーム数")
+	fps := flag.Float64("fps", 12, "1秒あたりのフレーム数")
+	elevation := flag.Float64("elevation", 20, "カメラの仰角(単位：度)")
+	margin := flag.Float64("margin", 0.1, "境界球の半径に対する余白の割合")
+	out := flag.String("out", "turntable.gif", "出力先（.gif: アニメーションGIF, .png: APNG, %を含む場合: 連番PNG 例: frame_%04d.png）")
+	dither := flag.Bool("dither", false, "GIFの減色時にディザリングする")
+	flag.Parse()
+
+	if *turntable {
+		tt := domain.Turntable{
+			Frames:    *frames,
+			FPS:       *fps,
+			Elevation: *elevation * math.Pi / 180,
+			Margin:    *margin,
+		}
+		if err := renderTurntable(*in, *model, *heightmap, meshOptions{Subdivide: *subdivide, Scheme: *scheme, Decimate: *decimate}, tt, *out, *dither); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
 	game := &Game{
-		world: world,
+		world:     newWorld(),
+		startTime: time.Now(),
 	}
 
 	ebiten.SetWindowSize(int(width), int(height))